@@ -3,8 +3,13 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,8 +19,10 @@ import (
 	"lazytest/internal/lt"
 	"lazytest/internal/plan"
 	"lazytest/internal/report"
+	"lazytest/internal/secrets"
 	"lazytest/internal/tcp"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -35,6 +42,23 @@ var (
 	envA        string
 	envB        string
 	verbose     bool
+	expectFlag  string
+	contract    bool
+	failOn      string
+	fuzzFailOn  string
+	diffFailOn  string
+	seed        int64
+	exampleName string
+	failUnder   float64
+	authProfile string
+	anonymous   bool
+	rateLimit   int
+	htmlPath    string
+	failOnDiff  bool
+	authA       string
+	authB       string
+	baselineURL string
+	candidate   string
 )
 
 func main() {
@@ -45,15 +69,16 @@ func main() {
 			return cmd.Help()
 		},
 	}
-	root.PersistentFlags().StringVarP(&openAPIPath, "file", "f", "", "OpenAPI spec file (yaml/json)")
+	root.PersistentFlags().StringVarP(&openAPIPath, "file", "f", "", "OpenAPI spec file (yaml/json), directory of specs or http(s) URL")
 	root.PersistentFlags().StringVarP(&envName, "env", "e", "dev", "Environment name (dev|test|prod)")
 	root.PersistentFlags().StringVar(&baseURL, "base", "", "Base URL (overrides env config)")
 	root.PersistentFlags().StringVar(&envFile, "env-config", "env.yaml", "env.yaml path")
 	root.PersistentFlags().StringVar(&authFile, "auth-config", "auth.yaml", "auth.yaml path")
+	root.PersistentFlags().StringVar(&authProfile, "auth-profile", "default-jwt", "auth.yaml profile for operations without a mapped security scheme")
 	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose logs")
 
 	loadCmd := &cobra.Command{Use: "load", Short: "Load OpenAPI spec and print summary", RunE: runLoad}
-	loadCmd.Flags().StringVarP(&openAPIPath, "file", "f", "", "OpenAPI spec file")
+	loadCmd.Flags().StringVarP(&openAPIPath, "file", "f", "", "OpenAPI spec file, directory or http(s) URL")
 	loadCmd.MarkFlagRequired("file")
 
 	runCmd := &cobra.Command{Use: "run", Short: "Run smoke, drift or tcp tests"}
@@ -62,24 +87,69 @@ func main() {
 	smokeCmd.Flags().StringVar(&reportPath, "report", "junit.xml", "JUnit XML output path")
 	smokeCmd.Flags().StringVar(&jsonPath, "json", "out.json", "JSON report output path")
 	smokeCmd.Flags().IntVar(&workers, "workers", 10, "Number of workers")
+	smokeCmd.Flags().StringVar(&expectFlag, "expect", core.ExpectDocumented, "Status expectation policy (documented|strict|legacy)")
+	smokeCmd.Flags().BoolVar(&contract, "contract", false, "Also validate response bodies against the schema (drift)")
+	smokeCmd.Flags().StringVar(&failOn, "fail-on", "none", "With --contract: exit non-zero on drift of this severity (breaking|warning|none)")
+	smokeCmd.Flags().Int64Var(&seed, "seed", 0, "Seed for generated example params and bodies (same seed, same payloads)")
+	smokeCmd.Flags().StringVar(&exampleName, "example", "", "Use this named example from the spec when present")
+	smokeCmd.Flags().BoolVar(&anonymous, "anonymous", false, "Send no credentials; operations requiring auth pass only on 401/403")
 	runCmd.AddCommand(smokeCmd)
 
-	driftCmd := &cobra.Command{Use: "drift", Short: "Run contract drift check", RunE: runDrift}
-	driftCmd.Flags().StringVar(&pathFlag, "path", "", "Path to check (e.g. /customers)")
-	driftCmd.Flags().StringVar(&methodFlag, "method", "GET", "HTTP method")
+	driftCmd := &cobra.Command{Use: "drift", Short: "Run contract drift check (one endpoint with --path, else the whole spec)", RunE: runDrift}
+	driftCmd.Flags().StringVar(&pathFlag, "path", "", "Path to check (e.g. /customers); empty checks every endpoint")
+	driftCmd.Flags().StringVar(&methodFlag, "method", "GET", "HTTP method (in bulk mode only applied when set explicitly)")
+	driftCmd.Flags().StringVar(&tags, "tags", "", "Bulk mode: comma-separated tag filter")
+	driftCmd.Flags().IntVar(&workers, "workers", 10, "Bulk mode: number of workers")
+	driftCmd.Flags().StringVar(&reportPath, "report", "drift.junit.xml", "JUnit XML output path")
+	driftCmd.Flags().StringVar(&jsonPath, "json", "drift.json", "JSON report output path")
+	driftCmd.Flags().StringVar(&failOn, "fail-on", "none", "Exit non-zero on findings of this severity (breaking|warning|none)")
 	runCmd.AddCommand(driftCmd)
 
+	fuzzCmd := &cobra.Command{Use: "fuzz", Short: "Send schema-derived invalid requests and expect documented 4xx (never 5xx)", RunE: runFuzz}
+	fuzzCmd.Flags().StringVar(&pathFlag, "path", "", "Only fuzz this path")
+	fuzzCmd.Flags().StringVar(&methodFlag, "method", "", "Comma-separated method filter")
+	fuzzCmd.Flags().StringVar(&tags, "tags", "", "Comma-separated tag filter")
+	fuzzCmd.Flags().IntVar(&workers, "workers", 4, "Number of workers")
+	fuzzCmd.Flags().Int64Var(&seed, "seed", 0, "Mutation seed (0 picks one; printed for replay)")
+	fuzzCmd.Flags().StringVar(&reportPath, "report", "fuzz.junit.xml", "JUnit XML output path")
+	fuzzCmd.Flags().StringVar(&jsonPath, "json", "fuzz.json", "JSON report output path")
+	fuzzCmd.Flags().StringVar(&fuzzFailOn, "fail-on", "failure", "Exit non-zero on failed mutations: failure (any), 5xx (server errors only) or none")
+	runCmd.AddCommand(fuzzCmd)
+
 	tcpCmd := &cobra.Command{Use: "tcp", Short: "Run TCP plan", RunE: runTCP}
 	tcpCmd.Flags().StringVar(&openAPIPath, "plan", "plans/tcp.yaml", "TCP plan YAML path")
 	tcpCmd.Flags().StringVar(&reportPath, "report", "junit.xml", "JUnit XML output path")
 	tcpCmd.Flags().StringVar(&jsonPath, "json", "out.json", "JSON report output path")
 	runCmd.AddCommand(tcpCmd)
 
-	compareCmd := &cobra.Command{Use: "compare", Short: "A/B compare two environments", RunE: runCompare}
+	compareCmd := &cobra.Command{Use: "compare", Short: "A/B compare two environments (one endpoint with --path, else every GET endpoint)", RunE: runCompare}
 	compareCmd.Flags().StringVar(&envA, "envA", "dev", "First environment")
 	compareCmd.Flags().StringVar(&envB, "envB", "test", "Second environment")
-	compareCmd.Flags().StringVar(&pathFlag, "path", "", "Path to compare")
-	compareCmd.Flags().StringVar(&methodFlag, "method", "GET", "HTTP method")
+	compareCmd.Flags().StringVar(&authA, "authA", "", "auth.yaml profile for envA (default --auth-profile)")
+	compareCmd.Flags().StringVar(&authB, "authB", "", "auth.yaml profile for envB (default --auth-profile)")
+	compareCmd.Flags().StringVar(&pathFlag, "path", "", "Path to compare; empty compares every endpoint matching --method/--tags")
+	compareCmd.Flags().StringVar(&methodFlag, "method", "GET", "HTTP method (bulk mode: comma-separated filter)")
+	compareCmd.Flags().StringVar(&tags, "tags", "", "Bulk mode: comma-separated tag filter")
+	compareCmd.Flags().IntVar(&workers, "workers", 4, "Bulk mode: number of workers")
+	compareCmd.Flags().IntVar(&rateLimit, "rps", 5, "Bulk mode: endpoints compared per second")
+	compareCmd.Flags().StringVar(&reportPath, "report", "", "JUnit XML output path (one testcase per endpoint)")
+	compareCmd.Flags().StringVar(&jsonPath, "json", "", "JSON report output path")
+	compareCmd.Flags().StringVar(&htmlPath, "html", "", "HTML side-by-side diff output path")
+	compareCmd.Flags().BoolVar(&failOnDiff, "fail-on-diff", false, "Exit non-zero when any endpoint differs or errors (release gate)")
+	replayCmd := &cobra.Command{Use: "replay <capture>", Short: "Replay recorded traffic (HAR or JSON Lines) on a baseline and a candidate and diff the responses", Args: cobra.ExactArgs(1), RunE: runReplay}
+	replayCmd.Flags().StringVar(&envA, "envA", "dev", "Baseline environment (headers, auth, compare rules)")
+	replayCmd.Flags().StringVar(&envB, "envB", "test", "Candidate environment")
+	replayCmd.Flags().StringVar(&baselineURL, "baseline", "", "Baseline base URL (overrides envA)")
+	replayCmd.Flags().StringVar(&candidate, "candidate", "", "Candidate base URL (overrides envB)")
+	replayCmd.Flags().StringVar(&authA, "authA", "", "auth.yaml profile for the baseline (default --auth-profile)")
+	replayCmd.Flags().StringVar(&authB, "authB", "", "auth.yaml profile for the candidate (default --auth-profile)")
+	replayCmd.Flags().IntVar(&workers, "workers", 4, "Number of workers")
+	replayCmd.Flags().IntVar(&rateLimit, "rps", 5, "Recorded requests replayed per second")
+	replayCmd.Flags().StringVar(&reportPath, "report", "", "JUnit XML output path (one testcase per request)")
+	replayCmd.Flags().StringVar(&jsonPath, "json", "", "JSON report output path (with per-endpoint aggregation)")
+	replayCmd.Flags().StringVar(&htmlPath, "html", "", "HTML report output path")
+	replayCmd.Flags().BoolVar(&failOnDiff, "fail-on-diff", false, "Exit non-zero when any request differs or errors (release gate)")
+	compareCmd.AddCommand(replayCmd)
 
 	ltCmd := &cobra.Command{Use: "lt", Short: "Run Taurus YAML plan (headless)", RunE: runLT}
 	ltCmd.Flags().StringVarP(&openAPIPath, "file", "f", "", "Taurus plan YAML")
@@ -91,9 +161,27 @@ func main() {
 	planEditCmd := &cobra.Command{Use: "edit <path>", Short: "Edit plan with $EDITOR", Args: cobra.ExactArgs(1), RunE: runPlanEdit}
 	planCmd.AddCommand(planNewCmd, planEditCmd)
 
+	coverageCmd := &cobra.Command{Use: "coverage <report.json>...", Short: "Spec coverage (operations, responses, params, tags) of JSON run reports", Args: cobra.MinimumNArgs(1), RunE: runCoverage}
+	coverageCmd.Flags().StringVar(&jsonPath, "json", "", "Coverage JSON output path")
+	coverageCmd.Flags().Float64Var(&failUnder, "fail-under", 0, "Exit non-zero when operation coverage is below this percent")
+
+	specCmd := &cobra.Command{Use: "spec", Short: "Spec utilities"}
+	specDiffCmd := &cobra.Command{Use: "diff <old> <new>", Short: "List changes between two spec versions, breaking or not", Args: cobra.ExactArgs(2), RunE: runSpecDiff}
+	specDiffCmd.Flags().StringVar(&reportPath, "report", "", "JUnit XML output path (one testcase per changed endpoint)")
+	specDiffCmd.Flags().StringVar(&jsonPath, "json", "", "JSON report output path")
+	specDiffCmd.Flags().StringVar(&diffFailOn, "fail-on", "breaking", "Exit non-zero on changes of this severity (breaking|warning = any change|none)")
+	specCmd.AddCommand(specDiffCmd)
+
+	vaultCmd := &cobra.Command{Use: "vault", Short: "Manage the encrypted secrets vault of ${vault:name} references (passphrase from " + secrets.PassphraseEnv + ")"}
+	vaultCmd.PersistentFlags().String("vault", "", "Vault file (default: vault of auth.yaml)")
+	vaultSetCmd := &cobra.Command{Use: "set <name> [value]", Short: "Store a secret; without value it is read from stdin", Args: cobra.RangeArgs(1, 2), RunE: runVaultSet}
+	vaultListCmd := &cobra.Command{Use: "list", Short: "List secret names", Args: cobra.NoArgs, RunE: runVaultList}
+	vaultRmCmd := &cobra.Command{Use: "rm <name>", Short: "Remove a secret", Args: cobra.ExactArgs(1), RunE: runVaultRm}
+	vaultCmd.AddCommand(vaultSetCmd, vaultListCmd, vaultRmCmd)
+
 	desktopCmd := &cobra.Command{Use: "desktop", Short: "Run native desktop UI", RunE: runDesktop}
 
-	root.AddCommand(loadCmd, runCmd, compareCmd, ltCmd, planCmd, desktopCmd)
+	root.AddCommand(loadCmd, runCmd, compareCmd, coverageCmd, ltCmd, planCmd, specCmd, vaultCmd, desktopCmd)
 
 	if err := root.Execute(); err != nil {
 		os.Exit(1)
@@ -108,7 +196,7 @@ func runLoad(cmd *cobra.Command, args []string) error {
 	if openAPIPath == "" {
 		return fmt.Errorf("--file is required")
 	}
-	endpoints, doc, err := core.LoadOpenAPI(openAPIPath)
+	endpoints, doc, err := loadSpecs()
 	if err != nil {
 		return err
 	}
@@ -121,59 +209,255 @@ func runLoad(cmd *cobra.Command, args []string) error {
 	if title != "" || version != "" {
 		fmt.Printf("Spec: %s %s\n", title, version)
 	}
+	perSpec := map[string]int{}
+	var specs []string
+	for _, ep := range endpoints {
+		if perSpec[ep.Spec] == 0 {
+			specs = append(specs, ep.Spec)
+		}
+		perSpec[ep.Spec]++
+	}
+	if len(specs) > 1 {
+		sort.Strings(specs)
+		for _, sp := range specs {
+			fmt.Printf("  %s: %d endpoints\n", sp, perSpec[sp])
+		}
+	}
 	return nil
 }
 
-func resolveContext() (string, map[string]string, map[string]string, error) {
+// resolveContext returns the base URL (--base, env baseURL, else the first server of the loaded
+// spec), env headers and auth header, with ${...} references expanded.
+func resolveContext(endpoints []core.Endpoint) (string, map[string]string, map[string]string, error) {
 	base := ""
 	headers := map[string]string{}
-	authHeader := map[string]string{}
 
-	if envFile != "" {
-		envCfg, err := config.LoadEnvConfig(envFile)
-		if err == nil {
-			if e := envCfg.GetEnvironment(envName); e != nil {
-				base = e.BaseURL
-				for k, v := range e.Headers {
-					headers[k] = v
-				}
-			}
+	env, err := loadEnv(envName)
+	if err != nil {
+		return "", headers, map[string]string{}, err
+	}
+	if env != nil {
+		base = env.BaseURL
+		for k, v := range env.Headers {
+			headers[k] = v
 		}
 	}
 	if baseURL != "" {
 		base = baseURL
 	}
-	if authFile != "" {
-		authCfg, err := config.LoadAuthConfig(authFile)
+	if base == "" {
+		if urls := core.ServerURLs(core.CommonSpecServers(endpoints), envServerVars()); len(urls) > 0 {
+			base = urls[0]
+		}
+	}
+	authHeader, err := profileAuthHeader(authProfile, env)
+	return base, headers, authHeader, err
+}
+
+// loadEnv returns environment name of --env-config with its ${...} references expanded; nil
+// when there is no env config file or no such environment.
+func loadEnv(name string) (*config.Environment, error) {
+	if envFile == "" {
+		return nil, nil
+	}
+	envCfg, err := config.LoadEnvConfig(envFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return envCfg.Resolve(name)
+}
+
+// envVars expands ${...} references of auth profiles with the variables of env (nil: only
+// ${env:NAME} and ${file:path}).
+func envVars(env *config.Environment) config.Vars {
+	if env == nil {
+		return config.Vars{}
+	}
+	return env.Vars()
+}
+
+// profileAuthHeader returns the auth header of the auth.yaml profile named profile; env
+// supplies the variables of ${...} references.
+func profileAuthHeader(profile string, env *config.Environment) (map[string]string, error) {
+	authHeader := map[string]string{}
+	if authFile == "" {
+		return authHeader, nil
+	}
+	authCfg, err := config.LoadAuthConfig(authFile)
+	if err != nil {
+		return authHeader, nil
+	}
+	if p := authCfg.GetAuthProfile(profile); p != nil {
+		rp, err := p.Resolve(envVars(env))
 		if err == nil {
-			if p := authCfg.GetAuthProfile("default-jwt"); p != nil && p.Type == "jwt" {
-				authHeader["Authorization"] = "Bearer " + p.Token
+			err = rp.FetchOAuth2Token()
+		}
+		if err != nil {
+			return authHeader, err
+		}
+		switch {
+		case rp.Bearer():
+			authHeader["Authorization"] = "Bearer " + rp.Token
+		case rp.Type == "apikey" && rp.Header != "" && rp.Key != "":
+			authHeader[rp.Header] = rp.Key
+		case rp.Type == "basic" && rp.Username != "":
+			authHeader["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(rp.Username+":"+rp.Password))
+		}
+	}
+	return authHeader, nil
+}
+
+// schemeSecrets maps the securitySchemes of endpoints to auth.yaml profiles; operations with
+// a spec security requirement get those credentials instead of the --auth-profile header.
+func schemeSecrets(endpoints []core.Endpoint) (map[string]core.AuthSecret, error) {
+	env, err := loadEnv(envName)
+	if err != nil {
+		return nil, err
+	}
+	return profileSchemeSecrets(endpoints, authProfile, env)
+}
+
+// profileSchemeSecrets is schemeSecrets with profile preferred instead of --auth-profile and
+// the variables of env.
+func profileSchemeSecrets(endpoints []core.Endpoint, profile string, env *config.Environment) (map[string]core.AuthSecret, error) {
+	if authFile == "" {
+		return nil, nil
+	}
+	authCfg, err := config.LoadAuthConfig(authFile)
+	if err != nil {
+		return nil, nil
+	}
+	out := map[string]core.AuthSecret{}
+	for _, name := range core.SecuritySchemeNames(endpoints) {
+		if p := authCfg.ProfileForScheme(name, profile); p != nil {
+			rp, err := p.Resolve(envVars(env))
+			if err == nil {
+				err = rp.FetchOAuth2Token()
+			}
+			if err != nil {
+				return nil, err
 			}
+			out[name] = core.AuthSecret{Token: rp.Token, Key: rp.Key, Username: rp.Username, Password: rp.Password}
+		}
+	}
+	return out, nil
+}
+
+// loadSpecs loads --file (a spec file, a directory of specs or an http(s) URL);
+// URLs are fetched with the env headers and auth.
+func loadSpecs() ([]core.Endpoint, *openapi3.T, error) {
+	return core.LoadSpecs(openAPIPath, specLoadOptions())
+}
+
+// specLoadOptions sends the env headers and auth with remote spec requests.
+func specLoadOptions() core.LoadOptions {
+	_, headers, authHeader, _ := resolveContext(nil)
+	for k, v := range authHeader {
+		headers[k] = v
+	}
+	return core.LoadOptions{Headers: headers}
+}
+
+// envServerVars returns the server variable overrides of the selected environment, if any.
+func envServerVars() map[string]string {
+	if e, _ := loadEnv(envName); e != nil {
+		return e.ServerVariables
+	}
+	return nil
+}
+
+// requireBase fails when neither base nor the servers of every endpoint give a base URL.
+func requireBase(base string, endpoints []core.Endpoint) error {
+	if base != "" {
+		return nil
+	}
+	for _, ep := range endpoints {
+		if _, err := core.ResolveBaseURL(ep, "", "", envServerVars(), false); err != nil {
+			return fmt.Errorf("set --base, env config baseURL or spec servers")
 		}
 	}
-	return base, headers, authHeader, nil
+	return nil
+}
+
+// envPathParams returns pinned path params of the selected environment, if any.
+func envPathParams() core.ParamOverrides {
+	if e, _ := loadEnv(envName); e != nil {
+		return e.PathParams
+	}
+	return nil
+}
+
+// runConfig is the request setup shared by smoke, drift and fuzz: base URL, env headers,
+// auth, pinned params, server variables and scheme secrets of --env.
+func runConfig(endpoints []core.Endpoint) (core.SmokeConfig, error) {
+	_, headers, authHeader, err := resolveContext(endpoints)
+	if err != nil {
+		return core.SmokeConfig{}, err
+	}
+	secrets, err := schemeSecrets(endpoints)
+	if err != nil {
+		return core.SmokeConfig{}, err
+	}
+	// The spec servers are resolved per endpoint (see core.ResolveBaseURL), so only --base or
+	// the env baseURL is passed on.
+	base, operationServers := baseURL, false
+	var expandBody func(string) (string, error)
+	if env, _ := loadEnv(envName); env != nil {
+		if base == "" {
+			base = env.BaseURL
+		}
+		operationServers = env.OperationServers
+		if env.ExpandExamples {
+			expandBody = env.Vars().Expand
+		}
+	}
+	return core.SmokeConfig{
+		BaseURL:          base,
+		Headers:          headers,
+		AuthHeader:       authHeader,
+		Timeout:          5 * time.Second,
+		Workers:          workers,
+		RateLimitRPS:     5,
+		PathParams:       envPathParams(),
+		PinBaseURL:       baseURL != "",
+		OperationServers: operationServers,
+		ServerVars:       envServerVars(),
+		Secrets:          secrets,
+		ExpandBody:       expandBody,
+	}, nil
 }
 
 func runSmoke(cmd *cobra.Command, args []string) error {
 	if openAPIPath == "" {
 		return fmt.Errorf("--file is required")
 	}
-	endpoints, _, err := core.LoadOpenAPI(openAPIPath)
+	endpoints, _, err := loadSpecs()
 	if err != nil {
 		return err
 	}
-	base, headers, authHeader, _ := resolveContext()
-	if base == "" {
-		return fmt.Errorf("set --base or env config baseURL")
+	policy, err := core.PolicyByName(expectFlag)
+	if err != nil {
+		return err
 	}
-	cfg := core.SmokeConfig{
-		BaseURL:      base,
-		Headers:      headers,
-		AuthHeader:   authHeader,
-		Timeout:      5 * time.Second,
-		Workers:      workers,
-		RateLimitRPS: 5,
+	gate, err := core.ParseSeverity(failOn)
+	if err != nil {
+		return err
+	}
+	cfg, err := runConfig(endpoints)
+	if err != nil {
+		return err
 	}
+	if err := requireBase(cfg.BaseURL, endpoints); err != nil {
+		return err
+	}
+	cfg.Expect = policy
+	cfg.CheckContract = contract
+	cfg.Seed = seed
+	cfg.ExampleName = exampleName
+	cfg.Anonymous = anonymous
 	start := time.Now()
 	results := core.RunSmokeBulk(context.Background(), cfg, endpoints)
 	duration := time.Since(start)
@@ -185,6 +469,18 @@ func runSmoke(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "write json: %v\n", err)
 	}
 	fmt.Printf("Smoke: %d total, %d passed, %d failed in %v\n", len(results), rep.Smoke.Passed, rep.Smoke.Failed, duration)
+	if contract {
+		fmt.Printf("Contract: %d checked, %d ok, %d drifted\n", rep.Smoke.ContractChecked, rep.Smoke.ContractOK, rep.Smoke.ContractDrifted)
+		var contracts []core.DriftResult
+		for _, r := range results {
+			if r.Contract != nil {
+				contracts = append(contracts, *r.Contract)
+			}
+		}
+		if err := driftGate(contracts, gate); err != nil {
+			return err
+		}
+	}
 	_ = tags
 	return nil
 }
@@ -193,37 +489,274 @@ func runDrift(cmd *cobra.Command, args []string) error {
 	if openAPIPath == "" {
 		return fmt.Errorf("--file is required")
 	}
-	endpoints, _, err := core.LoadOpenAPI(openAPIPath)
+	endpoints, _, err := loadSpecs()
+	if err != nil {
+		return err
+	}
+	if pathFlag != "" {
+		method := strings.ToUpper(methodFlag)
+		var ep *core.Endpoint
+		for i := range endpoints {
+			if endpoints[i].Path == pathFlag && strings.ToUpper(endpoints[i].Method) == method {
+				ep = &endpoints[i]
+				break
+			}
+		}
+		if ep == nil {
+			return fmt.Errorf("endpoint %s %s not found", methodFlag, pathFlag)
+		}
+		endpoints = []core.Endpoint{*ep}
+	} else {
+		var methods []string
+		if cmd.Flags().Changed("method") {
+			methods = splitList(methodFlag)
+		}
+		endpoints = core.FilterEndpoints(endpoints, splitList(tags), methods)
+	}
+	gate, err := core.ParseSeverity(failOn)
+	if err != nil {
+		return err
+	}
+	cfg, err := runConfig(endpoints)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	results := core.RunDriftBulk(context.Background(), cfg, endpoints, func(_ int, dr core.DriftResult) {
+		if dr.Err != "" {
+			fmt.Printf("Drift %s %s: error %s\n", dr.Method, dr.Path, dr.Err)
+			return
+		}
+		fmt.Printf("Drift %s %s: OK=%v findings=%d\n", dr.Method, dr.Path, dr.OK, len(dr.Findings))
+		for _, f := range dr.Findings {
+			if f.Detail != "" {
+				fmt.Printf("  [%s] %s %s (%s)\n", f.Severity, f.Type, f.Path, f.Detail)
+				continue
+			}
+			fmt.Printf("  [%s] %s %s\n", f.Severity, f.Type, f.Path)
+		}
+	})
+	duration := time.Since(start)
+	if err := report.WriteJUnitDrift(reportPath, results, duration); err != nil {
+		fmt.Fprintf(os.Stderr, "write junit: %v\n", err)
+	}
+	rep := report.DriftReportFromResults(results, duration)
+	if err := report.WriteJSON(jsonPath, rep); err != nil {
+		fmt.Fprintf(os.Stderr, "write json: %v\n", err)
+	}
+	if len(results) > 1 {
+		fmt.Printf("Drift: %d total, %d ok, %d drifted (%d breaking) in %v\n", rep.Drift.Total, rep.Drift.OK, rep.Drift.Drifted, rep.Drift.Breaking, duration)
+	}
+	if len(results) == 1 && results[0].Err != "" {
+		return fmt.Errorf("%s", results[0].Err)
+	}
+	return driftGate(results, gate)
+}
+
+func runFuzz(cmd *cobra.Command, args []string) error {
+	if openAPIPath == "" {
+		return fmt.Errorf("--file is required")
+	}
+	switch fuzzFailOn {
+	case "failure", "5xx", "none":
+	default:
+		return fmt.Errorf("--fail-on: want failure, 5xx or none, got %q", fuzzFailOn)
+	}
+	endpoints, _, err := loadSpecs()
+	if err != nil {
+		return err
+	}
+	endpoints = core.FilterEndpoints(endpoints, splitList(tags), splitList(methodFlag))
+	if pathFlag != "" {
+		var selected []core.Endpoint
+		for _, ep := range endpoints {
+			if ep.Path == pathFlag {
+				selected = append(selected, ep)
+			}
+		}
+		endpoints = selected
+	}
+	cfg, err := runConfig(endpoints)
+	if err != nil {
+		return err
+	}
+	if err := requireBase(cfg.BaseURL, endpoints); err != nil {
+		return err
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Fuzz seed=%d\n", seed)
+	start := time.Now()
+	results := core.RunFuzz(context.Background(), cfg, endpoints, seed, func(_, _ int, r core.FuzzResult) {
+		if !r.OK {
+			fmt.Printf("FAIL %s %s [%s %s] %s: %s\n", r.Method, r.Path, r.Kind, r.Target, r.Desc, r.Err)
+		} else if verbose {
+			fmt.Printf("ok   %s %s [%s %s] -> %d\n", r.Method, r.Path, r.Kind, r.Target, r.StatusCode)
+		}
+	})
+	duration := time.Since(start)
+	if err := report.WriteJUnitFuzz(reportPath, results, seed, duration); err != nil {
+		fmt.Fprintf(os.Stderr, "write junit: %v\n", err)
+	}
+	rep := report.FuzzReportFromResults(results, seed, duration)
+	if err := report.WriteJSON(jsonPath, rep); err != nil {
+		fmt.Fprintf(os.Stderr, "write json: %v\n", err)
+	}
+	fmt.Printf("Fuzz: %d mutations, %d passed, %d failed (%d server errors) in %v, seed=%d\n",
+		rep.Fuzz.Total, rep.Fuzz.Passed, rep.Fuzz.Failed, rep.Fuzz.ServerErrors, duration, seed)
+	return fuzzGate(rep.Fuzz, fuzzFailOn)
+}
+
+// fuzzGate returns an error when the fuzz run fails at the --fail-on level: any failed
+// mutation, only server errors (5xx) or none.
+func fuzzGate(sum *report.FuzzSummary, failOn string) error {
+	switch {
+	case failOn == "failure" && sum.Failed > 0:
+		return fmt.Errorf("%d of %d mutation(s) failed (%d server errors), seed=%d", sum.Failed, sum.Total, sum.ServerErrors, sum.Seed)
+	case failOn == "5xx" && sum.ServerErrors > 0:
+		return fmt.Errorf("%d mutation(s) got a server error, seed=%d", sum.ServerErrors, sum.Seed)
+	}
+	return nil
+}
+
+// driftGate returns an error when any result fails at the --fail-on severity.
+func driftGate(results []core.DriftResult, gate core.Severity) error {
+	failed := 0
+	for _, r := range results {
+		if r.Fails(gate) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d endpoint(s) have %s drift", failed, gate)
+	}
+	return nil
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	if openAPIPath == "" {
+		return fmt.Errorf("--file is required")
+	}
+	endpoints, _, err := loadSpecs()
 	if err != nil {
 		return err
 	}
-	method := strings.ToUpper(methodFlag)
-	var ep *core.Endpoint
-	for i := range endpoints {
-		if endpoints[i].Path == pathFlag && strings.ToUpper(endpoints[i].Method) == method {
-			ep = &endpoints[i]
-			break
+	b := core.NewCoverageBuilder(endpoints)
+	for _, path := range args {
+		rep, err := report.ReadJSON(path)
+		if err != nil {
+			return err
+		}
+		report.AddToCoverage(b, rep)
+	}
+	cov := b.Coverage()
+	for _, op := range cov.Operations {
+		var missing []string
+		for _, r := range op.Responses {
+			if !r.Hit {
+				missing = append(missing, r.Status)
+			}
+		}
+		var params []string
+		for _, p := range op.Params {
+			if !p.Hit {
+				params = append(params, p.In+":"+p.Name)
+			}
+		}
+		mark := "x"
+		if op.Hits == 0 {
+			mark = " "
+		}
+		line := fmt.Sprintf("[%s] %s %s", mark, op.Method, op.Path)
+		if len(op.RunTypes) > 0 {
+			line += " (" + strings.Join(op.RunTypes, ",") + ")"
+		}
+		if len(missing) > 0 {
+			line += " untested responses: " + strings.Join(missing, ",")
+		}
+		if len(params) > 0 {
+			line += " unused params: " + strings.Join(params, ",")
+		}
+		fmt.Println(line)
+	}
+	for _, t := range cov.Tags {
+		fmt.Printf("Tag %s: operations %d/%d (%.1f%%), responses %d/%d (%.1f%%)\n", t.Tag, t.OperationsHit, t.Operations, t.OperationsPct, t.ResponsesHit, t.ResponsesTotal, t.ResponsesPct)
+	}
+	fmt.Printf("Coverage: operations %d/%d (%.1f%%), responses %d/%d (%.1f%%), params %d/%d (%.1f%%)\n",
+		cov.OperationsHit, cov.OperationsTotal, cov.OperationsPct,
+		cov.ResponsesHit, cov.ResponsesTotal, cov.ResponsesPct,
+		cov.ParamsHit, cov.ParamsTotal, cov.ParamsPct)
+	if jsonPath != "" {
+		if err := report.WriteJSON(jsonPath, report.CoverageReport(cov)); err != nil {
+			fmt.Fprintf(os.Stderr, "write json: %v\n", err)
 		}
 	}
-	if ep == nil {
-		return fmt.Errorf("endpoint %s %s not found", methodFlag, pathFlag)
+	if failUnder > 0 && cov.OperationsPct < failUnder {
+		return fmt.Errorf("operation coverage %.1f%% is below %.1f%%", cov.OperationsPct, failUnder)
 	}
-	base, headers, authHeader, _ := resolveContext()
-	cfg := core.SmokeConfig{BaseURL: base, Headers: headers, AuthHeader: authHeader, Timeout: 5 * time.Second}
-	statusCode, body, err := core.FetchResponse(cfg, *ep)
+	return nil
+}
+
+func runSpecDiff(cmd *cobra.Command, args []string) error {
+	gate, err := core.ParseSeverity(diffFailOn)
 	if err != nil {
 		return err
 	}
-	dr := core.RunDrift(body, ep.Schema, statusCode)
-	dr.Path = ep.Path
-	dr.Method = ep.Method
-	fmt.Printf("Drift %s %s: OK=%v findings=%d\n", ep.Method, ep.Path, dr.OK, len(dr.Findings))
-	for _, f := range dr.Findings {
-		fmt.Printf("  %s %s\n", f.Type, f.Path)
+	start := time.Now()
+	opts := specLoadOptions()
+	oldEps, _, err := core.LoadSpecs(args[0], opts)
+	if err != nil {
+		return fmt.Errorf("old spec: %w", err)
+	}
+	newEps, _, err := core.LoadSpecs(args[1], opts)
+	if err != nil {
+		return fmt.Errorf("new spec: %w", err)
+	}
+	diff := core.DiffSpecs(oldEps, newEps)
+	duration := time.Since(start)
+	for _, c := range diff.Changes {
+		label := "non-breaking"
+		if c.Breaking {
+			label = "breaking"
+		}
+		line := fmt.Sprintf("[%s] %s %s %s", label, c.Method, c.Path, c.Kind)
+		if c.Location != "" {
+			line += " " + c.Location
+		}
+		if c.Detail != "" {
+			line += " (" + c.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("Spec diff: %d changes, %d breaking\n", len(diff.Changes), diff.Breaking)
+	if reportPath != "" {
+		if err := report.WriteJUnitSpecDiff(reportPath, diff, duration); err != nil {
+			fmt.Fprintf(os.Stderr, "write junit: %v\n", err)
+		}
+	}
+	if jsonPath != "" {
+		if err := report.WriteJSON(jsonPath, report.SpecDiffReport(args[0], args[1], diff, duration)); err != nil {
+			fmt.Fprintf(os.Stderr, "write json: %v\n", err)
+		}
+	}
+	if diff.Fails(gate) {
+		return fmt.Errorf("%d breaking change(s) of %d", diff.Breaking, len(diff.Changes))
 	}
 	return nil
 }
 
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func runLT(cmd *cobra.Command, args []string) error {
 	planPath := openAPIPath
 	if planPath == "" {
@@ -234,6 +767,17 @@ func runLT(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("parse Taurus plan: %w", err)
 	}
 	r := &lt.Runner{Plan: p, Config: lt.DefaultRunConfig()}
+	if cmd.Flags().Changed("auth-profile") {
+		env, err := loadEnv(envName)
+		if err != nil {
+			return err
+		}
+		authHeader, err := profileAuthHeader(authProfile, env)
+		if err != nil {
+			return err
+		}
+		r.Auth = func() (map[string]string, error) { return core.FreshAuthHeaders(authHeader) }
+	}
 	if err := r.Run(context.Background()); err != nil {
 		return err
 	}
@@ -246,38 +790,288 @@ func runCompare(cmd *cobra.Command, args []string) error {
 	if openAPIPath == "" {
 		return fmt.Errorf("--file is required")
 	}
-	endpoints, _, err := core.LoadOpenAPI(openAPIPath)
+	endpoints, _, err := loadSpecs()
 	if err != nil {
 		return err
 	}
-	method := strings.ToUpper(methodFlag)
-	var ep *core.Endpoint
-	for i := range endpoints {
-		if endpoints[i].Path == pathFlag && strings.ToUpper(endpoints[i].Method) == method {
-			ep = &endpoints[i]
-			break
+	if pathFlag != "" {
+		method := strings.ToUpper(methodFlag)
+		var ep *core.Endpoint
+		for i := range endpoints {
+			if endpoints[i].Path == pathFlag && strings.ToUpper(endpoints[i].Method) == method {
+				ep = &endpoints[i]
+				break
+			}
 		}
-	}
-	if ep == nil {
-		return fmt.Errorf("endpoint %s %s not found", methodFlag, pathFlag)
+		if ep == nil {
+			return fmt.Errorf("endpoint %s %s not found", methodFlag, pathFlag)
+		}
+		endpoints = []core.Endpoint{*ep}
+	} else {
+		endpoints = core.FilterEndpoints(endpoints, splitList(tags), splitList(methodFlag))
 	}
 	envCfg, err := config.LoadEnvConfig(envFile)
 	if err != nil {
 		return err
 	}
-	ea := envCfg.GetEnvironment(envA)
-	eb := envCfg.GetEnvironment(envB)
+	rules, err := envCfg.CompareRuleSet()
+	if err != nil {
+		return err
+	}
+	ea, err := envCfg.Resolve(envA)
+	if err != nil {
+		return err
+	}
+	eb, err := envCfg.Resolve(envB)
+	if err != nil {
+		return err
+	}
 	if ea == nil || eb == nil {
 		return fmt.Errorf("environments %s and %s must be in env config", envA, envB)
 	}
-	res := core.RunABCompare(*ep, ea.BaseURL, eb.BaseURL, ea.Headers, nil, 5*time.Second)
-	fmt.Printf("A/B %s %s: Status A=%d B=%d Match=%v\n", ep.Method, ep.Path, res.StatusA, res.StatusB, res.StatusMatch)
+	a, err := compareSide(ea, authA, endpoints)
+	if err != nil {
+		return err
+	}
+	b, err := compareSide(eb, authB, endpoints)
+	if err != nil {
+		return err
+	}
+	cfg := core.ABCompareConfig{
+		A:            a,
+		B:            b,
+		Rules:        rules,
+		Timeout:      5 * time.Second,
+		Workers:      workers,
+		RateLimitRPS: rateLimit,
+	}
+	start := time.Now()
+	results := core.RunABCompareBulk(context.Background(), cfg, endpoints, func(_, _ int, res core.ABCompareResult) {
+		printCompare(res)
+	})
+	return finishCompare(results, time.Since(start), "endpoint")
+}
+
+// compareSide is the compare side of the resolved environment e (nil: none configured) with
+// the credentials of auth profile (empty: --auth-profile).
+func compareSide(e *config.Environment, profile string, endpoints []core.Endpoint) (core.CompareSide, error) {
+	if profile == "" {
+		profile = authProfile
+	}
+	authHeader, err := profileAuthHeader(profile, e)
+	if err != nil {
+		return core.CompareSide{}, err
+	}
+	secrets, err := profileSchemeSecrets(endpoints, profile, e)
+	if err != nil {
+		return core.CompareSide{}, err
+	}
+	side := core.CompareSide{AuthHeader: authHeader, Secrets: secrets}
+	if e != nil {
+		side.BaseURL, side.Headers, side.PathParams = e.BaseURL, e.Headers, e.PathParams
+	}
+	return side, nil
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	reqs, err := core.LoadCapture(args[0])
+	if err != nil {
+		return err
+	}
+	// The spec is optional: it maps recorded paths to operations for grouping, security
+	// schemes and per-endpoint compare rules.
+	var endpoints []core.Endpoint
+	if openAPIPath != "" {
+		if endpoints, _, err = loadSpecs(); err != nil {
+			return err
+		}
+	}
+	// An environment is only used for a side without a base URL flag or when named
+	// explicitly; with --baseline and --candidate alone env.yaml is optional and only
+	// supplies compare rules.
+	useA := baselineURL == "" || cmd.Flags().Changed("envA")
+	useB := candidate == "" || cmd.Flags().Changed("envB")
+	envCfg, err := config.LoadEnvConfig(envFile)
+	if errors.Is(err, fs.ErrNotExist) && !useA && !useB {
+		envCfg, err = &config.EnvConfig{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	rules, err := envCfg.CompareRuleSet()
+	if err != nil {
+		return err
+	}
+	var ea, eb *config.Environment
+	if useA {
+		if ea, err = envCfg.Resolve(envA); err != nil {
+			return err
+		}
+	}
+	if useB {
+		if eb, err = envCfg.Resolve(envB); err != nil {
+			return err
+		}
+	}
+	a, err := compareSide(ea, authA, endpoints)
+	if err != nil {
+		return err
+	}
+	b, err := compareSide(eb, authB, endpoints)
+	if err != nil {
+		return err
+	}
+	if baselineURL != "" {
+		a.BaseURL = baselineURL
+	}
+	if candidate != "" {
+		b.BaseURL = candidate
+	}
+	if a.BaseURL == "" || b.BaseURL == "" {
+		return fmt.Errorf("baseline and candidate base URLs are required (--baseline/--candidate or envA/envB in env config)")
+	}
+	cfg := core.ABCompareConfig{
+		A:            a,
+		B:            b,
+		Rules:        rules,
+		Timeout:      5 * time.Second,
+		Workers:      workers,
+		RateLimitRPS: rateLimit,
+	}
+	start := time.Now()
+	results := core.RunReplayCompare(context.Background(), cfg, endpoints, reqs, func(_, _ int, res core.ABCompareResult) {
+		printCompare(res)
+	})
+	return finishCompare(results, time.Since(start), "request")
+}
+
+// finishCompare writes the compare reports, prints the summary and applies --fail-on-diff;
+// unit names what one result is (endpoint or replayed request).
+func finishCompare(results []core.ABCompareResult, duration time.Duration, unit string) error {
+	rep := report.CompareReportFromResults(envA, envB, results, duration)
+	if reportPath != "" {
+		if err := report.WriteJUnitCompare(reportPath, results, duration); err != nil {
+			fmt.Fprintf(os.Stderr, "write junit: %v\n", err)
+		}
+	}
+	if jsonPath != "" {
+		if err := report.WriteJSON(jsonPath, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "write json: %v\n", err)
+		}
+	}
+	if htmlPath != "" {
+		if err := report.WriteHTMLCompare(htmlPath, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "write html: %v\n", err)
+		}
+	}
+	for _, g := range rep.AB.Endpoints {
+		fmt.Printf("%s %s: %d requests, %d same, %d differ, %d errors\n", g.Method, g.Path, g.Requests, g.Same, g.Differ, g.Errors)
+		for _, d := range g.Diffs {
+			fmt.Printf("  %dx %s\n", d.Count, d.Diff)
+		}
+	}
+	if len(results) > 1 {
+		fmt.Printf("Compare %s vs %s: %d %ss, %d same, %d differ, %d errors, %d ignored differences in %v\n",
+			envA, envB, rep.AB.Total, unit, rep.AB.Same, rep.AB.Differ, rep.AB.Errors, rep.AB.Ignored, duration)
+	}
+	if failOnDiff && rep.AB.Differ+rep.AB.Errors > 0 {
+		return fmt.Errorf("%d %s(s) differ between %s and %s", rep.AB.Differ+rep.AB.Errors, unit, envA, envB)
+	}
+	return nil
+}
+
+// printCompare prints one A/B compare result with its real differences; ignored ones are
+// counted, or listed with -v.
+func printCompare(res core.ABCompareResult) {
+	fmt.Printf("A/B %s: Status A=%d B=%d Match=%v\n", res.Name(), res.StatusA, res.StatusB, res.StatusMatch)
+	if res.ErrA != "" {
+		fmt.Println("   A error:", res.ErrA)
+	}
+	if res.ErrB != "" {
+		fmt.Println("   B error:", res.ErrB)
+	}
 	for _, d := range res.HeadersDiff {
 		fmt.Println("  ", d)
 	}
 	for _, d := range res.BodyStructureDiff {
 		fmt.Println("  [struct]", d)
 	}
+	for _, d := range res.BodyValueDiff {
+		fmt.Println("  [value]", d)
+	}
+	if verbose {
+		for _, d := range res.Ignored {
+			fmt.Printf("   [ignored] %s (%s)\n", d.Diff, d.Rule)
+		}
+	} else if len(res.Ignored) > 0 {
+		fmt.Printf("  %d difference(s) ignored by compare rules (-v lists them)\n", len(res.Ignored))
+	}
+}
+
+// openVault opens the vault of --vault, else the one auth.yaml names.
+func openVault(cmd *cobra.Command) (*secrets.Vault, error) {
+	path, _ := cmd.Flags().GetString("vault")
+	if path == "" && authFile != "" {
+		if authCfg, err := config.LoadAuthConfig(authFile); err == nil {
+			path = authCfg.VaultPath(authFile)
+		}
+	}
+	if path == "" {
+		return nil, fmt.Errorf("set --vault or vault in %s", authFile)
+	}
+	return secrets.OpenVault(path, os.Getenv(secrets.PassphraseEnv))
+}
+
+func runVaultSet(cmd *cobra.Command, args []string) error {
+	v, err := openVault(cmd)
+	if err != nil {
+		return err
+	}
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		// Read from stdin so the secret stays out of shell history.
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("empty secret %q", args[0])
+	}
+	v.Set(args[0], value)
+	if err := v.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Stored %s\n", args[0])
+	return nil
+}
+
+func runVaultList(cmd *cobra.Command, args []string) error {
+	v, err := openVault(cmd)
+	if err != nil {
+		return err
+	}
+	for _, name := range v.Names() {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func runVaultRm(cmd *cobra.Command, args []string) error {
+	v, err := openVault(cmd)
+	if err != nil {
+		return err
+	}
+	if !v.Delete(args[0]) {
+		return fmt.Errorf("no secret %q in vault", args[0])
+	}
+	if err := v.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", args[0])
 	return nil
 }
 
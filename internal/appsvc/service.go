@@ -10,6 +10,8 @@ import (
 
 	"lazytest/internal/config"
 	"lazytest/internal/core"
+
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
 // clock lets tests control time deterministically.
@@ -34,6 +36,9 @@ type Service struct {
 	docVer    string
 	endpoints []core.Endpoint
 	byID      map[string]core.Endpoint
+	// servers are the root servers of a single loaded spec (nil for a spec directory,
+	// where every endpoint carries its own).
+	servers openapi3.Servers
 
 	// Runtime configuration context.
 	envCfg  *config.EnvConfig
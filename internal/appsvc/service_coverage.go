@@ -0,0 +1,41 @@
+package appsvc
+
+import (
+	"lazytest/internal/core"
+	"lazytest/internal/report"
+)
+
+// Coverage aggregates the run history against the loaded spec: which operations, documented
+// responses and parameters smoke, drift, fuzz and compare runs have exercised, per tag too.
+// Canceled and failed runs count with whatever results they produced.
+func (s *Service) Coverage() core.Coverage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b := core.NewCoverageBuilder(s.endpoints)
+	for _, h := range s.history {
+		addCoverage(b, h.Data)
+	}
+	return b.Coverage()
+}
+
+// addCoverage records one run result; results of other run types (lt, tcp) are skipped.
+func addCoverage(b *core.CoverageBuilder, data interface{}) {
+	switch v := data.(type) {
+	case []core.SmokeResult:
+		b.AddSmoke(v)
+	case core.DriftResult:
+		b.AddDrift([]core.DriftResult{v})
+	case []core.DriftResult:
+		b.AddDrift(v)
+	case *report.FuzzSummary:
+		if v != nil {
+			b.AddFuzz(v.Results)
+		}
+	case core.ABCompareResult:
+		b.AddCompare(v)
+	case []core.ABCompareResult:
+		for _, r := range v {
+			b.AddCompare(r)
+		}
+	}
+}
@@ -3,29 +3,59 @@ package appsvc
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"lazytest/internal/core"
 )
 
+// ValidateRequest checks req against the parameters and requestBody schema of req.EndpointID
+// without sending it. An empty slice means the request matches the contract.
+func (s *Service) ValidateRequest(req RequestDTO) ([]RequestIssueDTO, error) {
+	s.mu.RLock()
+	ep, ok := s.byID[req.EndpointID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("endpoint not found")
+	}
+	req, err := s.expandBody(req)
+	if err != nil {
+		return []RequestIssueDTO{{In: "body", Message: err.Error()}}, nil
+	}
+	hreq, err := newHTTPRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	issues := []RequestIssueDTO{}
+	for _, is := range core.ValidateRequest(ep, hreq) {
+		issues = append(issues, RequestIssueDTO{In: is.In, Name: is.Name, Message: is.Message})
+	}
+	return issues, nil
+}
+
 // SendRequest executes a single HTTP call and normalizes response for UI/CLI.
+// ${...} references in the body are expanded with the variables of req.EnvName ($${ is a
+// literal ${).
 //
 // Java analogy: similar to a RestTemplate/WebClient call wrapped in a service method.
 func (s *Service) SendRequest(req RequestDTO) (ResponseDTO, error) {
 	start := s.clk.Now()
 
-	var body io.Reader
-	if req.Body != "" && shouldHaveBody(req.Method) {
-		body = bytes.NewReader([]byte(req.Body))
+	var issues []RequestIssueDTO
+	if req.EndpointID != "" {
+		issues, _ = s.ValidateRequest(req)
 	}
-
-	hreq, err := http.NewRequest(req.Method, req.URL, body)
+	req, err := s.expandBody(req)
 	if err != nil {
 		return ResponseDTO{}, err
 	}
-	for k, v := range req.Headers {
-		hreq.Header.Set(k, v)
+	hreq, err := newHTTPRequest(req)
+	if err != nil {
+		return ResponseDTO{}, err
 	}
 
 	timeout := 15 * time.Second
@@ -37,7 +67,7 @@ func (s *Service) SendRequest(req RequestDTO) (ResponseDTO, error) {
 	resp, err := client.Do(hreq)
 	if err != nil {
 		lat := s.clk.Now().Sub(start).Milliseconds()
-		return ResponseDTO{Error: err.Error(), Err: err.Error(), LatencyMS: lat}, err
+		return ResponseDTO{Error: err.Error(), Err: err.Error(), LatencyMS: lat, RequestIssues: issues}, err
 	}
 	defer resp.Body.Close()
 
@@ -51,14 +81,52 @@ func (s *Service) SendRequest(req RequestDTO) (ResponseDTO, error) {
 
 	lat := s.clk.Now().Sub(start).Milliseconds()
 	return ResponseDTO{
-		StatusCode: resp.StatusCode,
-		Status:     resp.StatusCode,
-		Headers:    headers,
-		Body:       prettyBody,
-		LatencyMS:  lat,
+		StatusCode:    resp.StatusCode,
+		Status:        resp.StatusCode,
+		Headers:       headers,
+		Body:          prettyBody,
+		LatencyMS:     lat,
+		RequestIssues: issues,
 	}, nil
 }
 
+// expandBody expands the ${...} references of the body the user sends with the variables
+// of req.EnvName.
+func (s *Service) expandBody(req RequestDTO) (RequestDTO, error) {
+	if !strings.Contains(req.Body, "${") {
+		return req, nil
+	}
+	s.mu.RLock()
+	vars := s.varsLocked(req.EnvName)
+	s.mu.RUnlock()
+	body, err := vars.Expand(req.Body)
+	if err != nil {
+		return req, fmt.Errorf("request body: %w", err)
+	}
+	req.Body = body
+	return req, nil
+}
+
+func newHTTPRequest(req RequestDTO) (*http.Request, error) {
+	var body io.Reader
+	if req.Body != "" && shouldHaveBody(req.Method) {
+		body = bytes.NewReader([]byte(req.Body))
+	}
+	hreq, err := http.NewRequest(req.Method, req.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	// A request built earlier may carry an OAuth2 token that has expired since.
+	headers, err := core.FreshAuthHeaders(req.Headers)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		hreq.Header.Set(k, v)
+	}
+	return hreq, nil
+}
+
 func shouldHaveBody(method string) bool {
 	switch strings.ToUpper(strings.TrimSpace(method)) {
 	case http.MethodPost, http.MethodPut, http.MethodPatch:
@@ -18,6 +18,13 @@ import (
 
 // StartSmoke runs smoke checks for selected endpoints.
 func (s *Service) StartSmoke(cfg SmokeStartConfig, envName, authProfile, baseOverride string) (string, error) {
+	policy, err := core.PolicyByName(cfg.Expect)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkConfig(envName, authProfile); err != nil {
+		return "", err
+	}
 	startFn := func(ctx context.Context, run *runState) (interface{}, error) {
 		s.mu.RLock()
 		eps := append([]core.Endpoint(nil), s.endpoints...)
@@ -35,17 +42,29 @@ func (s *Service) StartSmoke(cfg SmokeStartConfig, envName, authProfile, baseOve
 			eps = selected
 		}
 
-		base, headers, authHeader := s.resolveContext(envName, authProfile)
+		_, headers, authHeader := s.resolveContext(envName, authProfile)
+		base, operationServers := s.envBaseURL(envName)
 		if baseOverride != "" {
 			base = baseOverride
 		}
 		scfg := core.SmokeConfig{
-			BaseURL:      base,
-			Headers:      headers,
-			AuthHeader:   authHeader,
-			Workers:      cfg.Workers,
-			RateLimitRPS: cfg.RateLimit,
-			Timeout:      time.Duration(max(cfg.TimeoutMS, 5000)) * time.Millisecond,
+			BaseURL:          base,
+			Headers:          headers,
+			AuthHeader:       authHeader,
+			Workers:          cfg.Workers,
+			RateLimitRPS:     cfg.RateLimit,
+			Timeout:          time.Duration(max(cfg.TimeoutMS, 5000)) * time.Millisecond,
+			PathParams:       s.pathOverrides(envName),
+			PinBaseURL:       baseOverride != "",
+			OperationServers: operationServers,
+			ServerVars:       s.serverVars(envName),
+			Expect:           policy,
+			CheckContract:    cfg.CheckContract,
+			Seed:             cfg.Seed,
+			ExampleName:      cfg.Example,
+			Secrets:          s.schemeSecrets(envName, authProfile),
+			Anonymous:        cfg.Anonymous,
+			ExpandBody:       s.bodyExpander(envName),
 		}
 
 		results := make([]core.SmokeResult, 0, len(eps))
@@ -80,8 +99,15 @@ func (s *Service) StartSmoke(cfg SmokeStartConfig, envName, authProfile, baseOve
 	return s.startRun("smoke", startFn)
 }
 
-// StartDrift validates one endpoint response against schema and exports result if requested.
+// StartDrift validates endpoint responses against schema and exports result if requested.
+// With cfg.EndpointID the run result is one core.DriftResult, otherwise a []core.DriftResult.
 func (s *Service) StartDrift(cfg DriftStartConfig, envName, authProfile, baseOverride string) (string, error) {
+	if err := s.checkConfig(envName, authProfile); err != nil {
+		return "", err
+	}
+	if cfg.EndpointID == "" {
+		return s.startDriftBulk(cfg, envName, authProfile, baseOverride)
+	}
 	return s.startRun("drift", func(ctx context.Context, run *runState) (interface{}, error) {
 		s.mu.RLock()
 		ep, ok := s.byID[cfg.EndpointID]
@@ -90,36 +116,177 @@ func (s *Service) StartDrift(cfg DriftStartConfig, envName, authProfile, baseOve
 			return nil, errors.New("endpoint not found")
 		}
 
-		base, headers, authHeader := s.resolveContext(envName, authProfile)
-		if baseOverride != "" {
-			base = baseOverride
-		}
-		scfg := core.SmokeConfig{
-			BaseURL:    base,
-			Headers:    headers,
-			AuthHeader: authHeader,
-			Timeout:    time.Duration(max(cfg.TimeoutMS, 5000)) * time.Millisecond,
-		}
-
-		code, body, err := core.FetchResponse(scfg, ep)
+		scfg := s.driftSmokeConfig(cfg, envName, authProfile, baseOverride)
+		code, header, body, err := core.FetchResponse(scfg, ep)
 		if err != nil {
 			return nil, err
 		}
-		dr := core.RunDrift(body, ep.Schema, code)
+		dr := core.RunDriftResponse(ep.Schema, code, header, body)
 		dr.Path = ep.Path
 		dr.Method = ep.Method
+		dr.StatusCode = code
 		s.emitProgress(run.id, "drift", 1, 1, ep.Method+" "+ep.Path, b2i(dr.OK), b2i(!dr.OK))
 
+		exportDrift(cfg.ExportDir, []core.DriftResult{dr}, s.clk.Now().Sub(run.started))
+		return dr, nil
+	})
+}
+
+// startDriftBulk checks every endpoint matching cfg.Tag/cfg.Method using the smoke worker pool.
+func (s *Service) startDriftBulk(cfg DriftStartConfig, envName, authProfile, baseOverride string) (string, error) {
+	return s.startRun("drift", func(ctx context.Context, run *runState) (interface{}, error) {
+		s.mu.RLock()
+		eps := append([]core.Endpoint(nil), s.endpoints...)
+		s.mu.RUnlock()
+		var tags, methods []string
+		if cfg.Tag != "" {
+			tags = []string{cfg.Tag}
+		}
+		if cfg.Method != "" {
+			methods = []string{cfg.Method}
+		}
+		eps = core.FilterEndpoints(eps, tags, methods)
+
+		scfg := s.driftSmokeConfig(cfg, envName, authProfile, baseOverride)
+		start := s.clk.Now()
+		done, okCount := 0, 0
+		results := core.RunDriftBulk(ctx, scfg, eps, func(_ int, dr core.DriftResult) {
+			done++
+			okCount += b2i(dr.OK)
+			s.emitProgress(run.id, "drift", done, len(eps), dr.Method+" "+dr.Path, okCount, done-okCount)
+		})
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		exportDrift(cfg.ExportDir, results, s.clk.Now().Sub(start))
+		return results, nil
+	})
+}
+
+func (s *Service) driftSmokeConfig(cfg DriftStartConfig, envName, authProfile, baseOverride string) core.SmokeConfig {
+	_, headers, authHeader := s.resolveContext(envName, authProfile)
+	base, operationServers := s.envBaseURL(envName)
+	if baseOverride != "" {
+		base = baseOverride
+	}
+	return core.SmokeConfig{
+		BaseURL:          base,
+		Headers:          headers,
+		AuthHeader:       authHeader,
+		Workers:          cfg.Workers,
+		RateLimitRPS:     cfg.RateLimit,
+		Timeout:          time.Duration(max(cfg.TimeoutMS, 5000)) * time.Millisecond,
+		PathParams:       s.pathOverrides(envName),
+		PinBaseURL:       baseOverride != "",
+		OperationServers: operationServers,
+		ServerVars:       s.serverVars(envName),
+		Secrets:          s.schemeSecrets(envName, authProfile),
+		ExpandBody:       s.bodyExpander(envName),
+	}
+}
+
+// exportDrift writes drift.json and drift.junit.xml into dir (no-op when dir is empty).
+func exportDrift(dir string, results []core.DriftResult, d time.Duration) {
+	if dir == "" {
+		return
+	}
+	if d <= 0 {
+		d = time.Second
+	}
+	_ = os.MkdirAll(dir, 0755)
+	_ = report.WriteJSON(filepath.Join(dir, "drift.json"), report.DriftReportFromResults(results, d))
+	_ = report.WriteJUnitDrift(filepath.Join(dir, "drift.junit.xml"), results, d)
+}
+
+// StartFuzz sends schema-derived invalid requests and expects documented 4xx answers.
+// The run result is a *report.FuzzSummary carrying the seed used.
+func (s *Service) StartFuzz(cfg FuzzStartConfig, envName, authProfile, baseOverride string) (string, error) {
+	if err := s.checkConfig(envName, authProfile); err != nil {
+		return "", err
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = s.clk.Now().UnixNano()
+	}
+	return s.startRun("fuzz", func(ctx context.Context, run *runState) (interface{}, error) {
+		s.mu.RLock()
+		eps := append([]core.Endpoint(nil), s.endpoints...)
+		if len(cfg.EndpointIDs) > 0 {
+			eps = eps[:0]
+			for _, id := range cfg.EndpointIDs {
+				if ep, ok := s.byID[id]; ok {
+					eps = append(eps, ep)
+				}
+			}
+		}
+		s.mu.RUnlock()
+		var tags, methods []string
+		if cfg.Tag != "" {
+			tags = []string{cfg.Tag}
+		}
+		if cfg.Method != "" {
+			methods = []string{cfg.Method}
+		}
+		eps = core.FilterEndpoints(eps, tags, methods)
+
+		_, headers, authHeader := s.resolveContext(envName, authProfile)
+		base, operationServers := s.envBaseURL(envName)
+		if baseOverride != "" {
+			base = baseOverride
+		}
+		scfg := core.SmokeConfig{
+			BaseURL:          base,
+			Headers:          headers,
+			AuthHeader:       authHeader,
+			Workers:          cfg.Workers,
+			RateLimitRPS:     cfg.RateLimit,
+			Timeout:          time.Duration(max(cfg.TimeoutMS, 5000)) * time.Millisecond,
+			PathParams:       s.pathOverrides(envName),
+			PinBaseURL:       baseOverride != "",
+			OperationServers: operationServers,
+			ServerVars:       s.serverVars(envName),
+			Secrets:          s.schemeSecrets(envName, authProfile),
+		}
+		start := s.clk.Now()
+		okCount := 0
+		results := core.RunFuzz(ctx, scfg, eps, seed, func(done, total int, r core.FuzzResult) {
+			okCount += b2i(r.OK)
+			s.emitProgress(run.id, "fuzz", done, total, fmt.Sprintf("%s %s [%s %s]", r.Method, r.Path, r.Kind, r.Target), okCount, done-okCount)
+		})
+		d := s.clk.Now().Sub(start)
+		rep := report.FuzzReportFromResults(results, seed, d)
+		if err := ctx.Err(); err != nil {
+			return rep.Fuzz, err
+		}
 		if cfg.ExportDir != "" {
+			if d <= 0 {
+				d = time.Second
+			}
 			_ = os.MkdirAll(cfg.ExportDir, 0755)
-			_ = report.WriteJSON(filepath.Join(cfg.ExportDir, "drift.json"), report.DriftReportFromResults([]core.DriftResult{dr}, time.Second))
+			_ = report.WriteJSON(filepath.Join(cfg.ExportDir, "fuzz.json"), rep)
+			_ = report.WriteJUnitFuzz(filepath.Join(cfg.ExportDir, "fuzz.junit.xml"), results, seed, d)
 		}
-		return dr, nil
+		return rep.Fuzz, nil
 	})
 }
 
 // StartCompare performs A/B response compare between two environments.
+// With cfg.EndpointID the run result is one core.ABCompareResult, otherwise a []core.ABCompareResult.
 func (s *Service) StartCompare(cfg CompareStartConfig) (string, error) {
+	profileA, profileB := s.compareProfiles(cfg)
+	if err := s.checkConfig(cfg.EnvA, profileA); err != nil {
+		return "", err
+	}
+	if err := s.checkConfig(cfg.EnvB, profileB); err != nil {
+		return "", err
+	}
+	rules, err := s.compareRuleSet()
+	if err != nil {
+		return "", err
+	}
+	if cfg.EndpointID == "" {
+		return s.startCompareBulk(cfg, rules)
+	}
 	return s.startRun("compare", func(ctx context.Context, run *runState) (interface{}, error) {
 		_ = ctx
 		s.mu.RLock()
@@ -128,16 +295,110 @@ func (s *Service) StartCompare(cfg CompareStartConfig) (string, error) {
 		if !ok {
 			return nil, errors.New("endpoint not found")
 		}
-		baseA, headersA, authA := s.resolveContext(cfg.EnvA, "")
-		baseB, _, _ := s.resolveContext(cfg.EnvB, "")
-		res := core.RunABCompare(ep, baseA, baseB, headersA, authA, time.Duration(max(cfg.TimeoutMS, 5000))*time.Millisecond)
-		s.emitProgress(run.id, "compare", 1, 1, ep.Method+" "+ep.Path, b2i(res.StatusMatch), b2i(!res.StatusMatch))
+		a, b := s.compareSides(cfg)
+		res := core.RunABCompare(ep, a, b, rules.ForEndpoint(ep), time.Duration(max(cfg.TimeoutMS, 5000))*time.Millisecond)
+		s.emitProgress(run.id, "compare", 1, 1, ep.Method+" "+ep.Path, b2i(!res.Differs()), b2i(res.Differs()))
+		exportCompare(cfg, []core.ABCompareResult{res}, s.clk.Now().Sub(run.started))
 		return res, nil
 	})
 }
 
+// startCompareBulk compares every GET endpoint, or every endpoint matching cfg.Tag/cfg.Method,
+// using the smoke worker pool.
+func (s *Service) startCompareBulk(cfg CompareStartConfig, rules core.CompareRuleSet) (string, error) {
+	return s.startRun("compare", func(ctx context.Context, run *runState) (interface{}, error) {
+		s.mu.RLock()
+		eps := append([]core.Endpoint(nil), s.endpoints...)
+		s.mu.RUnlock()
+		var tags []string
+		if cfg.Tag != "" {
+			tags = []string{cfg.Tag}
+		}
+		methods := []string{"GET"}
+		if cfg.Method != "" {
+			methods = []string{cfg.Method}
+		}
+		eps = core.FilterEndpoints(eps, tags, methods)
+
+		a, b := s.compareSides(cfg)
+		ccfg := core.ABCompareConfig{
+			A:            a,
+			B:            b,
+			Rules:        rules,
+			Timeout:      time.Duration(max(cfg.TimeoutMS, 5000)) * time.Millisecond,
+			Workers:      cfg.Workers,
+			RateLimitRPS: cfg.RateLimit,
+		}
+		start := s.clk.Now()
+		same := 0
+		results := core.RunABCompareBulk(ctx, ccfg, eps, func(done, total int, r core.ABCompareResult) {
+			same += b2i(!r.Differs())
+			s.emitProgress(run.id, "compare", done, total, r.Method+" "+r.Path, same, done-same)
+		})
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		exportCompare(cfg, results, s.clk.Now().Sub(start))
+		return results, nil
+	})
+}
+
+// compareProfiles returns the auth profiles of both compare sides; an empty one falls back
+// to the workspace profile.
+func (s *Service) compareProfiles(cfg CompareStartConfig) (string, string) {
+	profileA, profileB := cfg.AuthProfileA, cfg.AuthProfileB
+	if ws, err := s.LoadWorkspace(); err == nil {
+		if profileA == "" {
+			profileA = ws.AuthProfile
+		}
+		if profileB == "" {
+			profileB = ws.AuthProfile
+		}
+	}
+	return profileA, profileB
+}
+
+// compareSides resolves base URL, env headers and credentials of both compare sides.
+func (s *Service) compareSides(cfg CompareStartConfig) (core.CompareSide, core.CompareSide) {
+	profileA, profileB := s.compareProfiles(cfg)
+	side := func(envName, profile string) core.CompareSide {
+		base, headers, authHeader := s.resolveContext(envName, profile)
+		return core.CompareSide{
+			BaseURL:    base,
+			Headers:    headers,
+			AuthHeader: authHeader,
+			Secrets:    s.schemeSecrets(envName, profile),
+			PathParams: s.pathOverrides(envName),
+		}
+	}
+	return side(cfg.EnvA, profileA), side(cfg.EnvB, profileB)
+}
+
+// exportCompare writes compare.json, compare.junit.xml and compare.html into cfg.ExportDir
+// (no-op when it is empty).
+func exportCompare(cfg CompareStartConfig, results []core.ABCompareResult, d time.Duration) {
+	if cfg.ExportDir == "" {
+		return
+	}
+	if d <= 0 {
+		d = time.Second
+	}
+	_ = os.MkdirAll(cfg.ExportDir, 0755)
+	rep := report.CompareReportFromResults(cfg.EnvA, cfg.EnvB, results, d)
+	_ = report.WriteJSON(filepath.Join(cfg.ExportDir, "compare.json"), rep)
+	_ = report.WriteJUnitCompare(filepath.Join(cfg.ExportDir, "compare.junit.xml"), results, d)
+	_ = report.WriteHTMLCompare(filepath.Join(cfg.ExportDir, "compare.html"), rep)
+}
+
 // StartLT executes load-test plan and streams metrics snapshots periodically.
 func (s *Service) StartLT(planPath string, cfg LTStartConfig) (string, error) {
+	var authHeader map[string]string
+	if cfg.AuthProfile != "" {
+		if err := s.checkConfig(cfg.EnvName, cfg.AuthProfile); err != nil {
+			return "", err
+		}
+		_, _, authHeader = s.resolveContext(cfg.EnvName, cfg.AuthProfile)
+	}
 	return s.startRun("lt", func(ctx context.Context, run *runState) (interface{}, error) {
 		p, err := lt.ParseFile(planPath)
 		if err != nil {
@@ -146,6 +407,9 @@ func (s *Service) StartLT(planPath string, cfg LTStartConfig) (string, error) {
 		r := &lt.Runner{Plan: p, Config: lt.DefaultRunConfig()}
 		r.Config.MaxErrorPct = cfg.MaxErrorPct
 		r.Config.MaxP95Ms = cfg.MaxP95Ms
+		if authHeader != nil {
+			r.Auth = func() (map[string]string, error) { return core.FreshAuthHeaders(authHeader) }
+		}
 
 		ticker := time.NewTicker(500 * time.Millisecond)
 		defer ticker.Stop()
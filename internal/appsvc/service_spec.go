@@ -1,8 +1,10 @@
 package appsvc
 
 import (
+	"encoding/base64"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"lazytest/internal/config"
@@ -10,10 +12,13 @@ import (
 )
 
 // LoadSpec parses OpenAPI and rebuilds endpoint read-model cache.
+// filePath may be a spec file, a directory of specs or an http(s) URL; URLs are fetched with
+// the headers and auth of the workspace environment/profile.
 //
 // Java analogy: this is the "spec import use-case" that updates an in-memory repository.
 func (s *Service) LoadSpec(filePath string) (SpecSummary, error) {
-	eps, doc, err := core.LoadOpenAPI(filePath)
+	ws, _ := s.LoadWorkspace()
+	eps, doc, err := core.LoadSpecs(filePath, s.specLoadOptions(ws))
 	if err != nil {
 		return SpecSummary{}, err
 	}
@@ -26,13 +31,19 @@ func (s *Service) LoadSpec(filePath string) (SpecSummary, error) {
 	s.byID = map[string]core.Endpoint{}
 
 	tags := map[string]struct{}{}
+	var specs []string
 	for _, ep := range eps {
 		id := endpointID(ep)
 		s.byID[id] = ep
 		for _, t := range ep.Tags {
 			tags[t] = struct{}{}
 		}
+		if !contains(specs, ep.Spec) {
+			specs = append(specs, ep.Spec)
+		}
 	}
+	sort.Strings(specs)
+	s.servers = core.CommonSpecServers(eps)
 
 	tagList := make([]string, 0, len(tags))
 	for t := range tags {
@@ -52,9 +63,36 @@ func (s *Service) LoadSpec(filePath string) (SpecSummary, error) {
 		EndpointsCount: len(eps),
 		TagCount:       len(tagList),
 		Tags:           tagList,
+		Specs:          specs,
+		Servers:        core.ServerURLs(s.servers, s.serverVarsLocked(ws.EnvName)),
 	}, nil
 }
 
+// DiffSpecs loads two versions of a spec (file, directory or URL) and lists the changes
+// between them, each classified as breaking or not. The loaded spec of the service is untouched.
+func (s *Service) DiffSpecs(oldSource, newSource string) (core.SpecDiff, error) {
+	ws, _ := s.LoadWorkspace()
+	opts := s.specLoadOptions(ws)
+	oldEps, _, err := core.LoadSpecs(oldSource, opts)
+	if err != nil {
+		return core.SpecDiff{}, fmt.Errorf("old spec: %w", err)
+	}
+	newEps, _, err := core.LoadSpecs(newSource, opts)
+	if err != nil {
+		return core.SpecDiff{}, fmt.Errorf("new spec: %w", err)
+	}
+	return core.DiffSpecs(oldEps, newEps), nil
+}
+
+// specLoadOptions sends the workspace environment headers and auth with remote spec requests.
+func (s *Service) specLoadOptions(ws Workspace) core.LoadOptions {
+	_, headers, authHeader := s.resolveContext(ws.EnvName, ws.AuthProfile)
+	for k, v := range authHeader {
+		headers[k] = v
+	}
+	return core.LoadOptions{Headers: headers}
+}
+
 // ListEndpoints returns filtered/sorted endpoint DTOs for UI presentation.
 func (s *Service) ListEndpoints(filter EndpointFilter) []EndpointDTO {
 	s.mu.RLock()
@@ -84,6 +122,7 @@ func (s *Service) ListEndpoints(filter EndpointFilter) []EndpointDTO {
 			Summary:     ep.Summary,
 			OperationID: ep.OperationID,
 			Tags:        ep.Tags,
+			Spec:        ep.Spec,
 		})
 	}
 
@@ -97,6 +136,7 @@ func (s *Service) ListEndpoints(filter EndpointFilter) []EndpointDTO {
 }
 
 // BuildExampleRequest builds a ready-to-send request template for an endpoint.
+// Supported overrides: "baseURL", "seed" (example data seed) and "example" (named example to use).
 func (s *Service) BuildExampleRequest(endpointID, envName, authProfile string, overrides map[string]string) (RequestDTO, error) {
 	s.mu.RLock()
 	ep, ok := s.byID[endpointID]
@@ -105,63 +145,307 @@ func (s *Service) BuildExampleRequest(endpointID, envName, authProfile string, o
 		return RequestDTO{}, fmt.Errorf("endpoint not found: %s", endpointID)
 	}
 
-	baseURL, headers, authHeader := s.resolveContext(envName, authProfile)
-	if v := overrides["baseURL"]; v != "" {
-		baseURL = v
+	if err := s.checkConfig(envName, authProfile); err != nil {
+		return RequestDTO{}, err
+	}
+	_, headers, authHeader := s.resolveContext(envName, authProfile)
+	// An explicit base wins; otherwise the env baseURL, then the spec servers.
+	envBase, operationServers := s.envBaseURL(envName)
+	baseURL, err := core.ResolveBaseURL(ep, overrides["baseURL"], envBase, s.serverVars(envName), operationServers)
+	if err != nil {
+		baseURL = ""
 	}
 
-	urlStr, err := core.BuildURL(baseURL, ep.Path, nil)
+	var seed int64
+	if v := overrides["seed"]; v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return RequestDTO{}, fmt.Errorf("invalid seed %q: %w", v, err)
+		}
+		seed = n
+	}
+	g := core.ExampleGeneratorFor(ep, seed, overrides["example"])
+	rs := core.BuildRequestSpecWith(ep, s.pathOverrides(envName), g)
+	authHeader = core.ApplySecurity(ep, &rs, s.schemeSecrets(envName, authProfile), authHeader)
+	urlStr, err := rs.URL(baseURL)
 	if err != nil {
 		return RequestDTO{}, err
 	}
-	body, _ := core.ExampleBody(ep.Schema)
 
 	merged := map[string]string{}
+	for k, v := range rs.Headers {
+		merged[k] = v
+	}
+	if c := rs.CookieHeader(); c != "" {
+		merged["Cookie"] = c
+	}
 	for k, v := range headers {
 		merged[k] = v
 	}
 	for k, v := range authHeader {
 		merged[k] = v
 	}
+	if rs.HasBody() {
+		merged["Content-Type"] = "application/json"
+	}
+
+	params := make([]ParamDTO, 0, len(rs.Params))
+	for _, p := range rs.Params {
+		params = append(params, ParamDTO{In: p.In, Name: p.Name, Value: p.Value, Pinned: p.Pinned})
+	}
+
+	// SendRequest expands the body the user edits; references that come from the spec are
+	// escaped unless the environment opts in with expandExamples.
+	body := string(rs.Body)
+	if expand := s.bodyExpander(envName); expand != nil {
+		if body, err = expand(body); err != nil {
+			return RequestDTO{}, fmt.Errorf("request body: %w", err)
+		}
+	} else {
+		body = strings.ReplaceAll(body, "${", "$${")
+	}
 
 	return RequestDTO{
 		EndpointID: endpointID,
 		Method:     ep.Method,
 		URL:        urlStr,
 		Headers:    merged,
-		Body:       string(body),
+		Body:       body,
+		Params:     params,
+		EnvName:    envName,
 	}, nil
 }
 
 // resolveContext reads env/auth settings and produces merged transport context.
+// Without an env baseURL the base falls back to the first usable server of the spec.
 func (s *Service) resolveContext(envName, authProfile string) (string, map[string]string, map[string]string) {
 	base := ""
 	headers := map[string]string{}
 	authHeader := map[string]string{}
 
+	s.mu.RLock()
+	if env := s.environmentLocked(envName); env != nil {
+		base = env.BaseURL
+		for k, v := range env.Headers {
+			headers[k] = v
+		}
+	}
+
+	if base == "" {
+		if urls := core.ServerURLs(s.servers, s.serverVarsLocked(envName)); len(urls) > 0 {
+			base = urls[0]
+		}
+	}
+
+	var profile *config.AuthProfile
+	if s.authCfg != nil {
+		profile = copyProfile(s.authCfg.GetAuthProfile(authProfile))
+	}
+	vars := s.varsLocked(envName)
+	s.mu.RUnlock()
+
+	// Profiles resolve outside the lock: secret helpers and OAuth2 token requests may block.
+	if p := resolveProfile(profile, vars); p != nil {
+		if p.Bearer() && p.Token != "" {
+			authHeader["Authorization"] = "Bearer " + p.Token
+		}
+		if p.Type == "apikey" && p.Header != "" && p.Key != "" {
+			authHeader[p.Header] = p.Key
+		}
+		if p.Type == "basic" && p.Username != "" {
+			authHeader["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(p.Username+":"+p.Password))
+		}
+	}
+	return base, headers, authHeader
+}
+
+// schemeSecrets maps the securitySchemes of the loaded spec to auth.yaml profiles
+// (see config.AuthConfig.ProfileForScheme); authProfile is preferred when it lists a scheme.
+// Profile values are expanded with the variables of envName.
+func (s *Service) schemeSecrets(envName, authProfile string) map[string]core.AuthSecret {
+	s.mu.RLock()
+	if s.authCfg == nil {
+		s.mu.RUnlock()
+		return nil
+	}
+	profiles := map[string]*config.AuthProfile{}
+	for _, name := range core.SecuritySchemeNames(s.endpoints) {
+		profiles[name] = copyProfile(s.authCfg.ProfileForScheme(name, authProfile))
+	}
+	vars := s.varsLocked(envName)
+	s.mu.RUnlock()
+
+	out := map[string]core.AuthSecret{}
+	for name, profile := range profiles {
+		if p := resolveProfile(profile, vars); p != nil {
+			out[name] = core.AuthSecret{Token: p.Token, Key: p.Key, Username: p.Username, Password: p.Password}
+		}
+	}
+	return out
+}
+
+// bodyExpander returns the ${...} expander of generated request bodies for envName; nil
+// unless the environment sets expandExamples.
+func (s *Service) bodyExpander(envName string) func(string) (string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if env := s.environmentLocked(envName); env == nil || !env.ExpandExamples {
+		return nil
+	}
+	return s.varsLocked(envName).Expand
+}
 
+// envBaseURL returns the baseURL of envName and whether its operation/path servers of the
+// spec win over it (see core.ResolveBaseURL).
+func (s *Service) envBaseURL(envName string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if env := s.environmentLocked(envName); env != nil {
+		return env.BaseURL, env.OperationServers
+	}
+	return "", false
+}
+
+// serverVars returns the server variable overrides of envName.
+func (s *Service) serverVars(envName string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.serverVarsLocked(envName)
+}
+
+func (s *Service) serverVarsLocked(envName string) map[string]string {
+	if env := s.environmentLocked(envName); env != nil {
+		return env.ServerVariables
+	}
+	return nil
+}
+
+// environmentLocked returns envName with its ${...} references expanded; nil when it is not
+// configured or does not resolve (checkConfig reports why before a run starts).
+func (s *Service) environmentLocked(envName string) *config.Environment {
+	if s.envCfg == nil {
+		return nil
+	}
+	env, err := s.envCfg.Resolve(envName)
+	if err != nil {
+		return nil
+	}
+	return env
+}
+
+// varsLocked returns the ${...} expander of envName; without such an environment only
+// ${env:NAME} and ${file:path} resolve.
+func (s *Service) varsLocked(envName string) config.Vars {
 	if s.envCfg != nil {
 		if env := s.envCfg.GetEnvironment(envName); env != nil {
-			base = env.BaseURL
-			for k, v := range env.Headers {
-				headers[k] = v
-			}
+			return env.Vars()
 		}
 	}
+	return config.Vars{}
+}
 
+// copyProfile copies p out of the loaded auth config so it can be resolved after s.mu is
+// released; nil stays nil.
+func copyProfile(p *config.AuthProfile) *config.AuthProfile {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	return &cp
+}
+
+// resolveProfile returns p with its ${...} references expanded by vars; nil when p is nil or
+// does not resolve. Resolving may run credential helpers or fetch an OAuth2 token, so it is
+// never called with s.mu held.
+func resolveProfile(p *config.AuthProfile, vars config.Vars) *config.AuthProfile {
+	if p == nil {
+		return nil
+	}
+	rp, err := p.Resolve(vars)
+	if err != nil {
+		return nil
+	}
+	if err := rp.FetchOAuth2Token(); err != nil {
+		return nil
+	}
+	return &rp
+}
+
+// checkConfig reports ${...} references of envName and of the auth profiles used with
+// authProfile that do not resolve, so a run fails up front instead of sending requests
+// without base URL or credentials.
+func (s *Service) checkConfig(envName, authProfile string) error {
+	if _, err := s.workspacePathParams(envName); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	if s.envCfg != nil {
+		if _, err := s.envCfg.Resolve(envName); err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+	}
+	var profiles []*config.AuthProfile
 	if s.authCfg != nil {
-		if p := s.authCfg.GetAuthProfile(authProfile); p != nil {
-			if p.Type == "jwt" && p.Token != "" {
-				authHeader["Authorization"] = "Bearer " + p.Token
-			}
-			if p.Type == "apikey" && p.Header != "" && p.Key != "" {
-				authHeader[p.Header] = p.Key
-			}
+		profiles = append(profiles, copyProfile(s.authCfg.GetAuthProfile(authProfile)))
+		for _, name := range core.SecuritySchemeNames(s.endpoints) {
+			profiles = append(profiles, copyProfile(s.authCfg.ProfileForScheme(name, authProfile)))
 		}
 	}
-	return base, headers, authHeader
+	vars := s.varsLocked(envName)
+	s.mu.RUnlock()
+
+	for _, p := range profiles {
+		if p == nil {
+			continue
+		}
+		rp, err := p.Resolve(vars)
+		if err != nil {
+			return err
+		}
+		if err := rp.FetchOAuth2Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathOverrides merges pinned path params from env.yaml and the workspace (workspace wins).
+func (s *Service) pathOverrides(envName string) core.ParamOverrides {
+	var fromEnv core.ParamOverrides
+	s.mu.RLock()
+	if env := s.environmentLocked(envName); env != nil {
+		fromEnv = env.PathParams
+	}
+	s.mu.RUnlock()
+
+	fromWS, _ := s.workspacePathParams(envName)
+	return core.MergeParamOverrides(fromEnv, fromWS)
+}
+
+// workspacePathParams returns the workspace path param overrides with their ${...}
+// references expanded by the variables of envName.
+func (s *Service) workspacePathParams(envName string) (core.ParamOverrides, error) {
+	ws, err := s.LoadWorkspace()
+	if err != nil {
+		return nil, nil
+	}
+	s.mu.RLock()
+	vars := s.varsLocked(envName)
+	s.mu.RUnlock()
+	return core.ParamOverrides(ws.PathParams).Expand(vars.Expand)
+}
+
+// compareRuleSet returns the env.yaml compare rules; invalid rules are an error, not
+// silently skipped.
+func (s *Service) compareRuleSet() (core.CompareRuleSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rules, err := s.envCfg.CompareRuleSet()
+	if err != nil {
+		return nil, fmt.Errorf("env compare rules: %w", err)
+	}
+	return rules, nil
 }
 
 // LoadConfigs loads env/auth yaml files and stores them in service context.
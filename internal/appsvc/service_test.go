@@ -2,11 +2,16 @@ package appsvc
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -146,6 +151,658 @@ paths:
 	}
 }
 
+func TestCompareAppliesEnvCompareRules(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /x:
+    get:
+      operationId: getX
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	serve := func(id string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Trace", id)
+			w.Write([]byte(`{"id":"` + id + `","v":1}`))
+		}))
+	}
+	a, b := serve("a1"), serve("b2")
+	defer a.Close()
+	defer b.Close()
+	env := filepath.Join(d, "env.yaml")
+	os.WriteFile(env, []byte(`environments:
+  - {name: a, baseURL: `+a.URL+`}
+  - {name: b, baseURL: `+b.URL+`}
+compare:
+  "*":
+    ignoreHeaders: [x-trace]
+  getX:
+    normalizers:
+      - {pattern: "[ab][0-9]", replace: "<id>"}
+`), 0644)
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadConfigs(env, ""); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.StartCompare(CompareStartConfig{EndpointID: "getX", EnvA: "a", EnvB: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := waitRun(t, s, id).Data.(core.ABCompareResult)
+	if cmp.Differs() || len(cmp.Ignored) != 2 || cmp.Ignored[0].Rule != "ignoreHeaders x-trace" || cmp.Ignored[1].Diff != "$.id" {
+		t.Errorf("compare rules from env config not applied: %+v", cmp)
+	}
+
+	os.WriteFile(env, []byte("compare:\n  \"*\":\n    normalizers: [{pattern: \"(\"}]\n"), 0644)
+	if err := s.LoadConfigs(env, ""); err == nil || !strings.Contains(err.Error(), "normalizer") {
+		t.Errorf("invalid normalizer must be rejected on load, got %v", err)
+	}
+	s.envCfg.Compare = map[string]config.CompareRules{"*": {Normalizers: []config.CompareNormalizer{{Pattern: "("}}}}
+	for _, cfg := range []CompareStartConfig{{EndpointID: "getX", EnvA: "a", EnvB: "b"}, {EnvA: "a", EnvB: "b"}} {
+		if _, err := s.StartCompare(cfg); err == nil || !strings.Contains(err.Error(), "normalizer") {
+			t.Errorf("invalid rules must be reported by compare %q, got %v", cfg.EndpointID, err)
+		}
+	}
+}
+
+func TestSmokeResolvesPathParams(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /users/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer, example: 42}}
+    get:
+      operationId: getUser
+      responses: {"200": {description: ok}}
+  /orders/{orderId}/items/{sku}:
+    get:
+      operationId: getItem
+      parameters:
+        - {name: orderId, in: path, required: true, schema: {type: string}}
+        - {name: sku, in: path, required: true, schema: {type: string, enum: [A1, B2]}}
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	seen := make(chan string, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.URL.Path
+	}))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{{
+		Name:       "dev",
+		PathParams: map[string]map[string]string{"getItem": {"orderId": "ord-7"}},
+	}}}
+	if _, err := s.StartSmoke(SmokeStartConfig{RunAll: true, Workers: 1, RateLimit: 50}, "dev", "", ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case p := <-seen:
+			got[p] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout, got %v", got)
+		}
+	}
+	if !got["/users/42"] || !got["/orders/ord-7/items/A1"] {
+		t.Fatalf("unexpected paths: %v", got)
+	}
+
+	req, err := s.BuildExampleRequest("getUser", "dev", "", map[string]string{"baseURL": "http://x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL != "http://x/users/42" {
+		t.Fatalf("url %s", req.URL)
+	}
+}
+
+func TestRequiredParamsAreGenerated(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /search:
+    get:
+      operationId: search
+      parameters:
+        - {name: q, in: query, required: true, schema: {type: string, example: shoes}}
+        - {name: ids, in: query, required: true, schema: {type: array, items: {type: integer}}}
+        - {name: page, in: query, schema: {type: integer}}
+        - {name: X-Tenant, in: header, required: true, schema: {type: string, default: acme}}
+        - {name: session, in: cookie, required: true, schema: {type: string, enum: [s1]}}
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	got := make(chan *http.Request, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got <- r }))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := s.BuildExampleRequest("search", "", "", map[string]string{"baseURL": "http://x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL != "http://x/search?ids=1&q=shoes" || req.Headers["X-Tenant"] != "acme" || req.Headers["Cookie"] != "session=s1" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if len(req.Params) != 4 {
+		t.Fatalf("expected 4 generated params, got %+v", req.Params)
+	}
+
+	if _, err := s.StartSmoke(SmokeStartConfig{RunAll: true, Workers: 1, RateLimit: 50}, "", "", ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-got:
+		if r.URL.Query().Get("q") != "shoes" || r.Header.Get("X-Tenant") != "acme" {
+			t.Fatalf("unexpected smoke request: %s %v", r.URL, r.Header)
+		}
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s1" {
+			t.Fatalf("missing cookie: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestValidateRequestBeforeSend(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /orders/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer, example: 7}}
+    put:
+      operationId: putOrder
+      parameters:
+        - {name: X-Tenant, in: header, required: true, schema: {type: string, example: acme}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [qty]
+              properties:
+                qty: {type: integer, minimum: 1, example: 2}
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	req, err := s.BuildExampleRequest("putOrder", "", "", map[string]string{"baseURL": ts.URL + "/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues, err := s.ValidateRequest(req); err != nil || len(issues) != 0 {
+		t.Fatalf("generated request should be valid: %v %+v", err, issues)
+	}
+
+	req.URL = ts.URL + "/api/orders/abc"
+	req.Body = `{"qty":0}`
+	delete(req.Headers, "X-Tenant")
+	issues, err := s.ValidateRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, is := range issues {
+		seen[is.In+" "+is.Name] = true
+	}
+	for _, want := range []string{"path id", "header X-Tenant", "body /qty"} {
+		if !seen[want] {
+			t.Fatalf("missing issue %q in %+v", want, issues)
+		}
+	}
+
+	resp, err := s.SendRequest(req)
+	if err != nil || resp.StatusCode != 200 || len(resp.RequestIssues) != len(issues) {
+		t.Fatalf("send should still go out and report issues: %v %+v", err, resp)
+	}
+	if _, err := s.ValidateRequest(RequestDTO{EndpointID: "nope"}); err == nil {
+		t.Fatal("expected unknown endpoint error")
+	}
+}
+
+func waitRun(t *testing.T, s *Service, id string) ResultDTO {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		res, err := s.GetRunResult(id)
+		if err == nil && res.Status != "running" {
+			return res
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("run %s did not finish", id)
+	return ResultDTO{}
+}
+
+func TestSmokeExpectationPolicies(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /missing:
+    get:
+      operationId: getMissing
+      responses:
+        "200": {description: ok}
+  /gone:
+    get:
+      operationId: getGone
+      responses:
+        "200": {description: ok}
+        "4XX": {description: client error}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(404) }))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	verdicts := func(expect string) map[string]core.SmokeResult {
+		id, err := s.StartSmoke(SmokeStartConfig{RunAll: true, Workers: 1, RateLimit: 50, Expect: expect}, "", "", ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := map[string]core.SmokeResult{}
+		for _, r := range waitRun(t, s, id).Data.([]core.SmokeResult) {
+			out[r.Path] = r
+		}
+		return out
+	}
+
+	doc := verdicts("")
+	if doc["/missing"].OK || !strings.Contains(doc["/missing"].Err, "not documented") || !doc["/gone"].OK {
+		t.Fatalf("documented policy: %+v", doc)
+	}
+	strict := verdicts("strict")
+	if strict["/missing"].OK || strict["/gone"].OK {
+		t.Fatalf("strict policy: %+v", strict)
+	}
+	legacy := verdicts("legacy")
+	if !legacy["/missing"].OK || !legacy["/gone"].OK {
+		t.Fatalf("legacy policy: %+v", legacy)
+	}
+	if _, err := s.StartSmoke(SmokeStartConfig{Expect: "bogus"}, "", "", ts.URL); err == nil {
+		t.Fatal("expected unknown policy error")
+	}
+}
+
+func TestSmokeContractInSamePass(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /x:
+    get:
+      operationId: getX
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name: {type: string}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"name":1}`)) }))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(d, "out")
+	id, err := s.StartSmoke(SmokeStartConfig{RunAll: true, Workers: 1, RateLimit: 50, CheckContract: true, ExportDir: out}, "", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := waitRun(t, s, id).Data.([]core.SmokeResult)
+	if len(results) != 1 || !results[0].OK || results[0].Contract == nil || results[0].Contract.OK {
+		t.Fatalf("expected status pass and contract drift: %+v", results)
+	}
+	junit, err := os.ReadFile(filepath.Join(out, "smoke.junit.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(junit), `classname="lazytest.smoke"`) || !strings.Contains(string(junit), `classname="lazytest.contract"`) {
+		t.Fatalf("expected status and contract testcases:\n%s", junit)
+	}
+}
+
+func TestSmokeContractBodyReadErrorIsNotDrift(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /x:
+    get:
+      operationId: getX
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: object}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"na`))
+	}))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.StartSmoke(SmokeStartConfig{RunAll: true, Workers: 1, RateLimit: 50, CheckContract: true}, "", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := waitRun(t, s, id).Data.([]core.SmokeResult)
+	if len(results) != 1 || results[0].OK || !strings.Contains(results[0].Err, "read body") {
+		t.Fatalf("expected a request error: %+v", results)
+	}
+	if c := results[0].Contract; c == nil || c.Err == "" || len(c.Findings) != 0 {
+		t.Fatalf("read error must not be reported as drift findings: %+v", c)
+	}
+}
+
+func TestDriftBulkWithFilterAndExport(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /a:
+    get:
+      tags: [x]
+      operationId: getA
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: object, required: [id], properties: {id: {type: integer}}}
+  /b:
+    get:
+      tags: [x]
+      operationId: getB
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: object, required: [id], properties: {id: {type: integer}}}
+  /c:
+    get:
+      tags: [y]
+      operationId: getC
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/b" {
+			w.Write([]byte(`{"id":"nope"}`))
+			return
+		}
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(d, "out")
+	id, err := s.StartDrift(DriftStartConfig{Tag: "x", RateLimit: 50, ExportDir: out}, "", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := waitRun(t, s, id)
+	results, ok := res.Data.([]core.DriftResult)
+	if !ok || len(results) != 2 {
+		t.Fatalf("expected 2 drift results, got %+v", res)
+	}
+	byPath := map[string]core.DriftResult{}
+	for _, r := range results {
+		byPath[r.Path] = r
+	}
+	if !byPath["/a"].OK || byPath["/b"].OK {
+		t.Fatalf("unexpected verdicts: %+v", results)
+	}
+	for _, f := range []string{"drift.json", "drift.junit.xml"} {
+		if _, err := os.Stat(filepath.Join(out, f)); err != nil {
+			t.Fatalf("missing export %s: %v", f, err)
+		}
+	}
+}
+
+func TestCompareBulkGETEndpointsAndExport(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /a:
+    get:
+      operationId: getA
+      responses: {"200": {description: ok}}
+    post:
+      operationId: postA
+      responses: {"200": {description: ok}}
+  /b:
+    get:
+      operationId: getB
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	serve := func(b string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/b" {
+				w.Write([]byte(`{"v":"` + b + `"}`))
+				return
+			}
+			w.Write([]byte(`{"v":"same"}`))
+		}))
+	}
+	a, b := serve("1"), serve("2")
+	defer a.Close()
+	defer b.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{{Name: "staging", BaseURL: a.URL}, {Name: "prod", BaseURL: b.URL}}}
+	out := filepath.Join(d, "out")
+	id, err := s.StartCompare(CompareStartConfig{EnvA: "staging", EnvB: "prod", RateLimit: 50, ExportDir: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := waitRun(t, s, id)
+	results, ok := res.Data.([]core.ABCompareResult)
+	if !ok || len(results) != 2 {
+		t.Fatalf("expected the 2 GET endpoints, got %+v", res)
+	}
+	for _, r := range results {
+		if want := r.Path == "/b"; r.Differs() != want {
+			t.Errorf("%s %s differs=%v", r.Method, r.Path, r.Differs())
+		}
+	}
+	for _, f := range []string{"compare.json", "compare.junit.xml", "compare.html"} {
+		if _, err := os.Stat(filepath.Join(out, f)); err != nil {
+			t.Fatalf("missing export %s: %v", f, err)
+		}
+	}
+	rep, err := report.ReadJSON(filepath.Join(out, "compare.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.AB == nil || rep.AB.Same != 1 || rep.AB.Differ != 1 || rep.AB.EnvA != "staging" {
+		t.Errorf("compare summary: %+v", rep.AB)
+	}
+	html, _ := os.ReadFile(filepath.Join(out, "compare.html"))
+	if !strings.Contains(string(html), "GET /b: differ") {
+		t.Errorf("html report lacks the differing endpoint:\n%s", html)
+	}
+}
+
+func TestCompareUsesPerSideHeadersAndAuth(t *testing.T) {
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /me:
+    get:
+      operationId: getMe
+      responses: {"200": {description: ok}}
+`), 0644)
+	env := func(token, tenant string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+token || r.Header.Get("X-Tenant") != tenant {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}))
+	}
+	a, b := env("dev-token", "dev"), env("test-token", "test")
+	defer a.Close()
+	defer b.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{
+		{Name: "dev", BaseURL: a.URL, Headers: map[string]string{"X-Tenant": "dev"}},
+		{Name: "test", BaseURL: b.URL, Headers: map[string]string{"X-Tenant": "test"}},
+	}}
+	s.authCfg = &config.AuthConfig{Profiles: []config.AuthProfile{
+		{Name: "dev-jwt", Type: "jwt", Token: "dev-token"},
+		{Name: "test-jwt", Type: "jwt", Token: "test-token"},
+	}}
+	id, err := s.StartCompare(CompareStartConfig{EndpointID: "getMe", EnvA: "dev", EnvB: "test", AuthProfileA: "dev-jwt", AuthProfileB: "test-jwt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := waitRun(t, s, id)
+	cmp, _ := res.Data.(core.ABCompareResult)
+	if cmp.StatusA != 200 || cmp.StatusB != 200 {
+		t.Fatalf("each side should send its own env headers and auth: %+v", res)
+	}
+}
+
+func TestFuzzExpectsDocumented4xx(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /pets:
+    post:
+      operationId: addPet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, maxLength: 4, example: rex}
+                kind: {type: string, enum: [cat, dog]}
+      responses:
+        "201": {description: created}
+        "400": {description: bad}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		name, ok := body["name"].(string)
+		switch {
+		case !ok:
+			w.WriteHeader(400)
+		case len(name) > 4:
+			w.WriteHeader(500) // the bug fuzzing should surface
+		default:
+			w.WriteHeader(201) // kind is never validated
+		}
+	}))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(d, "out")
+	id, err := s.StartFuzz(FuzzStartConfig{RateLimit: 100, ExportDir: out, Seed: 99}, "", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := waitRun(t, s, id)
+	sum, ok := res.Data.(*report.FuzzSummary)
+	if !ok || sum.Seed != 99 || sum.Total == 0 {
+		t.Fatalf("unexpected fuzz result: %+v", res)
+	}
+	verdicts := map[core.MutationKind]core.FuzzResult{}
+	for _, r := range sum.Results {
+		verdicts[r.Kind] = r
+	}
+	if r := verdicts[core.MutOverlongString]; r.OK || r.StatusCode != 500 {
+		t.Fatalf("overlong name should fail with a server error: %+v", r)
+	}
+	if r := verdicts[core.MutInvalidEnum]; r.OK || !strings.Contains(r.Err, "accepted") {
+		t.Fatalf("invalid enum accepted with 201 should fail: %+v", r)
+	}
+	for _, k := range []core.MutationKind{core.MutMissingRequired, core.MutMalformedJSON} {
+		if r := verdicts[k]; !r.OK {
+			t.Fatalf("%s should be rejected with documented 400: %+v", k, r)
+		}
+	}
+	if sum.ServerErrors != 1 {
+		t.Fatalf("expected one server error, got %d", sum.ServerErrors)
+	}
+	junit, err := os.ReadFile(filepath.Join(out, "fuzz.junit.xml"))
+	if err != nil || !strings.Contains(string(junit), `name="seed" value="99"`) || strings.Count(string(junit), "<testcase") != sum.Total {
+		t.Fatalf("unexpected junit export: %v\n%s", err, junit)
+	}
+}
+
 func TestLTMetricsAndThreshold(t *testing.T) {
 	m := lt.NewMetrics(0)
 	m.Record(10, true, 200)
@@ -228,3 +885,530 @@ func TestReportExportAndWorkspaceRoundTrip(t *testing.T) {
 		t.Fatalf("roundtrip mismatch")
 	}
 }
+
+func TestBuildExampleRequestSeed(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                id: {type: string, format: uuid}
+                email: {type: string, format: email}
+      responses: {"201": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	body := func(seed string) string {
+		req, err := s.BuildExampleRequest("createUser", "", "", map[string]string{"baseURL": "http://x", "seed": seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req.Body
+	}
+	if a, b := body("42"), body("42"); a != b {
+		t.Fatalf("same seed, different bodies:\n%s\n%s", a, b)
+	}
+	if a, b := body("42"), body("43"); a == b {
+		t.Fatalf("different seeds, same body: %s", a)
+	}
+	if _, err := s.BuildExampleRequest("createUser", "", "", map[string]string{"seed": "abc"}); err == nil {
+		t.Fatal("expected invalid seed error")
+	}
+}
+
+func TestSpecServersAsBaseFallback(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+servers:
+  - url: https://{region}.example.com
+    variables:
+      region: {default: eu}
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses: {"200": {description: ok}}
+  /upload:
+    post:
+      operationId: upload
+      servers: [{url: "https://files.example.com"}]
+      responses: {"201": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{{Name: "us", ServerVariables: map[string]string{"region": "us"}}}}
+	sum, err := s.LoadSpec(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sum.Servers) != 1 || sum.Servers[0] != "https://eu.example.com" {
+		t.Fatalf("summary servers = %v", sum.Servers)
+	}
+	urls := map[string]string{}
+	for _, id := range []string{"listUsers", "upload"} {
+		req, err := s.BuildExampleRequest(id, "us", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls[id] = req.URL
+	}
+	if urls["listUsers"] != "https://us.example.com/users" || urls["upload"] != "https://files.example.com/upload" {
+		t.Fatalf("unexpected urls: %v", urls)
+	}
+	req, _ := s.BuildExampleRequest("upload", "us", "", map[string]string{"baseURL": "http://x"})
+	if req.URL != "http://x/upload" {
+		t.Fatalf("explicit base should win, got %s", req.URL)
+	}
+}
+
+func TestCoverageFromHistory(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      tags: [users]
+      responses:
+        "200": {description: ok}
+        "404": {description: missing}
+  /orders:
+    get:
+      operationId: listOrders
+      tags: [orders]
+      responses: {"200": {description: ok}}
+`
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(spec), 0644)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{}`)) }))
+	defer ts.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	if c := s.Coverage(); c.OperationsTotal != 2 || c.OperationsHit != 0 {
+		t.Fatalf("coverage before runs: %+v", c)
+	}
+	id, err := s.StartSmoke(SmokeStartConfig{EndpointIDs: []string{"listUsers"}, Workers: 1, RateLimit: 50}, "", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRun(t, s, id)
+	id, err = s.StartDrift(DriftStartConfig{EndpointID: "listUsers"}, "", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRun(t, s, id)
+
+	c := s.Coverage()
+	if c.OperationsHit != 1 || c.OperationsPct != 50 || c.ResponsesHit != 1 || c.ResponsesTotal != 3 {
+		t.Fatalf("coverage: %+v", c)
+	}
+	users := c.Operations[1]
+	if users.Path != "/users" || users.Hits != 2 || len(users.RunTypes) != 2 {
+		t.Errorf("GET /users: %+v", users)
+	}
+	for _, tag := range c.Tags {
+		if (tag.Tag == "users") != (tag.OperationsPct == 100) {
+			t.Errorf("tag %s: %.1f%%", tag.Tag, tag.OperationsPct)
+		}
+	}
+}
+
+func TestEnvInheritanceAndVariables(t *testing.T) {
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /tenants/{tenant}/me:
+    get:
+      operationId: getMe
+      parameters:
+        - {name: tenant, in: path, required: true, schema: {type: string}}
+      responses: {"200": {description: ok}}
+`), 0644)
+	os.WriteFile(filepath.Join(d, "token.txt"), []byte("file-token\n"), 0600)
+	os.WriteFile(filepath.Join(d, "env.yaml"), []byte(`environments:
+  - name: base
+    baseURL: https://${host}/v1
+    headers: {X-Tenant: "${tenant}", X-Trace: "on"}
+    variables: {host: api.example.com, tenant: acme}
+    pathParams: {getMe: {tenant: "${tenant}"}}
+  - name: staging
+    extends: base
+    variables: {host: staging.example.com, token: "${file:token.txt}"}
+  - name: broken
+    extends: base
+    variables: {tenant: "${env:LAZYTEST_TEST_UNSET}"}
+`), 0644)
+	os.WriteFile(filepath.Join(d, "auth.yaml"), []byte(`profiles:
+  - name: jwt
+    type: jwt
+    token: ${token}
+`), 0644)
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadConfigs(filepath.Join(d, "env.yaml"), filepath.Join(d, "auth.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	req, err := s.BuildExampleRequest("getMe", "staging", "jwt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL != "https://staging.example.com/v1/tenants/acme/me" {
+		t.Errorf("url %s", req.URL)
+	}
+	if req.Headers["X-Tenant"] != "acme" || req.Headers["X-Trace"] != "on" || req.Headers["Authorization"] != "Bearer file-token" {
+		t.Errorf("headers %v", req.Headers)
+	}
+	if _, err := s.BuildExampleRequest("getMe", "base", "jwt", nil); err == nil || !strings.Contains(err.Error(), "${token}") {
+		t.Errorf("want undefined ${token} error, got %v", err)
+	}
+	os.Unsetenv("LAZYTEST_TEST_UNSET")
+	if _, err := s.StartSmoke(SmokeStartConfig{RunAll: true}, "broken", "", ""); err == nil || !strings.Contains(err.Error(), "LAZYTEST_TEST_UNSET") {
+		t.Errorf("want unset variable error, got %v", err)
+	}
+}
+
+func TestGeneratedRequestsAreNotInterpolated(t *testing.T) {
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /tenants/{tenant}/notes:
+    post:
+      operationId: addNote
+      parameters:
+        - {name: tenant, in: path, required: true, schema: {type: string}}
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+            example: {note: "${env:LAZYTEST_TEST_SECRET}"}
+      responses: {"200": {description: ok}}
+`), 0644)
+	t.Setenv("LAZYTEST_TEST_SECRET", "s3cr3t")
+	var mu sync.Mutex
+	var gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		gotPath, gotBody = r.URL.Path, string(b)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{{Name: "dev", BaseURL: srv.URL, Variables: map[string]string{"tenant": "acme"}}}}
+	if err := s.SaveWorkspace(Workspace{PathParams: map[string]map[string]string{"addNote": {"tenant": "${tenant}"}}}); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.StartSmoke(SmokeStartConfig{RunAll: true}, "dev", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitRun(t, s, id)
+	mu.Lock()
+	defer mu.Unlock()
+	if gotPath != "/tenants/acme/notes" {
+		t.Errorf("workspace path param not expanded: %s", gotPath)
+	}
+	if !strings.Contains(gotBody, "${env:LAZYTEST_TEST_SECRET}") || strings.Contains(gotBody, "s3cr3t") {
+		t.Errorf("spec example must be sent as is: %s", gotBody)
+	}
+}
+
+func TestRequestBodyVariables(t *testing.T) {
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /notes:
+    post:
+      operationId: addNote
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+            example: {tenant: "${tenant}"}
+      responses: {"200": {description: ok}}
+`), 0644)
+	var mu sync.Mutex
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		gotBody = string(b)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{
+		{Name: "dev", BaseURL: srv.URL, Variables: map[string]string{"tenant": "acme"}},
+		{Name: "trusted", BaseURL: srv.URL, Variables: map[string]string{"tenant": "acme"}, ExpandExamples: true},
+	}}
+	body := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return gotBody
+	}
+
+	// Explorer edits are expanded against the request's environment; $${ stays literal.
+	if _, err := s.SendRequest(RequestDTO{Method: "POST", URL: srv.URL + "/notes", Body: `{"t":"${tenant}","raw":"$${tenant}"}`, EnvName: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := body(); got != `{"t":"acme","raw":"${tenant}"}` {
+		t.Errorf("explorer body not expanded: %s", got)
+	}
+	if _, err := s.SendRequest(RequestDTO{Method: "POST", URL: srv.URL + "/notes", Body: `{"t":"${nope}"}`, EnvName: "dev"}); err == nil || !strings.Contains(err.Error(), "request body") {
+		t.Errorf("want undefined variable error, got %v", err)
+	}
+
+	// Without the opt-in the example is escaped, so sending it unedited keeps it literal.
+	req, err := s.BuildExampleRequest("addNote", "dev", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(req.Body, "$${tenant}") {
+		t.Errorf("example must be escaped without expandExamples: %s", req.Body)
+	}
+	if _, err := s.SendRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := body(); !strings.Contains(got, `"${tenant}"`) {
+		t.Errorf("escaped example must be sent literally: %s", got)
+	}
+	req, err = s.BuildExampleRequest("addNote", "trusted", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(req.Body, `"acme"`) {
+		t.Errorf("example not expanded with expandExamples: %s", req.Body)
+	}
+
+	// Smoke expands spec examples only for environments that opt in.
+	for env, want := range map[string]string{"dev": `"${tenant}"`, "trusted": `"acme"`} {
+		id, err := s.StartSmoke(SmokeStartConfig{RunAll: true}, env, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitRun(t, s, id)
+		if got := body(); !strings.Contains(got, want) {
+			t.Errorf("%s: want %s in smoke body, got %s", env, want, got)
+		}
+	}
+}
+
+func TestAuthSecretRefsAreResolvedAndRedacted(t *testing.T) {
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /me:
+    get:
+      operationId: getMe
+      responses: {"200": {description: ok}}
+`), 0644)
+	authYAML := `profiles:
+  - name: helper
+    type: jwt
+    token: ${cmd:printf helper-secret-1}
+  - name: from-env
+    type: apikey
+    header: X-API-Key
+    key: ${env:LAZYTEST_TEST_KEY}
+`
+	os.WriteFile(filepath.Join(d, "auth.yaml"), []byte(authYAML), 0600)
+	t.Setenv("LAZYTEST_TEST_KEY", "env-secret-2")
+	// Both sides echo the credential they received, as a misbehaving API might.
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"auth": r.Header.Get("Authorization"), "key": r.Header.Get("X-API-Key")})
+	}))
+	defer echo.Close()
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadConfigs("", filepath.Join(d, "auth.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{{Name: "a", BaseURL: echo.URL}, {Name: "b", BaseURL: echo.URL}}}
+	out := filepath.Join(d, "out")
+	id, err := s.StartCompare(CompareStartConfig{EndpointID: "getMe", EnvA: "a", EnvB: "b", AuthProfileA: "helper", AuthProfileB: "from-env", ExportDir: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := waitRun(t, s, id)
+	cmp, _ := res.Data.(core.ABCompareResult)
+	if !strings.Contains(cmp.BodyA, "Bearer helper-secret-1") || !strings.Contains(cmp.BodyB, "env-secret-2") {
+		t.Fatalf("secret refs not resolved: %+v", cmp)
+	}
+	files, _ := filepath.Glob(filepath.Join(out, "*"))
+	if len(files) == 0 {
+		t.Fatal("no reports exported")
+	}
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		if strings.Contains(string(data), "helper-secret-1") || strings.Contains(string(data), "env-secret-2") {
+			t.Errorf("%s contains a resolved secret:\n%s", filepath.Base(f), data)
+		}
+	}
+	if err := s.SaveWorkspace(Workspace{AuthPath: filepath.Join(d, "auth.yaml"), EnvName: "a", BaseURL: echo.URL, TokenAlias: "env-secret-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if ws, _ := os.ReadFile(filepath.Join(d, "ws.json")); strings.Contains(string(ws), "env-secret-2") || !strings.Contains(string(ws), echo.URL) {
+		t.Errorf("workspace.json contains a resolved secret or lost a field:\n%s", ws)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(d, "auth.yaml")); string(raw) != authYAML {
+		t.Errorf("auth.yaml rewritten:\n%s", raw)
+	}
+}
+
+func TestOAuth2ProfileTokensAreCachedAndRefreshed(t *testing.T) {
+	d := t.TempDir()
+	sp := filepath.Join(d, "o.yaml")
+	os.WriteFile(sp, []byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /me:
+    get:
+      operationId: getMe
+      responses: {"200": {description: ok}}
+`), 0644)
+	// The token endpoint issues tokens living one second; the API accepts only the latest.
+	var mu sync.Mutex
+	issued := 0
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "svc" || secret != "svc-secret" || r.PostFormValue("grant_type") != "client_credentials" || r.PostFormValue("scope") != "me:read" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		issued++
+		n := issued
+		mu.Unlock()
+		fmt.Fprintf(w, `{"access_token":"oauth-token-%d","token_type":"bearer","expires_in":1}`, n)
+	}))
+	defer tokens.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		want := fmt.Sprintf("Bearer oauth-token-%d", issued)
+		mu.Unlock()
+		if r.Header.Get("Authorization") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	os.WriteFile(filepath.Join(d, "auth.yaml"), []byte(`profiles:
+  - name: svc
+    type: oauth2_client_credentials
+    tokenURL: `+tokens.URL+`
+    clientID: svc
+    clientSecret: svc-secret
+    scopes: [me:read]
+`), 0600)
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if _, err := s.LoadSpec(sp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadConfigs("", filepath.Join(d, "auth.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	s.envCfg = &config.EnvConfig{Environments: []config.Environment{{Name: "dev", BaseURL: api.URL}}}
+
+	id, err := s.StartSmoke(SmokeStartConfig{RunAll: true}, "dev", "svc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := waitRun(t, s, id)
+	if rs, _ := res.Data.([]core.SmokeResult); len(rs) != 1 || rs[0].StatusCode != 200 {
+		t.Fatalf("smoke with oauth2 profile: %+v", res.Data)
+	}
+	req, err := s.BuildExampleRequest("getMe", "dev", "svc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := s.SendRequest(req); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("send: %+v, %v", resp, err)
+	}
+	mu.Lock()
+	n := issued
+	mu.Unlock()
+	if n != 1 {
+		t.Fatalf("token fetched %d times, want once (cached)", n)
+	}
+
+	// The request keeps the expired token; sending it again must use a fresh one.
+	time.Sleep(600 * time.Millisecond)
+	if resp, err := s.SendRequest(req); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("send after expiry: %+v, %v", resp, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if issued != 2 {
+		t.Errorf("token fetched %d times, want a refresh after expiry", issued)
+	}
+}
+
+func TestOAuth2TokenFetchDoesNotHoldServiceLock(t *testing.T) {
+	d := t.TempDir()
+	release := make(chan struct{})
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"access_token":"slow-token-1","token_type":"bearer"}`))
+	}))
+	defer tokens.Close()
+	defer close(release)
+	authPath := filepath.Join(d, "auth.yaml")
+	os.WriteFile(authPath, []byte(`profiles:
+  - name: slow
+    type: oauth2_client_credentials
+    tokenURL: `+tokens.URL+`
+    clientID: slow
+    clientSecret: slow-secret
+`), 0600)
+	s := NewService(filepath.Join(d, "ws.json"), sink{})
+	if err := s.LoadConfigs("", authPath); err != nil {
+		t.Fatal(err)
+	}
+	go s.resolveContext("", "slow")
+	time.Sleep(50 * time.Millisecond)
+	loaded := make(chan error, 1)
+	go func() { loaded <- s.LoadConfigs("", authPath) }()
+	select {
+	case err := <-loaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("LoadConfigs blocked while a token was being fetched")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"lazytest/internal/secrets"
 )
 
 // SaveWorkspace persists workspace.json.
@@ -14,6 +16,9 @@ func (s *Service) SaveWorkspace(ws Workspace) error {
 		ws.Version = 1
 	}
 	ws.UpdatedAtUnix = s.clk.Now().Unix()
+	// A token alias is the only credential-carrying field; resolved auth secrets pasted
+	// into it never reach workspace.json.
+	ws.TokenAlias = secrets.Redact(ws.TokenAlias)
 
 	b, err := json.MarshalIndent(ws, "", "  ")
 	if err != nil {
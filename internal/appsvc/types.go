@@ -10,6 +10,8 @@ type SpecSummary struct {
 	EndpointsCount int      `json:"endpointsCount"`
 	TagCount       int      `json:"tagCount"`
 	Tags           []string `json:"tags"`
+	Specs          []string `json:"specs,omitempty"`   // source files/URLs the endpoints came from
+	Servers        []string `json:"servers,omitempty"` // spec server URLs, variables expanded
 }
 
 // EndpointDTO is the UI-facing endpoint record (read-only data transfer object).
@@ -20,6 +22,7 @@ type EndpointDTO struct {
 	Summary     string   `json:"summary"`
 	OperationID string   `json:"operationID"`
 	Tags        []string `json:"tags"`
+	Spec        string   `json:"spec,omitempty"` // source spec file or URL
 }
 
 // RequestDTO is an outbound HTTP request model prepared by UI/application service.
@@ -30,6 +33,24 @@ type RequestDTO struct {
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
 	TimeoutMS  int               `json:"timeoutMs,omitempty"`
+	Params     []ParamDTO        `json:"params,omitempty"`
+	// EnvName supplies the variables of ${...} references in Body (see SendRequest).
+	EnvName string `json:"envName,omitempty"`
+}
+
+// ParamDTO is one parameter value filled into a generated request (path, query, header or cookie).
+type ParamDTO struct {
+	In     string `json:"in"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Pinned bool   `json:"pinned,omitempty"`
+}
+
+// RequestIssueDTO is one contract violation found in a request before it is sent.
+type RequestIssueDTO struct {
+	In      string `json:"in"`             // path | query | header | cookie | body
+	Name    string `json:"name,omitempty"` // parameter name or JSON pointer inside the body
+	Message string `json:"message"`
 }
 
 // ResponseDTO is a normalized HTTP response payload for UI rendering.
@@ -41,6 +62,8 @@ type ResponseDTO struct {
 	LatencyMS  int64               `json:"latencyMS"`
 	Error      string              `json:"error,omitempty"`
 	Err        string              `json:"err,omitempty"`
+	// RequestIssues lists contract violations of the sent request (see Service.ValidateRequest).
+	RequestIssues []RequestIssueDTO `json:"requestIssues,omitempty"`
 }
 
 // EndpointFilter is query criteria used by ListEndpoints.
@@ -58,28 +81,68 @@ type SmokeStartConfig struct {
 	RateLimit   int      `json:"rateLimit"`
 	TimeoutMS   int      `json:"timeoutMS"`
 	ExportDir   string   `json:"exportDir"`
+	Expect      string   `json:"expect,omitempty"` // documented (default) | strict | legacy
+	// CheckContract also validates each response body against its schema (drift) in the same pass.
+	CheckContract bool `json:"checkContract,omitempty"`
+	// Seed and Example select the generated example data (same seed, same payloads).
+	Seed    int64  `json:"seed,omitempty"`
+	Example string `json:"example,omitempty"`
+	// Anonymous sends no credentials: operations requiring auth pass only when rejected (401/403).
+	Anonymous bool `json:"anonymous,omitempty"`
 }
 
 // DriftStartConfig carries drift run parameters.
+// A non-empty EndpointID checks one endpoint; otherwise every endpoint matching Tag/Method is checked.
 type DriftStartConfig struct {
 	EndpointID string `json:"endpointID"`
 	TimeoutMS  int    `json:"timeoutMS"`
 	ExportDir  string `json:"exportDir"`
+	Tag        string `json:"tag,omitempty"`
+	Method     string `json:"method,omitempty"`
+	Workers    int    `json:"workers,omitempty"`
+	RateLimit  int    `json:"rateLimit,omitempty"`
+}
+
+// FuzzStartConfig carries fuzz run parameters.
+// EndpointIDs selects endpoints; when empty every endpoint matching Tag/Method is fuzzed.
+// Seed 0 picks a time-based seed; the seed actually used is part of the run result.
+type FuzzStartConfig struct {
+	EndpointIDs []string `json:"endpointIDs,omitempty"`
+	Tag         string   `json:"tag,omitempty"`
+	Method      string   `json:"method,omitempty"`
+	Workers     int      `json:"workers,omitempty"`
+	RateLimit   int      `json:"rateLimit,omitempty"`
+	TimeoutMS   int      `json:"timeoutMS"`
+	ExportDir   string   `json:"exportDir"`
+	Seed        int64    `json:"seed,omitempty"`
 }
 
 // CompareStartConfig carries A/B compare run parameters.
+// Without EndpointID every GET endpoint (or every endpoint matching Tag/Method) is compared.
+// Each side gets its env headers and its auth profile; an empty profile means the workspace one.
 type CompareStartConfig struct {
-	EndpointID string `json:"endpointID"`
-	EnvA       string `json:"envA"`
-	EnvB       string `json:"envB"`
-	OnlyDiff   bool   `json:"onlyDiff"`
-	TimeoutMS  int    `json:"timeoutMS"`
+	EndpointID   string `json:"endpointID"`
+	EnvA         string `json:"envA"`
+	EnvB         string `json:"envB"`
+	AuthProfileA string `json:"authProfileA,omitempty"`
+	AuthProfileB string `json:"authProfileB,omitempty"`
+	OnlyDiff     bool   `json:"onlyDiff"`
+	TimeoutMS    int    `json:"timeoutMS"`
+	Tag          string `json:"tag,omitempty"`
+	Method       string `json:"method,omitempty"`
+	Workers      int    `json:"workers,omitempty"`
+	RateLimit    int    `json:"rateLimit,omitempty"`
+	ExportDir    string `json:"exportDir,omitempty"`
 }
 
 // LTStartConfig carries load-test threshold settings.
 type LTStartConfig struct {
 	MaxErrorPct float64 `json:"maxErrorPct"`
 	MaxP95Ms    int64   `json:"maxP95Ms"`
+	// AuthProfile adds the credentials of this auth.yaml profile (with the variables of
+	// EnvName) to every request; empty sends only the plan's headers.
+	EnvName     string `json:"envName,omitempty"`
+	AuthProfile string `json:"authProfile,omitempty"`
 }
 
 type TCPStartConfig struct{}
@@ -107,6 +170,10 @@ type Workspace struct {
 	BaseURL       string `json:"baseURL"`
 	TokenAlias    string `json:"tokenAlias,omitempty"`
 	UpdatedAtUnix int64  `json:"updatedAtUnix"`
+	// PathParams pins path parameter values per endpoint selector; wins over env.yaml.
+	PathParams map[string]map[string]string `json:"pathParams,omitempty"`
+	// ExampleSeed seeds the explorer's generated example requests so payloads are reproducible.
+	ExampleSeed int64 `json:"exampleSeed,omitempty"`
 }
 
 // RunProgressEvent is incremental progress event published by Service.
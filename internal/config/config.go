@@ -2,8 +2,14 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"lazytest/internal/core"
+	"lazytest/internal/secrets"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,28 +17,93 @@ import (
 // EnvConfig represents env.yaml: environments (dev/test/prod).
 type EnvConfig struct {
 	Environments []Environment `yaml:"environments"`
+	// Compare holds A/B compare rules per endpoint selector ("*", "/users/{id}",
+	// "GET /users/{id}" or an operationId).
+	Compare map[string]CompareRules `yaml:"compare,omitempty"`
+}
+
+// CompareRules is the env.yaml form of one selector's A/B compare rules; appsvc and the CLI
+// convert them to core.CompareRules, which validates patterns and paths.
+type CompareRules struct {
+	IgnorePaths      []string                `yaml:"ignorePaths,omitempty"`
+	IgnoreHeaders    []string                `yaml:"ignoreHeaders,omitempty"`
+	NumericTolerance float64                 `yaml:"numericTolerance,omitempty"`
+	Normalizers      []CompareNormalizer     `yaml:"normalizers,omitempty"`
+	UnorderedArrays  []CompareUnorderedArray `yaml:"unorderedArrays,omitempty"`
+}
+
+// CompareNormalizer rewrites every match of Pattern to Replace before comparing.
+type CompareNormalizer struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// CompareUnorderedArray compares the array at Path regardless of order, pairing elements by Key.
+type CompareUnorderedArray struct {
+	Path string `yaml:"path"`
+	Key  string `yaml:"key,omitempty"`
 }
 
 // Environment holds baseURL, headers, rate limit for one env.
+// String values may contain ${name}, ${env:NAME} and ${file:path} references, expanded by
+// EnvConfig.Resolve with the environment's Variables (see Vars).
 type Environment struct {
 	Name        string            `yaml:"name"`
+	// Extends names the parent environment whose settings this one inherits and overrides.
+	Extends string `yaml:"extends,omitempty"`
+	// Variables are the values of ${name} references; inherited ones can be overridden.
+	Variables map[string]string `yaml:"variables,omitempty"`
 	BaseURL     string            `yaml:"baseURL"`
 	Headers     map[string]string `yaml:"headers"`
 	RateLimitRPS int               `yaml:"rateLimitRPS"`
+	// PathParams pins path parameter values per endpoint selector
+	// ("*", "/users/{id}", "GET /users/{id}" or an operationId).
+	PathParams map[string]map[string]string `yaml:"pathParams,omitempty"`
+	// ServerVariables override the defaults of spec server variables ({region}, {version}).
+	ServerVariables map[string]string `yaml:"serverVariables,omitempty"`
+	// OperationServers lets the servers an operation or path item declares in the spec win
+	// over BaseURL; by default spec servers are only used without a BaseURL.
+	OperationServers bool `yaml:"operationServers,omitempty"`
+	// ExpandExamples expands ${...} references in request bodies generated from the spec
+	// (smoke, drift and the explorer). Enable it only for specs you trust: a reference can
+	// read environment variables and files.
+	ExpandExamples bool `yaml:"expandExamples,omitempty"`
+
+	dir string // directory of env.yaml, for relative ${file:path} references
 }
 
 // AuthConfig represents auth.yaml: JWT / API key profiles.
 type AuthConfig struct {
 	Profiles []AuthProfile `yaml:"profiles"`
+	// Vault is the encrypted secrets file of ${vault:name} references, relative to auth.yaml.
+	Vault string `yaml:"vault,omitempty"`
 }
 
-// AuthProfile is one auth method (jwt or apikey).
+// AuthProfile is one auth method (jwt, apikey, basic, oauth2_client_credentials or
+// oauth2_password). OAuth2 profiles fetch their bearer token from TokenURL on use.
+// Credentials may be secret references instead of plaintext: ${env:NAME}, ${file:path},
+// ${cmd:command line} (a credential helper's stdout) and ${vault:name}, resolved by Resolve.
 type AuthProfile struct {
-	Name   string `yaml:"name"`
-	Type   string `yaml:"type"` // "jwt" or "apikey"
-	Token  string `yaml:"token,omitempty"`
-	Header string `yaml:"header,omitempty"`
-	Key    string `yaml:"key,omitempty"`
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"` // "jwt", "apikey", "basic", "oauth2_client_credentials" or "oauth2_password"
+	Token    string `yaml:"token,omitempty"`
+	Header   string `yaml:"header,omitempty"`
+	Key      string `yaml:"key,omitempty"`
+	Username string `yaml:"username,omitempty"` // basic and oauth2_password
+	Password string `yaml:"password,omitempty"`
+	// OAuth2 token request (oauth2_* types).
+	TokenURL     string   `yaml:"tokenURL,omitempty"`
+	ClientID     string   `yaml:"clientID,omitempty"`
+	ClientSecret string   `yaml:"clientSecret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	Audience     string   `yaml:"audience,omitempty"`
+	ClientAuth   string   `yaml:"clientAuth,omitempty"` // "basic" (default) or "post"
+	// Schemes lists the spec securitySchemes this profile satisfies; a profile named like a
+	// scheme satisfies it too.
+	Schemes []string `yaml:"schemes,omitempty"`
+
+	dir   string // directory of auth.yaml, for relative ${file:path}, ${cmd:...} and the vault
+	vault string // vault path of AuthConfig.Vault
 }
 
 // LoadEnvConfig reads env.yaml from path.
@@ -45,9 +116,172 @@ func LoadEnvConfig(path string) (*EnvConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse env config: %w", err)
 	}
+	if err := cfg.inherit(); err != nil {
+		return nil, fmt.Errorf("parse env config: %w", err)
+	}
+	if _, err := cfg.CompareRuleSet(); err != nil {
+		return nil, fmt.Errorf("parse env config: %w", err)
+	}
+	for i := range cfg.Environments {
+		cfg.Environments[i].dir = filepath.Dir(path)
+	}
 	return &cfg, nil
 }
 
+// CompareRuleSet converts the compare rules of e to core rules and validates them; a nil e
+// has no rules.
+func (e *EnvConfig) CompareRuleSet() (core.CompareRuleSet, error) {
+	set := core.CompareRuleSet{}
+	if e == nil {
+		return set, nil
+	}
+	for sel, r := range e.Compare {
+		c := core.CompareRules{IgnorePaths: r.IgnorePaths, IgnoreHeaders: r.IgnoreHeaders, NumericTolerance: r.NumericTolerance}
+		for _, n := range r.Normalizers {
+			c.Normalizers = append(c.Normalizers, core.Normalizer{Pattern: n.Pattern, Replace: n.Replace})
+		}
+		for _, u := range r.UnorderedArrays {
+			c.UnorderedArrays = append(c.UnorderedArrays, core.UnorderedArray{Path: u.Path, Key: u.Key})
+		}
+		set[sel] = c
+	}
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// inherit merges every environment with its extends chain: maps are merged key by key and
+// other settings are taken from the parent when unset.
+func (e *EnvConfig) inherit() error {
+	done := map[string]bool{}
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		if done[name] {
+			return nil
+		}
+		for _, c := range chain {
+			if c == name {
+				return fmt.Errorf("environment %q: extends cycle %s -> %s", chain[0], strings.Join(chain, " -> "), name)
+			}
+		}
+		env := e.GetEnvironment(name)
+		if env.Extends != "" {
+			parent := e.GetEnvironment(env.Extends)
+			if parent == nil {
+				return fmt.Errorf("environment %q extends unknown environment %q", name, env.Extends)
+			}
+			if err := resolve(parent.Name, append(chain, name)); err != nil {
+				return err
+			}
+			env.inheritFrom(parent)
+		}
+		done[name] = true
+		return nil
+	}
+	for _, env := range e.Environments {
+		if err := resolve(env.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (env *Environment) inheritFrom(parent *Environment) {
+	if env.BaseURL == "" {
+		env.BaseURL = parent.BaseURL
+	}
+	if env.RateLimitRPS == 0 {
+		env.RateLimitRPS = parent.RateLimitRPS
+	}
+	env.OperationServers = env.OperationServers || parent.OperationServers
+	env.ExpandExamples = env.ExpandExamples || parent.ExpandExamples
+	env.Variables = mergeStrings(parent.Variables, env.Variables)
+	env.Headers = mergeStrings(parent.Headers, env.Headers)
+	env.ServerVariables = mergeStrings(parent.ServerVariables, env.ServerVariables)
+	if len(parent.PathParams) > 0 {
+		merged := map[string]map[string]string{}
+		for sel, params := range parent.PathParams {
+			merged[sel] = mergeStrings(params, env.PathParams[sel])
+		}
+		for sel, params := range env.PathParams {
+			if _, ok := merged[sel]; !ok {
+				merged[sel] = params
+			}
+		}
+		env.PathParams = merged
+	}
+}
+
+// mergeStrings returns base overridden by over; nil when both are empty.
+func mergeStrings(base, over map[string]string) map[string]string {
+	if len(base) == 0 && len(over) == 0 {
+		return over
+	}
+	out := make(map[string]string, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
+
+// Vars returns the ${...} expander of env.
+func (env *Environment) Vars() Vars {
+	return Vars{Values: env.Variables, Dir: env.dir}
+}
+
+// Resolve returns a copy of the environment named name with every ${...} reference of its
+// base URL, headers, path params and server variables expanded. References are resolved on
+// each call, so a missing environment variable only fails the environment that uses it.
+// Header values read from ${env:...} and ${file:...} (directly or through a variable) are
+// remembered for secrets.Redact, as they often carry tokens and API keys.
+// It returns nil, nil when no environment has that name.
+func (e *EnvConfig) Resolve(name string) (*Environment, error) {
+	env := e.GetEnvironment(name)
+	if env == nil {
+		return nil, nil
+	}
+	out := *env
+	vars := env.Vars()
+	var err error
+	expandWith := func(vars Vars, s string) string {
+		if err != nil {
+			return s
+		}
+		v, xerr := vars.Expand(s)
+		if xerr != nil {
+			err = fmt.Errorf("environment %q: %w", name, xerr)
+		}
+		return v
+	}
+	expandMap := func(vars Vars, m map[string]string) map[string]string {
+		if m == nil {
+			return nil
+		}
+		out := make(map[string]string, len(m))
+		for k, v := range m {
+			out[k] = expandWith(vars, v)
+		}
+		return out
+	}
+	out.BaseURL = expandWith(vars, env.BaseURL)
+	out.Headers = expandMap(vars.remembering(), env.Headers)
+	out.ServerVariables = expandMap(vars, env.ServerVariables)
+	if env.PathParams != nil {
+		out.PathParams = make(map[string]map[string]string, len(env.PathParams))
+		for sel, params := range env.PathParams {
+			out.PathParams[sel] = expandMap(vars, params)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // LoadAuthConfig reads auth.yaml from path.
 func LoadAuthConfig(path string) (*AuthConfig, error) {
 	data, err := os.ReadFile(path)
@@ -58,9 +292,22 @@ func LoadAuthConfig(path string) (*AuthConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parse auth config: %w", err)
 	}
+	dir := filepath.Dir(path)
+	vault := cfg.VaultPath(path)
+	for i := range cfg.Profiles {
+		cfg.Profiles[i].dir, cfg.Profiles[i].vault = dir, vault
+	}
 	return &cfg, nil
 }
 
+// VaultPath returns the vault file of an auth.yaml read from path, "" when none is set.
+func (a *AuthConfig) VaultPath(path string) string {
+	if a.Vault == "" || filepath.IsAbs(a.Vault) {
+		return a.Vault
+	}
+	return filepath.Join(filepath.Dir(path), a.Vault)
+}
+
 // GetEnvironment returns env by name from EnvConfig.
 func (e *EnvConfig) GetEnvironment(name string) *Environment {
 	for i := range e.Environments {
@@ -71,6 +318,106 @@ func (e *EnvConfig) GetEnvironment(name string) *Environment {
 	return nil
 }
 
+// ProfileForScheme returns the profile satisfying the security scheme named scheme.
+// preferred (the selected profile) wins when it lists the scheme; then a profile listing it,
+// then a profile with the scheme's name.
+func (a *AuthConfig) ProfileForScheme(scheme, preferred string) *AuthProfile {
+	if p := a.GetAuthProfile(preferred); p != nil && contains(p.Schemes, scheme) {
+		return p
+	}
+	for i := range a.Profiles {
+		if contains(a.Profiles[i].Schemes, scheme) {
+			return &a.Profiles[i]
+		}
+	}
+	return a.GetAuthProfile(scheme)
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuth2 types of AuthProfile.Type.
+const (
+	AuthOAuth2ClientCredentials = "oauth2_client_credentials"
+	AuthOAuth2Password          = "oauth2_password"
+)
+
+// Bearer reports whether p sends its Token as "Authorization: Bearer".
+func (p AuthProfile) Bearer() bool {
+	return p.Type == "jwt" || p.Type == AuthOAuth2ClientCredentials || p.Type == AuthOAuth2Password
+}
+
+// Resolve returns a copy of p with the ${...} references of its token, header, key and
+// credentials expanded by vars (the variables of the selected environment) and the secret
+// references of auth.yaml. Secrets are resolved on use, never stored back in the config, and
+// remembered so reports, workspace.json and logs redact them (see secrets.Redact).
+// The Token of OAuth2 profiles is fetched from TokenURL by FetchOAuth2Token, not by Resolve.
+func (p AuthProfile) Resolve(vars Vars) (AuthProfile, error) {
+	if p.dir != "" {
+		vars.Dir = p.dir
+	}
+	vars.Kinds = map[string]func(string) (string, error){
+		"cmd": func(cmdline string) (string, error) {
+			return secrets.Command(cmdline, p.dir)
+		},
+		"vault": func(name string) (string, error) {
+			if p.vault == "" {
+				return "", fmt.Errorf("${vault:%s}: no vault set in auth.yaml", name)
+			}
+			return secrets.VaultSecret(p.vault, name)
+		},
+	}
+	for _, f := range []*string{&p.Token, &p.Header, &p.Key, &p.Username, &p.Password, &p.TokenURL, &p.ClientID, &p.ClientSecret, &p.Audience} {
+		v, err := vars.Expand(*f)
+		if err != nil {
+			return AuthProfile{}, fmt.Errorf("auth profile %q: %w", p.Name, err)
+		}
+		*f = v
+	}
+	for _, v := range []string{p.Token, p.Key, p.Password, p.ClientSecret} {
+		secrets.Remember(v)
+	}
+	if p.Type == "basic" && p.Username != "" {
+		secrets.Remember(base64.StdEncoding.EncodeToString([]byte(p.Username + ":" + p.Password)))
+	}
+	return p, nil
+}
+
+// FetchOAuth2Token sets the Token of a resolved OAuth2 profile to the handle of its token
+// source, a real access token that core.FreshAuthHeaders replaces with the current one when a
+// request is sent. Other profiles are left alone.
+func (p *AuthProfile) FetchOAuth2Token() error {
+	cfg := core.OAuth2Config{
+		TokenURL:     p.TokenURL,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Scopes:       p.Scopes,
+		Audience:     p.Audience,
+		ClientAuth:   p.ClientAuth,
+	}
+	switch p.Type {
+	case AuthOAuth2ClientCredentials:
+		cfg.Grant = core.OAuth2ClientCredentials
+	case AuthOAuth2Password:
+		cfg.Grant = core.OAuth2Password
+		cfg.Username, cfg.Password = p.Username, p.Password
+	default:
+		return nil
+	}
+	token, err := core.OAuth2TokenSource(cfg).Handle()
+	if err != nil {
+		return fmt.Errorf("auth profile %q: %w", p.Name, err)
+	}
+	p.Token = token
+	return nil
+}
+
 // GetAuthProfile returns profile by name.
 func (a *AuthConfig) GetAuthProfile(name string) *AuthProfile {
 	for i := range a.Profiles {
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lazytest/internal/secrets"
+)
+
+// Vars expands ${...} references in user-supplied configuration values:
+//
+//	${name}       a variable of Values (which may reference other variables)
+//	${env:NAME}   the process environment variable NAME
+//	${file:path}  the content of a file (without its trailing newline), relative to Dir
+//
+// $${ stands for a literal ${. Unknown variables, unset environment variables and unreadable
+// files are errors.
+type Vars struct {
+	Values map[string]string
+	Dir    string
+	// Kinds adds reference kinds: ${kind:name} is Kinds[kind](name), e.g. the secret
+	// references of auth.yaml.
+	Kinds map[string]func(name string) (string, error)
+}
+
+// maxVarDepth bounds nested variable references (and catches reference cycles).
+const maxVarDepth = 10
+
+// Expand replaces every ${...} reference in s.
+func (v Vars) Expand(s string) (string, error) {
+	return v.expand(s, 0)
+}
+
+func (v Vars) expand(s string, depth int) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if depth > maxVarDepth {
+		return "", fmt.Errorf("variables nested deeper than %d (reference cycle?)", maxVarDepth)
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", s)
+		}
+		b.WriteString(s[:i])
+		val, err := v.lookup(s[i+2:i+end], depth)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(val)
+		s = s[i+end+1:]
+	}
+}
+
+func (v Vars) lookup(ref string, depth int) (string, error) {
+	kind, name, ok := strings.Cut(ref, ":")
+	if !ok {
+		val, found := v.Values[ref]
+		if !found {
+			return "", fmt.Errorf("undefined variable ${%s}", ref)
+		}
+		return v.expand(val, depth+1)
+	}
+	if fn, ok := v.Kinds[kind]; ok {
+		return fn(name)
+	}
+	switch kind {
+	case "env":
+		val, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("${env:%s}: environment variable %s is not set", name, name)
+		}
+		return val, nil
+	case "file":
+		path := name
+		if !filepath.IsAbs(path) && v.Dir != "" {
+			path = filepath.Join(v.Dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("${file:%s}: %w", name, err)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+	}
+	return "", fmt.Errorf("unknown reference ${%s} (want ${name}, ${env:NAME} or ${file:path})", ref)
+}
+
+// remembering returns v with the values of ${env:NAME} and ${file:path} references, also
+// those reached through variables, remembered for secrets.Redact.
+func (v Vars) remembering() Vars {
+	plain := v
+	out := v
+	out.Kinds = map[string]func(string) (string, error){}
+	for k, fn := range v.Kinds {
+		out.Kinds[k] = fn
+	}
+	for _, kind := range []string{"env", "file"} {
+		if _, ok := v.Kinds[kind]; ok {
+			continue
+		}
+		kind := kind
+		out.Kinds[kind] = func(name string) (string, error) {
+			val, err := plain.lookup(kind+":"+name, 0)
+			if err == nil {
+				secrets.Remember(val)
+			}
+			return val, err
+		}
+	}
+	return out
+}
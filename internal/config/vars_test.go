@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lazytest/internal/secrets"
+)
+
+func TestVarsExpand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("s3cret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LAZYTEST_TEST_REGION", "eu")
+	v := Vars{Values: map[string]string{"host": "${region}.example.com", "region": "${env:LAZYTEST_TEST_REGION}", "loop": "${loop}"}, Dir: dir}
+	for in, want := range map[string]string{
+		"https://${host}/v1":        "https://eu.example.com/v1",
+		"Bearer ${file:secret.txt}": "Bearer s3cret",
+		"literal $${host}":          "literal ${host}",
+		"no refs":                   "no refs",
+	} {
+		got, err := v.Expand(in)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for in, want := range map[string]string{
+		"${missing}":                "undefined variable",
+		"${env:LAZYTEST_TEST_NONE}": "not set",
+		"${file:none.txt}":          "none.txt",
+		"${loop}":                   "cycle",
+		"${vault:x}":                "unknown reference",
+		"${host":                    "unterminated",
+	} {
+		if _, err := v.Expand(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: want error containing %q, got %v", in, want, err)
+		}
+	}
+
+}
+
+func TestResolveRemembersHeaderSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tok.txt"), []byte("ft9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LAZYTEST_TEST_TENANT", "tenant-key-1")
+	t.Setenv("LAZYTEST_TEST_HOST_REGION", "ap")
+	cfg := &EnvConfig{Environments: []Environment{{
+		Name:      "dev",
+		Variables: map[string]string{"tok": "${file:tok.txt}"},
+		BaseURL:   "https://${env:LAZYTEST_TEST_HOST_REGION}.example.com",
+		Headers:   map[string]string{"X-Tenant": "${env:LAZYTEST_TEST_TENANT}", "Authorization": "Bearer ${tok}"},
+		dir:       dir,
+	}}}
+	env, err := cfg.Resolve("dev")
+	if err != nil || env.Headers["Authorization"] != "Bearer ft9" {
+		t.Fatalf("Resolve: %+v, %v", env, err)
+	}
+	got := secrets.Redact("GET https://ap.example.com/x?t=tenant-key-1: bad token ft9")
+	if got != "GET https://ap.example.com/x?t=***: bad token ***" {
+		t.Errorf("header secrets from env/file must be redacted, other values kept: %s", got)
+	}
+}
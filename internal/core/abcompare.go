@@ -2,36 +2,87 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // ABCompareResult holds diff between env A and env B for one request.
+// HeadersDiff, BodyStructureDiff and BodyValueDiff are the real differences; differences
+// silenced by compare rules are listed in Ignored.
 type ABCompareResult struct {
-	Path         string
-	Method       string
-	StatusA      int
-	StatusB      int
-	StatusMatch  bool
-	HeadersDiff  []string
+	Path              string
+	Method            string
+	StatusA           int
+	StatusB           int
+	StatusMatch       bool
+	HeadersDiff       []string
 	BodyStructureDiff []string
-	BodyValueDiff []string
-	ErrA         string
-	ErrB         string
+	BodyValueDiff     []string
+	Ignored           []IgnoredDiff `json:",omitempty"`
+	ErrA              string
+	ErrB              string
+	// Patch turns body A into body B (RFC 6902); ignored differences are left out.
+	Patch []PatchOp `json:",omitempty"`
+	// Request is the replayed request (path and query) when the result comes from a traffic
+	// replay; Path is then the spec path it matched (see RunReplayCompare).
+	Request string `json:",omitempty"`
+	// BodyA and BodyB are the response bodies (cut at maxCompareBody) for side-by-side reports.
+	BodyA string `json:",omitempty"`
+	BodyB string `json:",omitempty"`
 }
 
-// RunABCompare sends the same request to baseURLA and baseURLB and diffs status, headers, body structure.
-func RunABCompare(ep Endpoint, baseURLA, baseURLB string, headers map[string]string, authHeader map[string]string, timeout time.Duration) ABCompareResult {
+// maxCompareBody bounds the response bodies kept in an ABCompareResult.
+const maxCompareBody = 64 << 10
+
+// IgnoredDiff is a difference a compare rule silenced.
+type IgnoredDiff struct {
+	Diff string // as it would have been reported: "header value: date", "$.meta.requestId"
+	Rule string // "ignoreHeaders Date", "ignorePaths $..updatedAt", "numericTolerance 0.01", "normalizer <pattern>"
+}
+
+// Name labels the result in reports: method and path, or the replayed request.
+func (r ABCompareResult) Name() string {
+	if r.Request != "" {
+		return r.Method + " " + r.Request
+	}
+	return r.Method + " " + r.Path
+}
+
+// Differs reports whether the two sides differ after compare rules were applied.
+func (r ABCompareResult) Differs() bool {
+	return !r.StatusMatch || r.ErrA != "" || r.ErrB != "" || len(r.HeadersDiff) > 0 || len(r.BodyStructureDiff) > 0 || len(r.BodyValueDiff) > 0
+}
+
+// CompareSide is one environment of an A/B compare with its own headers, path params and
+// credentials.
+type CompareSide struct {
+	BaseURL    string
+	Headers    map[string]string
+	PathParams ParamOverrides        // pinned path params of this environment
+	AuthHeader map[string]string     // auth profile header, used when the spec maps no security scheme
+	Secrets    map[string]AuthSecret // security scheme secrets (see ApplySecurity)
+}
+
+// RunABCompare sends the same request to side a and side b and diffs status, headers, body structure.
+// The request is built once so both sides receive the same params and body; each side pins its
+// own path params and adds its own headers and credentials. rules silence expected differences
+// (see CompareRules).
+func RunABCompare(ep Endpoint, a, b CompareSide, rules CompareRules, timeout time.Duration) ABCompareResult {
+	return compareRequest(ep, BuildRequestSpec(ep, nil), a, b, rules, timeout)
+}
+
+// compareRequest sends rs to both sides and diffs the responses.
+func compareRequest(ep Endpoint, rs RequestSpec, a, b CompareSide, rules CompareRules, timeout time.Duration) ABCompareResult {
 	res := ABCompareResult{Path: ep.Path, Method: ep.Method, StatusMatch: true}
-	body, _ := ExampleBody(ep.Schema)
-	urlA, _ := BuildURL(baseURLA, ep.Path, nil)
-	urlB, _ := BuildURL(baseURLB, ep.Path, nil)
-	respA, errA := doRequest(ep, urlA, body, headers, authHeader, timeout)
-	respB, errB := doRequest(ep, urlB, body, headers, authHeader, timeout)
+	respA, errA := doRequest(ep, rs, a, timeout)
+	respB, errB := doRequest(ep, rs, b, timeout)
 	if errA != nil {
 		res.ErrA = errA.Error()
 	}
@@ -51,169 +102,149 @@ func RunABCompare(ep Endpoint, baseURLA, baseURLB string, headers map[string]str
 		if !res.StatusMatch {
 			res.HeadersDiff = append(res.HeadersDiff, "status: "+strconv.Itoa(res.StatusA)+" vs "+strconv.Itoa(res.StatusB))
 		}
-		// Compare header keys (structure)
-		keysA := headerKeys(respA.Header)
-		keysB := headerKeys(respB.Header)
-		for k := range keysA {
-			if !keysB[k] {
-				res.HeadersDiff = append(res.HeadersDiff, "header only in A: "+k)
-			}
-		}
-		for k := range keysB {
-			if !keysA[k] {
-				res.HeadersDiff = append(res.HeadersDiff, "header only in B: "+k)
-			}
-		}
 		bodyA, _ := io.ReadAll(respA.Body)
 		bodyB, _ := io.ReadAll(respB.Body)
-		res.BodyStructureDiff, res.BodyValueDiff = diffBody(bodyA, bodyB)
+		res.BodyA, res.BodyB = truncateBody(bodyA), truncateBody(bodyB)
+		d := &responseDiffer{rules: compileCompareRules(rules), res: &res}
+		d.headers(respA.Header, respB.Header)
+		d.body(bodyA, bodyB)
 	}
 	return res
 }
 
-func doRequest(ep Endpoint, urlStr string, body []byte, headers, authHeader map[string]string, timeout time.Duration) (*http.Response, error) {
-	var bodyReader io.Reader
-	if len(body) > 0 && (ep.Method == "POST" || ep.Method == "PUT" || ep.Method == "PATCH") {
-		bodyReader = bytes.NewReader(body)
+// ABCompareConfig carries the settings of a bulk A/B compare.
+type ABCompareConfig struct {
+	A            CompareSide
+	B            CompareSide
+	Rules        CompareRuleSet
+	Timeout      time.Duration
+	Workers      int
+	RateLimitRPS int
+}
+
+// RunABCompareBulk compares every endpoint on both sides using the smoke worker pool; the rate
+// limit counts endpoints, each of which sends one request per side. onResult is called after
+// each endpoint. Results keep the order of endpoints.
+func RunABCompareBulk(ctx context.Context, cfg ABCompareConfig, endpoints []Endpoint, onResult func(done, total int, r ABCompareResult)) []ABCompareResult {
+	pool := SmokeConfig{Timeout: cfg.Timeout, Workers: cfg.Workers, RateLimitRPS: cfg.RateLimitRPS}
+	results := make([]ABCompareResult, len(endpoints))
+	var mu sync.Mutex
+	done := 0
+	runPool(ctx, &pool, len(endpoints), func(i int) {
+		ep := endpoints[i]
+		r := RunABCompare(ep, cfg.A, cfg.B, cfg.Rules.ForEndpoint(ep), pool.Timeout)
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = r
+		done++
+		if onResult != nil {
+			onResult(done, len(endpoints), r)
+		}
+	})
+	return results
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > maxCompareBody {
+		return string(b[:maxCompareBody]) + "\n... (truncated)"
+	}
+	return string(b)
+}
+
+func doRequest(ep Endpoint, rs RequestSpec, side CompareSide, timeout time.Duration) (*http.Response, error) {
+	rs = rs.clone()
+	for name, v := range side.PathParams.ForEndpoint(ep) {
+		if _, ok := rs.PathParams[name]; ok {
+			rs.PathParams[name] = v
+		}
 	}
-	req, err := http.NewRequest(ep.Method, urlStr, bodyReader)
+	auth := ApplySecurity(ep, &rs, side.Secrets, side.AuthHeader)
+	req, err := rs.NewHTTPRequest(side.BaseURL, side.Headers, auth)
 	if err != nil {
 		return nil, err
 	}
-	if len(body) > 0 {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	for k, v := range authHeader {
-		req.Header.Set(k, v)
-	}
 	client := &http.Client{Timeout: timeout}
 	return client.Do(req)
 }
 
-func headerKeys(h http.Header) map[string]bool {
-	m := make(map[string]bool)
-	for k := range h {
-		m[strings.ToLower(k)] = true
-	}
-	return m
+// responseDiffer fills the diff lists and the patch of res, routing differences matched by
+// rules to Ignored. The body walk is in jsondiff.go.
+type responseDiffer struct {
+	rules compareRules
+	res   *ABCompareResult
 }
 
-
-// diffBody returns structure diff (keys/types) and optional value diff.
-func diffBody(a, b []byte) (structureDiff, valueDiff []string) {
-	var ma, mb map[string]interface{}
-	if json.Unmarshal(a, &ma) != nil {
-		var arrA, arrB []interface{}
-		if json.Unmarshal(a, &arrA) == nil && json.Unmarshal(b, &arrB) == nil {
-			if len(arrA) != len(arrB) {
-				structureDiff = append(structureDiff, "array length: "+strconv.Itoa(len(arrA))+" vs "+strconv.Itoa(len(arrB)))
-			}
-		}
-		return structureDiff, valueDiff
-	}
-	if json.Unmarshal(b, &mb) != nil {
-		structureDiff = append(structureDiff, "B is not JSON object")
-		return structureDiff, valueDiff
-	}
-	diffMaps(ma, mb, "$", &structureDiff, &valueDiff)
-	return structureDiff, valueDiff
+func (d *responseDiffer) ignore(diff, rule string) {
+	d.res.Ignored = append(d.res.Ignored, IgnoredDiff{Diff: diff, Rule: rule})
 }
 
-func diffMaps(a, b map[string]interface{}, path string, structureDiff, valueDiff *[]string) {
-	allKeys := make(map[string]bool)
-	for k := range a {
-		allKeys[k] = true
-	}
-	for k := range b {
-		allKeys[k] = true
-	}
-	for k := range allKeys {
-		subPath := path + "." + k
-		va, okA := a[k]
-		vb, okB := b[k]
-		if !okA {
-			*structureDiff = append(*structureDiff, subPath+" only in B")
-			continue
+func (d *responseDiffer) headers(a, b http.Header) {
+	valsA, valsB := headerValues(a), headerValues(b)
+	names := make([]string, 0, len(valsA)+len(valsB))
+	for k := range valsA {
+		names = append(names, k)
+	}
+	for k := range valsB {
+		if _, ok := valsA[k]; !ok {
+			names = append(names, k)
 		}
-		if !okB {
-			*structureDiff = append(*structureDiff, subPath+" only in A")
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		va, okA := valsA[k]
+		vb, okB := valsB[k]
+		var diff string
+		switch {
+		case !okB:
+			diff = "header only in A: " + k
+		case !okA:
+			diff = "header only in B: " + k
+		case va != vb && k != "content-length": // the body diff covers it
+			diff = "header value: " + k + ": " + va + " vs " + vb
+		default:
 			continue
 		}
-		ta := reflectType(va)
-		tb := reflectType(vb)
-		if ta != tb {
-			*structureDiff = append(*structureDiff, subPath+" type: "+ta+" vs "+tb)
+		if rule, ok := d.rules.ignoredHeader(k); ok {
+			d.ignore(diff, "ignoreHeaders "+rule)
 			continue
 		}
-		switch va.(type) {
-		case map[string]interface{}:
-			diffMaps(va.(map[string]interface{}), vb.(map[string]interface{}), subPath, structureDiff, valueDiff)
-		case []interface{}:
-			arA, arB := va.([]interface{}), vb.([]interface{})
-			if len(arA) != len(arB) {
-				*structureDiff = append(*structureDiff, subPath+" array len: "+strconv.Itoa(len(arA))+" vs "+strconv.Itoa(len(arB)))
-			}
-			for i := 0; i < len(arA) && i < len(arB); i++ {
-				if m1, ok1 := arA[i].(map[string]interface{}); ok1 {
-					if m2, ok2 := arB[i].(map[string]interface{}); ok2 {
-						diffMaps(m1, m2, subPath+"["+strconv.Itoa(i)+"]", structureDiff, valueDiff)
-					}
-				}
-			}
-		default:
-			if !valuesEqual(va, vb) {
-				*valueDiff = append(*valueDiff, subPath)
+		if okA && okB {
+			na, ruleA := d.rules.normalize(va)
+			nb, ruleB := d.rules.normalize(vb)
+			if na == nb {
+				d.ignore(diff, "normalizer "+firstNonEmpty(ruleA, ruleB))
+				continue
 			}
 		}
+		d.res.HeadersDiff = append(d.res.HeadersDiff, diff)
 	}
 }
 
-func reflectType(v interface{}) string {
-	switch v.(type) {
-	case map[string]interface{}:
-		return "object"
-	case []interface{}:
-		return "array"
-	case string:
-		return "string"
-	case float64:
-		return "number"
-	case bool:
-		return "boolean"
-	case nil:
-		return "null"
-	}
-	return "unknown"
-}
-
-func valuesEqual(a, b interface{}) bool {
-	if a == nil && b == nil {
-		return true
-	}
-	if a == nil || b == nil {
-		return false
+// headerValues maps lower-cased header names to their joined values.
+func headerValues(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, v := range h {
+		m[strings.ToLower(k)] = strings.Join(v, ", ")
 	}
-	return reflectDeepEqual(a, b)
+	return m
 }
 
-func reflectDeepEqual(a, b interface{}) bool {
-	// simple equality for primitives
-	switch va := a.(type) {
-	case string:
-		if vb, ok := b.(string); ok {
-			return va == vb
-		}
-	case float64:
-		if vb, ok := b.(float64); ok {
-			return va == vb
-		}
-	case bool:
-		if vb, ok := b.(bool); ok {
-			return va == vb
+// body diffs two JSON bodies; non-JSON bodies are compared as text.
+func (d *responseDiffer) body(a, b []byte) {
+	var va, vb interface{}
+	errA, errB := json.Unmarshal(a, &va), json.Unmarshal(b, &vb)
+	switch {
+	case errA != nil && errB != nil:
+		if !bytes.Equal(a, b) {
+			d.value(string(a), string(b), "$", "")
 		}
+	case errA != nil:
+		d.res.BodyStructureDiff = append(d.res.BodyStructureDiff, "A is not JSON")
+		d.op(PatchOp{Op: "replace", Path: "", Value: vb, Old: string(a)})
+	case errB != nil:
+		d.res.BodyStructureDiff = append(d.res.BodyStructureDiff, "B is not JSON")
+		d.op(PatchOp{Op: "replace", Path: "", Value: string(b), Old: va})
+	default:
+		d.diff(va, vb, "$", "")
 	}
-	return false
 }
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const compareRulesYAML = `
+"*":
+  ignoreHeaders: [Date, X-Trace-*]
+  ignorePaths: ["$..updatedAt", "$.meta.*"]
+  normalizers:
+    - {pattern: "[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}", replace: "<uuid>"}
+GET /orders:
+  numericTolerance: 0.01
+  unorderedArrays:
+    - {path: $.items, key: id}
+    - {path: $.tags}
+`
+
+func TestABCompareRules(t *testing.T) {
+	serve := func(date, trace, etag, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Date", date)
+			w.Header().Set("X-Trace-Id", trace)
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(body))
+		}))
+	}
+	a := serve("Mon", "t1", `"v1"`, `{
+		"id": "0b7c4a8e-1d2f-4c3b-9a8e-1234567890ab",
+		"total": 10.004,
+		"status": "open",
+		"meta": {"requestId": "r1", "host": "a"},
+		"items": [{"id": 1, "qty": 2, "updatedAt": "x"}, {"id": 2, "qty": 1}],
+		"tags": ["a", "b"]
+	}`)
+	defer a.Close()
+	b := serve("Tue", "t2", `"v2"`, `{
+		"id": "5f1e2d3c-4b5a-4978-8a6b-ba0987654321",
+		"total": 10.0,
+		"status": "closed",
+		"meta": {"requestId": "r2"},
+		"items": [{"id": 2, "qty": 1}, {"id": 1, "qty": 3, "updatedAt": "y"}, {"id": 3, "qty": 1}],
+		"tags": ["b", "a"]
+	}`)
+	defer b.Close()
+
+	var set CompareRuleSet
+	if err := yaml.Unmarshal([]byte(compareRulesYAML), &set); err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	ep := Endpoint{Method: "GET", Path: "/orders"}
+	res := RunABCompare(ep, CompareSide{BaseURL: a.URL}, CompareSide{BaseURL: b.URL}, set.ForEndpoint(ep), 5*time.Second)
+
+	if want := []string{`header value: etag: "v1" vs "v2"`}; !reflect.DeepEqual(res.HeadersDiff, want) {
+		t.Errorf("headers diff %v, want %v", res.HeadersDiff, want)
+	}
+	if want := []string{"$.items[id=3] only in B"}; !reflect.DeepEqual(res.BodyStructureDiff, want) {
+		t.Errorf("structure diff %v, want %v", res.BodyStructureDiff, want)
+	}
+	if want := []string{"$.items[id=1].qty", "$.status"}; !reflect.DeepEqual(res.BodyValueDiff, want) {
+		t.Errorf("value diff %v, want %v", res.BodyValueDiff, want)
+	}
+	rules := map[string]string{}
+	for _, d := range res.Ignored {
+		rules[d.Diff] = d.Rule
+	}
+	wantIgnored := map[string]string{
+		"header value: date: Mon vs Tue":     "ignoreHeaders Date",
+		"header value: x-trace-id: t1 vs t2": "ignoreHeaders X-Trace-*",
+		"$.id":                               "normalizer [0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}",
+		"$.total":                            "numericTolerance 0.01",
+		"$.meta.requestId":                   "ignorePaths $.meta.*",
+		"$.meta.host only in A":              "ignorePaths $.meta.*",
+		"$.items[id=1].updatedAt":            "ignorePaths $..updatedAt",
+	}
+	if !reflect.DeepEqual(rules, wantIgnored) {
+		t.Errorf("ignored %v\nwant %v", rules, wantIgnored)
+	}
+	if !res.Differs() {
+		t.Error("real differences remain, Differs should be true")
+	}
+	var patch []string
+	for _, op := range res.Patch {
+		patch = append(patch, op.String())
+	}
+	// Paired items keep their index in A; ignored differences are not patched.
+	if want := []string{`replace /items/0/qty: 2 -> 3`, `add /items/-: {"id":3,"qty":1}`, `replace /status: "open" -> "closed"`}; !reflect.DeepEqual(patch, want) {
+		t.Errorf("patch %q, want %q", patch, want)
+	}
+
+	// Without rules every one of those differences is real.
+	raw := RunABCompare(ep, CompareSide{BaseURL: a.URL}, CompareSide{BaseURL: b.URL}, CompareRules{}, 5*time.Second)
+	if len(raw.Ignored) != 0 {
+		t.Errorf("no rules, nothing ignored: %v", raw.Ignored)
+	}
+	sort.Strings(raw.BodyValueDiff)
+	if !sliceContains(raw.BodyValueDiff, "$.id") || !sliceContains(raw.BodyValueDiff, "$.tags[0]") {
+		t.Errorf("value diff without rules: %v", raw.BodyValueDiff)
+	}
+}
+
+func TestJSONPathMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"$.a.b", "$.a.b", true},
+		{"$.a.*", "$.a.b", true},
+		{"$.a.*", "$.a.b.c", false},
+		{"$.items[*].id", "$.items[3].id", true},
+		{"$.items[*].id", "$.items[id=7].id", true},
+		{"$.items[*]", "$.items.id", false},
+		{"$..updatedAt", "$.updatedAt", true},
+		{"$..updatedAt", "$.a[0].b.updatedAt", true},
+		{"$.a..id", "$.b.id", false},
+	}
+	for _, c := range cases {
+		if got := jsonPathMatch(c.pattern, c.path); got != c.want {
+			t.Errorf("jsonPathMatch(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestABCompareBulk(t *testing.T) {
+	handler := func(side string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/b" {
+				w.Write([]byte(`{"side":"` + side + `"}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+	eps := []Endpoint{{Method: "GET", Path: "/a"}, {Method: "GET", Path: "/b"}, {Method: "GET", Path: "/c"}}
+	cfg := ABCompareConfig{A: CompareSide{BaseURL: a.URL}, B: CompareSide{BaseURL: b.URL}, Workers: 2, RateLimitRPS: 100}
+	calls := 0
+	res := RunABCompareBulk(context.Background(), cfg, eps, func(done, total int, _ ABCompareResult) {
+		calls++
+		if total != 3 || done != calls {
+			t.Errorf("progress %d/%d after %d calls", done, total, calls)
+		}
+	})
+	if len(res) != 3 || calls != 3 {
+		t.Fatalf("results %d, progress calls %d", len(res), calls)
+	}
+	for i, r := range res {
+		if r.Path != eps[i].Path {
+			t.Errorf("result %d is %s, want %s (endpoint order)", i, r.Path, eps[i].Path)
+		}
+		if want := r.Path == "/b"; r.Differs() != want {
+			t.Errorf("%s differs=%v: %+v", r.Path, r.Differs(), r)
+		}
+	}
+	if res[1].BodyA != `{"side":"a"}` || res[1].BodyB != `{"side":"b"}` {
+		t.Errorf("bodies not kept for reports: %q %q", res[1].BodyA, res[1].BodyB)
+	}
+
+	// Rules apply per endpoint.
+	cfg.Rules = CompareRuleSet{"GET /b": {IgnorePaths: []string{"$.side"}}}
+	res = RunABCompareBulk(context.Background(), cfg, eps, nil)
+	if res[1].Differs() || len(res[1].Ignored) != 1 {
+		t.Errorf("rule for GET /b not applied: %+v", res[1])
+	}
+}
+
+func TestABCompareSidesKeepTheirOwnCredentials(t *testing.T) {
+	// Each environment only accepts its own tenant header and credentials: the profile token,
+	// or the api key for operations declaring the key scheme.
+	env := func(token, tenant string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authed := r.Header.Get("Authorization") == "Bearer "+token
+			if r.URL.Path == "/keyed" {
+				authed = r.URL.Query().Get("api_key") == token+"-key"
+			}
+			if !authed || r.Header.Get("X-Tenant") != tenant {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"ok":true}`))
+		}))
+	}
+	a, b := env("dev", "acme-dev"), env("test", "acme-test")
+	defer a.Close()
+	defer b.Close()
+	dir := writeFiles(t, map[string]string{"api.yaml": `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /me:
+    get:
+      responses: {"200": {description: ok}}
+  /keyed:
+    get:
+      security: [{key: []}]
+      responses: {"200": {description: ok}}
+components:
+  securitySchemes:
+    key: {type: apiKey, in: query, name: api_key}
+`})
+	eps, _, err := LoadSpecs(filepath.Join(dir, "api.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := func(url, token, tenant string) CompareSide {
+		return CompareSide{
+			BaseURL:    url,
+			Headers:    map[string]string{"X-Tenant": tenant},
+			AuthHeader: map[string]string{"Authorization": "Bearer " + token},
+			Secrets:    map[string]AuthSecret{"key": {Key: token + "-key"}},
+		}
+	}
+	for _, ep := range eps {
+		res := RunABCompare(ep, side(a.URL, "dev", "acme-dev"), side(b.URL, "test", "acme-test"), CompareRules{}, 5*time.Second)
+		if res.StatusA != 200 || res.StatusB != 200 || res.Differs() {
+			t.Errorf("%s: each side should authenticate with its own credentials: %+v", ep.Path, res)
+		}
+	}
+}
+
+func TestRunABComparePinsPathParamsPerSide(t *testing.T) {
+	serve := func(want string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != want {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"ok":true}`))
+		}))
+	}
+	a, b := serve("/tenants/acme-dev/users/7"), serve("/tenants/acme-test/users/7")
+	defer a.Close()
+	defer b.Close()
+	ep := loadEndpoint(t, `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /tenants/{tenant}/users/{id}:
+    get:
+      parameters:
+        - {name: tenant, in: path, required: true, schema: {type: string}}
+        - {name: id, in: path, required: true, schema: {type: integer, example: 7}}
+      responses: {"200": {description: ok}}
+`, "/tenants/{tenant}/users/{id}", "GET")
+	res := RunABCompare(ep,
+		CompareSide{BaseURL: a.URL, PathParams: ParamOverrides{"*": {"tenant": "acme-dev"}}},
+		CompareSide{BaseURL: b.URL, PathParams: ParamOverrides{"GET /tenants/{tenant}/users/{id}": {"tenant": "acme-test"}}},
+		CompareRules{}, 5*time.Second)
+	if res.StatusA != 200 || res.StatusB != 200 || res.Differs() {
+		t.Errorf("each side should use its own path params: %+v", res)
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CompareRules silence expected differences in an A/B compare: volatile headers, generated
+// ids and timestamps, rounding noise and arrays whose order carries no meaning.
+//
+// JSON paths start at "$": "$.meta.requestId", "$.items[*].updatedAt" ("*" and "[*]" match one
+// key or index), "$..etag" (".." matches any depth). A matched path silences its whole subtree.
+type CompareRules struct {
+	IgnorePaths      []string         `yaml:"ignorePaths,omitempty" json:",omitempty"`
+	IgnoreHeaders    []string         `yaml:"ignoreHeaders,omitempty" json:",omitempty"` // case-insensitive, "X-Amzn-*" globs
+	NumericTolerance float64          `yaml:"numericTolerance,omitempty" json:",omitempty"`
+	Normalizers      []Normalizer     `yaml:"normalizers,omitempty" json:",omitempty"`
+	UnorderedArrays  []UnorderedArray `yaml:"unorderedArrays,omitempty" json:",omitempty"`
+}
+
+// Normalizer rewrites every match of Pattern in string values and header values of both
+// sides before they are compared ("[0-9a-f-]{36}" -> "<uuid>").
+type Normalizer struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// UnorderedArray compares the array at Path regardless of order. Elements are paired by the
+// value of their Key field, or by their whole value when Key is empty.
+type UnorderedArray struct {
+	Path string `yaml:"path"`
+	Key  string `yaml:"key,omitempty"`
+}
+
+// CompareRuleSet maps an endpoint selector to compare rules, like ParamOverrides:
+// "*" (every endpoint), "/users/{id}", "GET /users/{id}" or an operationId.
+type CompareRuleSet map[string]CompareRules
+
+// ForEndpoint combines the rules that apply to ep. Lists accumulate from the least to the
+// most specific selector; the most specific non-zero tolerance wins.
+func (s CompareRuleSet) ForEndpoint(ep Endpoint) CompareRules {
+	var out CompareRules
+	keys := []string{"*", ep.Path, strings.ToUpper(ep.Method) + " " + ep.Path}
+	if ep.OperationID != "" {
+		keys = append(keys, ep.OperationID)
+	}
+	for _, k := range keys {
+		r, ok := s[k]
+		if !ok {
+			continue
+		}
+		out.IgnorePaths = append(out.IgnorePaths, r.IgnorePaths...)
+		out.IgnoreHeaders = append(out.IgnoreHeaders, r.IgnoreHeaders...)
+		out.Normalizers = append(out.Normalizers, r.Normalizers...)
+		out.UnorderedArrays = append(out.UnorderedArrays, r.UnorderedArrays...)
+		if r.NumericTolerance > 0 {
+			out.NumericTolerance = r.NumericTolerance
+		}
+	}
+	return out
+}
+
+// Validate reports invalid normalizer patterns and JSON paths.
+func (s CompareRuleSet) Validate() error {
+	for sel, r := range s {
+		for _, n := range r.Normalizers {
+			if _, err := regexp.Compile(n.Pattern); err != nil {
+				return fmt.Errorf("compare rules %q: normalizer: %w", sel, err)
+			}
+		}
+		for _, p := range r.IgnorePaths {
+			if !strings.HasPrefix(p, "$") {
+				return fmt.Errorf("compare rules %q: ignore path %q must start with $", sel, p)
+			}
+		}
+		for _, u := range r.UnorderedArrays {
+			if !strings.HasPrefix(u.Path, "$") {
+				return fmt.Errorf("compare rules %q: unordered array path %q must start with $", sel, u.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// compareRules is CompareRules ready for matching.
+type compareRules struct {
+	CompareRules
+	normalizers []*regexp.Regexp
+}
+
+func compileCompareRules(r CompareRules) compareRules {
+	c := compareRules{CompareRules: r}
+	for _, n := range r.Normalizers {
+		// Invalid patterns are rejected by Validate when env.yaml is loaded.
+		if re, err := regexp.Compile(n.Pattern); err == nil {
+			c.normalizers = append(c.normalizers, re)
+		}
+	}
+	return c
+}
+
+// ignoredPath returns the IgnorePaths pattern matching path.
+func (c compareRules) ignoredPath(path string) (string, bool) {
+	for _, p := range c.IgnorePaths {
+		if jsonPathMatch(p, path) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// ignoredHeader returns the IgnoreHeaders entry matching the lower-cased header name.
+func (c compareRules) ignoredHeader(name string) (string, bool) {
+	for _, h := range c.IgnoreHeaders {
+		if ok, _ := filepath.Match(strings.ToLower(h), name); ok {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+// unordered returns the UnorderedArray rule for the array at path.
+func (c compareRules) unordered(path string) (UnorderedArray, bool) {
+	for _, u := range c.UnorderedArrays {
+		if jsonPathMatch(u.Path, path) {
+			return u, true
+		}
+	}
+	return UnorderedArray{}, false
+}
+
+// normalize applies the normalizers to s and returns the first pattern that changed it.
+func (c compareRules) normalize(s string) (string, string) {
+	rule := ""
+	for i, re := range c.normalizers {
+		if n := re.ReplaceAllString(s, c.Normalizers[i].Replace); n != s {
+			s = n
+			if rule == "" {
+				rule = c.Normalizers[i].Pattern
+			}
+		}
+	}
+	return s, rule
+}
+
+// jsonPathMatch reports whether the concrete path ("$.items[3].id", "$.items[id=7]") matches
+// pattern. "*" matches one key or index, "[*]" one index, ".." any number of segments.
+func jsonPathMatch(pattern, path string) bool {
+	return matchSegments(jsonPathSegments(pattern), jsonPathSegments(path))
+}
+
+func matchSegments(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	p, s := pat[0], segs[0]
+	switch {
+	case p == "*":
+	case p == "[*]":
+		if !strings.HasPrefix(s, "[") {
+			return false
+		}
+	case p != s:
+		return false
+	}
+	return matchSegments(pat[1:], segs[1:])
+}
+
+// jsonPathSegments splits "$.a..b[0]" into "a", "**", "b", "[0]".
+func jsonPathSegments(path string) []string {
+	path = strings.TrimPrefix(path, "$")
+	var out []string
+	for len(path) > 0 {
+		switch {
+		case strings.HasPrefix(path, ".."):
+			out = append(out, "**")
+			path = path[2:]
+		case path[0] == '.':
+			path = path[1:]
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				end = len(path) - 1
+			}
+			out = append(out, path[:end+1])
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			out = append(out, path[:end])
+			path = path[end:]
+		}
+	}
+	return out
+}
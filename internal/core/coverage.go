@@ -0,0 +1,252 @@
+package core
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// UntaggedCoverage is the tag operations without tags are counted under.
+const UntaggedCoverage = "untagged"
+
+// Coverage says which operations, documented responses and parameters of a spec were
+// exercised by a set of runs.
+type Coverage struct {
+	Operations      []OperationCoverage
+	Tags            []TagCoverage
+	OperationsTotal int
+	OperationsHit   int
+	ResponsesTotal  int
+	ResponsesHit    int
+	ParamsTotal     int
+	ParamsHit       int
+	OperationsPct   float64
+	ResponsesPct    float64
+	ParamsPct       float64
+}
+
+// OperationCoverage is the coverage of one operation. An operation is hit when a run got
+// any HTTP response from it; request errors do not count.
+type OperationCoverage struct {
+	Method       string
+	Path         string
+	OperationID  string `json:",omitempty"`
+	Tags         []string
+	Hits         int
+	RunTypes     []string `json:",omitempty"` // smoke, drift, fuzz, compare
+	Responses    []ResponseCoverage
+	Params       []ParamCoverage
+	Undocumented []int `json:",omitempty"` // statuses received that match no documented response
+}
+
+// ResponseCoverage is one documented response key ("200", "4XX", "default").
+type ResponseCoverage struct {
+	Status string
+	Hit    bool
+}
+
+// ParamCoverage is one declared parameter. Generated requests send path and required
+// parameters; fuzz mutations also exercise the parameter they target.
+type ParamCoverage struct {
+	In       string
+	Name     string
+	Required bool
+	Hit      bool
+}
+
+// TagCoverage aggregates the operations sharing a tag.
+type TagCoverage struct {
+	Tag            string
+	Operations     int
+	OperationsHit  int
+	ResponsesTotal int
+	ResponsesHit   int
+	OperationsPct  float64
+	ResponsesPct   float64
+}
+
+// percent is hit/total in percent; an empty total is 0%.
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(hit) / float64(total)
+}
+
+// CoverageBuilder aggregates run results against the endpoints of a spec.
+// Results for operations the spec does not declare are ignored.
+type CoverageBuilder struct {
+	endpoints []Endpoint
+	ops       map[string]*opCoverage
+}
+
+type opCoverage struct {
+	hits         int
+	runTypes     []string
+	statuses     map[string]bool // documented response keys hit
+	params       map[string]bool // "in:name" exercised
+	undocumented []int
+}
+
+// NewCoverageBuilder starts an empty coverage model over endpoints.
+func NewCoverageBuilder(endpoints []Endpoint) *CoverageBuilder {
+	b := &CoverageBuilder{endpoints: endpoints, ops: map[string]*opCoverage{}}
+	for _, ep := range endpoints {
+		b.ops[coverageKey(ep.Method, ep.Path)] = &opCoverage{statuses: map[string]bool{}, params: map[string]bool{}}
+	}
+	return b
+}
+
+func coverageKey(method, path string) string { return strings.ToUpper(method) + " " + path }
+
+// Hit records one response of runType from method path. status 0 (no response) is ignored.
+// params lists extra "in:name" parameters the request exercised beyond path and required ones.
+func (b *CoverageBuilder) Hit(runType, method, path string, status int, params ...string) {
+	oc, ok := b.ops[coverageKey(method, path)]
+	if !ok || status <= 0 {
+		return
+	}
+	ep := b.endpoint(method, path)
+	oc.hits++
+	if !sliceContains(oc.runTypes, runType) {
+		oc.runTypes = append(oc.runTypes, runType)
+	}
+	if key, ok := matchResponse(ep.Schema, status, true); ok {
+		oc.statuses[key] = true
+	} else if !intsContain(oc.undocumented, status) {
+		oc.undocumented = append(oc.undocumented, status)
+	}
+	for _, p := range ep.Params() {
+		if p.In == openapi3.ParameterInPath || p.Required {
+			oc.params[p.In+":"+p.Name] = true
+		}
+	}
+	for _, p := range params {
+		oc.params[p] = true
+	}
+}
+
+func (b *CoverageBuilder) endpoint(method, path string) Endpoint {
+	for _, ep := range b.endpoints {
+		if strings.EqualFold(ep.Method, method) && ep.Path == path {
+			return ep
+		}
+	}
+	return Endpoint{}
+}
+
+// AddSmoke records smoke results.
+func (b *CoverageBuilder) AddSmoke(results []SmokeResult) {
+	for _, r := range results {
+		b.Hit("smoke", r.Method, r.Path, r.StatusCode)
+	}
+}
+
+// AddDrift records drift results.
+func (b *CoverageBuilder) AddDrift(results []DriftResult) {
+	for _, r := range results {
+		b.Hit("drift", r.Method, r.Path, r.StatusCode)
+	}
+}
+
+// AddFuzz records fuzz results; the mutated parameter counts as exercised.
+func (b *CoverageBuilder) AddFuzz(results []FuzzResult) {
+	for _, r := range results {
+		var params []string
+		if in, name, ok := strings.Cut(r.Target, " "); ok && in != "body" {
+			params = append(params, in+":"+name)
+		}
+		b.Hit("fuzz", r.Method, r.Path, r.StatusCode, params...)
+	}
+}
+
+// AddCompare records both sides of an A/B compare.
+func (b *CoverageBuilder) AddCompare(r ABCompareResult) {
+	b.Hit("compare", r.Method, r.Path, r.StatusA)
+	b.Hit("compare", r.Method, r.Path, r.StatusB)
+}
+
+// Coverage computes the coverage model. Operations are sorted by path and method.
+func (b *CoverageBuilder) Coverage() Coverage {
+	var c Coverage
+	tags := map[string]*TagCoverage{}
+	for _, ep := range b.endpoints {
+		oc := b.ops[coverageKey(ep.Method, ep.Path)]
+		op := OperationCoverage{
+			Method:       strings.ToUpper(ep.Method),
+			Path:         ep.Path,
+			OperationID:  ep.OperationID,
+			Tags:         ep.Tags,
+			Hits:         oc.hits,
+			RunTypes:     oc.runTypes,
+			Undocumented: oc.undocumented,
+		}
+		sort.Ints(op.Undocumented)
+		respHit := 0
+		for _, status := range documentedStatuses(ep.Schema) {
+			hit := oc.statuses[status]
+			respHit += b2i(hit)
+			op.Responses = append(op.Responses, ResponseCoverage{Status: status, Hit: hit})
+		}
+		for _, p := range ep.Params() {
+			hit := oc.params[p.In+":"+p.Name]
+			c.ParamsHit += b2i(hit)
+			op.Params = append(op.Params, ParamCoverage{In: p.In, Name: p.Name, Required: p.Required || p.In == openapi3.ParameterInPath, Hit: hit})
+		}
+		c.ParamsTotal += len(op.Params)
+		c.OperationsTotal++
+		c.OperationsHit += b2i(op.Hits > 0)
+		c.ResponsesTotal += len(op.Responses)
+		c.ResponsesHit += respHit
+		c.Operations = append(c.Operations, op)
+
+		opTags := ep.Tags
+		if len(opTags) == 0 {
+			opTags = []string{UntaggedCoverage}
+		}
+		for _, t := range opTags {
+			tc := tags[t]
+			if tc == nil {
+				tc = &TagCoverage{Tag: t}
+				tags[t] = tc
+			}
+			tc.Operations++
+			tc.OperationsHit += b2i(op.Hits > 0)
+			tc.ResponsesTotal += len(op.Responses)
+			tc.ResponsesHit += respHit
+		}
+	}
+	sort.SliceStable(c.Operations, func(i, j int) bool {
+		if c.Operations[i].Path != c.Operations[j].Path {
+			return c.Operations[i].Path < c.Operations[j].Path
+		}
+		return c.Operations[i].Method < c.Operations[j].Method
+	})
+	for _, tc := range tags {
+		tc.OperationsPct = percent(tc.OperationsHit, tc.Operations)
+		tc.ResponsesPct = percent(tc.ResponsesHit, tc.ResponsesTotal)
+		c.Tags = append(c.Tags, *tc)
+	}
+	sort.Slice(c.Tags, func(i, j int) bool { return c.Tags[i].Tag < c.Tags[j].Tag })
+	c.OperationsPct = percent(c.OperationsHit, c.OperationsTotal)
+	c.ResponsesPct = percent(c.ResponsesHit, c.ResponsesTotal)
+	c.ParamsPct = percent(c.ParamsHit, c.ParamsTotal)
+	return c
+}
+
+func intsContain(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
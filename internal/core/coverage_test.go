@@ -0,0 +1,76 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const coverageSpec = `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /users:
+    get:
+      tags: [users]
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+        - {name: X-Tenant, in: header, required: true, schema: {type: string}}
+      responses:
+        "200": {description: ok}
+        "4XX": {description: client error}
+  /users/{id}:
+    delete:
+      tags: [users, admin]
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "204": {description: gone}
+        "404": {description: missing}
+  /health:
+    get:
+      responses:
+        default: {description: any}
+`
+
+func TestCoverage(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"api.yaml": coverageSpec})
+	eps, _, err := LoadOpenAPI(filepath.Join(dir, "api.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewCoverageBuilder(eps)
+	b.AddSmoke([]SmokeResult{
+		{Method: "GET", Path: "/users", StatusCode: 200},
+		{Method: "DELETE", Path: "/users/{id}", Err: "connection refused"},
+		{Method: "GET", Path: "/unknown", StatusCode: 200},
+	})
+	b.AddFuzz([]FuzzResult{{Method: "GET", Path: "/users", Target: "query limit", StatusCode: 400}})
+	b.AddCompare(ABCompareResult{Method: "DELETE", Path: "/users/{id}", StatusA: 500, StatusB: 404})
+	c := b.Coverage()
+
+	if c.OperationsTotal != 3 || c.OperationsHit != 2 {
+		t.Errorf("operations %d/%d, want 2/3", c.OperationsHit, c.OperationsTotal)
+	}
+	if c.ResponsesTotal != 5 || c.ResponsesHit != 3 {
+		t.Errorf("responses %d/%d, want 3/5", c.ResponsesHit, c.ResponsesTotal)
+	}
+	if c.ParamsTotal != 3 || c.ParamsHit != 3 {
+		t.Errorf("params %d/%d, want 3/3", c.ParamsHit, c.ParamsTotal)
+	}
+	users := c.Operations[1]
+	if users.Method != "GET" || users.Path != "/users" || users.Hits != 2 || len(users.RunTypes) != 2 {
+		t.Errorf("GET /users coverage: %+v", users)
+	}
+	del := c.Operations[2]
+	if len(del.Undocumented) != 1 || del.Undocumented[0] != 500 {
+		t.Errorf("DELETE /users/{id} undocumented statuses: %v", del.Undocumented)
+	}
+	want := map[string][2]int{"admin": {1, 1}, "untagged": {0, 1}, "users": {2, 2}}
+	for _, tc := range c.Tags {
+		if w := want[tc.Tag]; tc.OperationsHit != w[0] || tc.Operations != w[1] {
+			t.Errorf("tag %s: %d/%d, want %v", tc.Tag, tc.OperationsHit, tc.Operations, w)
+		}
+	}
+	if len(c.Tags) != 3 || c.Tags[2].ResponsesPct != 75 {
+		t.Errorf("tags: %+v", c.Tags)
+	}
+}
@@ -1,9 +1,10 @@
 package core
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"reflect"
-	"strconv"
+	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -16,144 +17,146 @@ const (
 	DriftExtra         DriftType = "extra"
 	DriftTypeMismatch  DriftType = "type_mismatch"
 	DriftEnumViolation DriftType = "enum_violation"
+	// DriftNoBranchMatch: value matches none of the oneOf/anyOf branches (or the discriminator target).
+	DriftNoBranchMatch DriftType = "no_branch_match"
+	// DriftAmbiguousBranch: value matches more than one oneOf branch.
+	DriftAmbiguousBranch DriftType = "ambiguous_branch"
+	// DriftNotViolation: value matches a schema declared under "not".
+	DriftNotViolation DriftType = "not_violation"
+	// Response-level findings: Content-Type and documented response headers.
+	DriftContentType   DriftType = "content_type_mismatch"
+	DriftHeaderMissing DriftType = "header_missing"
+	DriftHeaderInvalid DriftType = "header_invalid"
+	// Constraint-level findings: the value has the right type but breaks a keyword.
+	DriftFormatViolation      DriftType = "format_violation"
+	DriftPatternViolation     DriftType = "pattern_violation"
+	DriftLengthViolation      DriftType = "length_violation"
+	DriftRangeViolation       DriftType = "range_violation"
+	DriftItemCountViolation   DriftType = "item_count_violation"
+	DriftUniqueItemsViolation DriftType = "unique_items_violation"
 )
 
+// Severity grades a finding so CI can gate on breaking drift only.
+type Severity string
+
+const (
+	// SeverityBreaking: a client written against the spec is likely to fail (missing field, wrong type, ...).
+	SeverityBreaking Severity = "breaking"
+	// SeverityWarning: the response violates the spec but typical clients keep working (extra field, constraint).
+	SeverityWarning Severity = "warning"
+)
+
+// ParseSeverity maps a --fail-on style value to a Severity; "" and "none" mean never fail.
+func ParseSeverity(v string) (Severity, error) {
+	switch Severity(v) {
+	case "", "none":
+		return "", nil
+	case SeverityBreaking, SeverityWarning:
+		return Severity(v), nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want breaking|warning|none)", v)
+}
+
+// AtLeast reports whether s is as severe as min. An empty min is never reached.
+func (s Severity) AtLeast(min Severity) bool {
+	switch min {
+	case SeverityWarning:
+		return s == SeverityWarning || s == SeverityBreaking
+	case SeverityBreaking:
+		return s == SeverityBreaking
+	}
+	return false
+}
+
+// defaultSeverity is the severity of a finding type unless the check sets one explicitly.
+func defaultSeverity(t DriftType) Severity {
+	switch t {
+	case DriftMissing, DriftTypeMismatch, DriftEnumViolation, DriftNoBranchMatch, DriftNotViolation,
+		DriftContentType, DriftHeaderMissing:
+		return SeverityBreaking
+	}
+	return SeverityWarning
+}
+
 // DriftFinding is one contract drift finding.
 type DriftFinding struct {
-	Path   string   // JSON path e.g. "body.items[0].name"
-	Type   DriftType
-	Schema string   // expected (from OpenAPI)
-	Actual string   // actual value or type
-	Enum   []string // for enum_violation
+	Path     string // JSON path e.g. "body.items[0].name"
+	Type     DriftType
+	Schema   string   // expected (from OpenAPI)
+	Actual   string   // actual value or type
+	Enum     []string // for enum_violation
+	Detail   string   `json:",omitempty"` // explanation, e.g. why each composition branch failed
+	Severity Severity // breaking or warning
 }
 
 // DriftResult holds all drift findings for one endpoint.
 type DriftResult struct {
-	Path     string
-	Method   string
-	Findings []DriftFinding
-	OK       bool
+	Path       string
+	Method     string
+	StatusCode int    `json:",omitempty"`
+	Err        string `json:",omitempty"` // request failed before a body could be checked
+	Findings   []DriftFinding
+	OK         bool
 }
 
-// RunDrift compares response body (as map[string]any) against OpenAPI response schema.
-func RunDrift(respBody []byte, op *openapi3.Operation, statusCode int) DriftResult {
-	res := DriftResult{OK: true}
-	if op == nil {
-		return res
-	}
-	resp := op.Responses.Status(statusCode)
-	if resp == nil || resp.Value == nil {
-		resp = op.Responses.Value("default")
+// Fails reports whether r should fail a run gated at min severity.
+// Request errors always fail unless gating is off.
+func (r DriftResult) Fails(min Severity) bool {
+	if min == "" {
+		return false
 	}
-	if resp == nil || resp.Value == nil {
-		return res
-	}
-	content := resp.Value.Content.Get("application/json")
-	if content == nil || content.Schema == nil || content.Schema.Value == nil {
-		return res
+	if r.Err != "" {
+		return true
 	}
-	var body map[string]any
-	if err := json.Unmarshal(respBody, &body); err != nil {
-		// Try array root
-		var arr []any
-		if err2 := json.Unmarshal(respBody, &arr); err2 != nil {
-			res.Findings = append(res.Findings, DriftFinding{Path: "$", Type: DriftTypeMismatch, Actual: "invalid JSON"})
-			res.OK = false
-			return res
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(min) {
+			return true
 		}
-		compareSchemaToValue(content.Schema.Value, "", arr, &res)
-		res.OK = len(res.Findings) == 0
-		return res
 	}
-	compareSchemaToValue(content.Schema.Value, "", body, &res)
-	res.OK = len(res.Findings) == 0
-	return res
+	return false
 }
 
-func schemaTypeStr(s *openapi3.Schema) string {
-	if s.Type == nil || len(s.Type.Slice()) == 0 {
-		return ""
+// Breaking counts findings with SeverityBreaking.
+func (r DriftResult) Breaking() int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == SeverityBreaking {
+			n++
+		}
 	}
-	return s.Type.Slice()[0]
+	return n
 }
 
-func compareSchemaToValue(s *openapi3.Schema, path string, value interface{}, res *DriftResult) {
-	if path == "" {
-		path = "$"
-	}
-	t := schemaTypeStr(s)
-	switch t {
-	case "object":
-		obj, ok := value.(map[string]interface{})
-		if !ok {
-			res.Findings = append(res.Findings, DriftFinding{Path: path, Type: DriftTypeMismatch, Schema: "object", Actual: typeOf(value)})
-			res.OK = false
-			return
-		}
-		for name, prop := range s.Properties {
-			subPath := path + "." + name
-			if prop == nil || prop.Value == nil {
-				continue
-			}
-			actual, exists := obj[name]
-			if !exists {
-				if !sliceContains(s.Required, name) {
-					continue
-				}
-				res.Findings = append(res.Findings, DriftFinding{Path: subPath, Type: DriftMissing, Schema: schemaTypeStr(prop.Value), Actual: ""})
-				res.OK = false
-				continue
-			}
-			compareSchemaToValue(prop.Value, subPath, actual, res)
-		}
-		for name := range obj {
-			if s.Properties[name] == nil {
-				res.Findings = append(res.Findings, DriftFinding{Path: path + "." + name, Type: DriftExtra, Actual: typeOf(obj[name])})
-				res.OK = false
-			}
-		}
-	case "array":
-		arr, ok := value.([]interface{})
-		if !ok {
-			res.Findings = append(res.Findings, DriftFinding{Path: path, Type: DriftTypeMismatch, Schema: "array", Actual: typeOf(value)})
-			res.OK = false
-			return
-		}
-		itemSchema := s.Items
-		if itemSchema != nil && itemSchema.Value != nil {
-			for i, item := range arr {
-				compareSchemaToValue(itemSchema.Value, path+"["+strconv.Itoa(i)+"]", item, res)
-			}
+// RunDriftBulk fetches every endpoint with the smoke worker pool and rate limiter
+// and checks each response body against its schema.
+// onResult, if set, is called once per finished endpoint (serialized) for progress reporting.
+func RunDriftBulk(ctx context.Context, cfg SmokeConfig, endpoints []Endpoint, onResult func(idx int, r DriftResult)) []DriftResult {
+	results := make([]DriftResult, len(endpoints))
+	var mu sync.Mutex
+	runPool(ctx, &cfg, len(endpoints), func(i int) {
+		ep := endpoints[i]
+		var dr DriftResult
+		code, header, body, err := FetchResponse(cfg, ep)
+		if err != nil {
+			dr = DriftResult{Err: err.Error()}
+		} else {
+			dr = RunDriftResponse(ep.Schema, code, header, body)
 		}
-	case "string":
-		if _, ok := value.(string); !ok && value != nil {
-			res.Findings = append(res.Findings, DriftFinding{Path: path, Type: DriftTypeMismatch, Schema: "string", Actual: typeOf(value)})
-			res.OK = false
+		dr.Path, dr.Method, dr.StatusCode = ep.Path, ep.Method, code
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = dr
+		if onResult != nil {
+			onResult(i, dr)
 		}
-		if len(s.Enum) > 0 {
-			str, _ := value.(string)
-			var allowed []string
-			for _, e := range s.Enum {
-				allowed = append(allowed, stringify(e))
-			}
-			if str != "" && !sliceContainsString(allowed, str) {
-				res.Findings = append(res.Findings, DriftFinding{Path: path, Type: DriftEnumViolation, Actual: str, Enum: allowed})
-				res.OK = false
-			}
-		}
-	case "number", "integer":
-		if value != nil && !isNumber(value) {
-			res.Findings = append(res.Findings, DriftFinding{Path: path, Type: DriftTypeMismatch, Schema: t, Actual: typeOf(value)})
-			res.OK = false
-		}
-	case "boolean":
-		if value != nil && reflect.TypeOf(value).Kind() != reflect.Bool {
-			res.Findings = append(res.Findings, DriftFinding{Path: path, Type: DriftTypeMismatch, Schema: "boolean", Actual: typeOf(value)})
-			res.OK = false
-		}
-	default:
-		// any type or unknown
-	}
+	})
+	return results
+}
+
+// RunDrift compares response body (as map[string]any) against OpenAPI response schema.
+// Only the JSON media types are considered and headers are not checked; see RunDriftResponse.
+func RunDrift(respBody []byte, op *openapi3.Operation, statusCode int) DriftResult {
+	return RunDriftResponse(op, statusCode, nil, respBody)
 }
 
 func typeOf(v interface{}) string {
@@ -187,12 +190,3 @@ func sliceContains(s []string, x string) bool {
 	}
 	return false
 }
-
-func sliceContainsString(s []string, x string) bool {
-	for _, v := range s {
-		if v == x {
-			return true
-		}
-	}
-	return false
-}
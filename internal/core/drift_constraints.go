@@ -0,0 +1,148 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// patternCache holds compiled schema patterns; invalid patterns are cached as nil and skipped.
+var patternCache sync.Map
+
+// constraintFindings checks value-level keywords (format, pattern, length, range, item count,
+// uniqueness). The value is assumed to have passed the type check already.
+func constraintFindings(s *openapi3.Schema, path string, value interface{}) []DriftFinding {
+	var out []DriftFinding
+	switch v := value.(type) {
+	case string:
+		if s.Format != "" && !formatValid(s.Format, v) {
+			out = append(out, DriftFinding{Path: path, Type: DriftFormatViolation, Schema: s.Format, Actual: v})
+		}
+		if s.Pattern != "" {
+			if re := compiledPattern(s.Pattern); re != nil && !re.MatchString(v) {
+				out = append(out, DriftFinding{Path: path, Type: DriftPatternViolation, Schema: s.Pattern, Actual: v})
+			}
+		}
+		n := uint64(utf8.RuneCountInString(v))
+		if n < s.MinLength || (s.MaxLength != nil && n > *s.MaxLength) {
+			out = append(out, DriftFinding{Path: path, Type: DriftLengthViolation,
+				Schema: boundsLabel("length", uintBound(s.MinLength), uintPtrFloat(s.MaxLength), false, false), Actual: strconv.FormatUint(n, 10)})
+		}
+	case float64:
+		low := s.Min != nil && (v < *s.Min || (s.ExclusiveMin && v == *s.Min))
+		high := s.Max != nil && (v > *s.Max || (s.ExclusiveMax && v == *s.Max))
+		if low || high {
+			out = append(out, DriftFinding{Path: path, Type: DriftRangeViolation,
+				Schema: boundsLabel("value", s.Min, s.Max, s.ExclusiveMin, s.ExclusiveMax), Actual: strconv.FormatFloat(v, 'g', -1, 64)})
+		}
+	case []interface{}:
+		n := uint64(len(v))
+		if n < s.MinItems || (s.MaxItems != nil && n > *s.MaxItems) {
+			out = append(out, DriftFinding{Path: path, Type: DriftItemCountViolation,
+				Schema: boundsLabel("items", uintBound(s.MinItems), uintPtrFloat(s.MaxItems), false, false), Actual: strconv.FormatUint(n, 10)})
+		}
+		if s.UniqueItems {
+			if i, j := firstDuplicate(v); i >= 0 {
+				out = append(out, DriftFinding{Path: path, Type: DriftUniqueItemsViolation, Schema: "uniqueItems",
+					Actual: fmt.Sprintf("items %d and %d are equal", i, j)})
+			}
+		}
+	}
+	return out
+}
+
+// formatValid validates the common string formats; unknown formats always pass.
+func formatValid(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	case "uuid":
+		return uuidRe.MatchString(v)
+	case "email":
+		a, err := mail.ParseAddress(v)
+		return err == nil && a.Address == v
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	}
+	return true
+}
+
+func compiledPattern(p string) *regexp.Regexp {
+	if re, ok := patternCache.Load(p); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		re = nil
+	}
+	patternCache.Store(p, re)
+	return re
+}
+
+// boundsLabel renders e.g. "length >=1 <=10", "value >0", "items <=5".
+func boundsLabel(what string, min, max *float64, exclMin, exclMax bool) string {
+	label := what
+	if min != nil {
+		op := ">="
+		if exclMin {
+			op = ">"
+		}
+		label += " " + op + strconv.FormatFloat(*min, 'g', -1, 64)
+	}
+	if max != nil {
+		op := "<="
+		if exclMax {
+			op = "<"
+		}
+		label += " " + op + strconv.FormatFloat(*max, 'g', -1, 64)
+	}
+	return label
+}
+
+// uintBound treats a zero lower bound (minLength/minItems default) as unset.
+func uintBound(n uint64) *float64 {
+	if n == 0 {
+		return nil
+	}
+	f := float64(n)
+	return &f
+}
+
+func uintPtrFloat(p *uint64) *float64 {
+	if p == nil {
+		return nil
+	}
+	f := float64(*p)
+	return &f
+}
+
+// firstDuplicate returns the indexes of the first pair of equal items, or -1, -1.
+func firstDuplicate(items []interface{}) (int, int) {
+	seen := make(map[string]int, len(items))
+	for i, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			continue
+		}
+		if j, ok := seen[string(b)]; ok {
+			return j, i
+		}
+		seen[string(b)] = i
+	}
+	return -1, -1
+}
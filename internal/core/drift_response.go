@@ -0,0 +1,182 @@
+package core
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RunDriftResponse checks a whole response against the operation: the Content-Type against the
+// documented media types, documented response headers (presence and schema), and the body against
+// the schema of the matched media type when that type is JSON (application/json, problem+json,
+// vendor +json). A nil header skips the header and Content-Type checks.
+func RunDriftResponse(op *openapi3.Operation, statusCode int, header http.Header, respBody []byte) DriftResult {
+	res := DriftResult{OK: true}
+	if op == nil {
+		return res
+	}
+	key, ok := matchResponse(op, statusCode, true)
+	if !ok {
+		return res
+	}
+	resp := op.Responses.Value(key)
+	if resp == nil || resp.Value == nil {
+		return res
+	}
+	if header != nil {
+		res.Findings = append(res.Findings, headerFindings(resp.Value.Headers, header)...)
+	}
+	media, f := responseMedia(resp.Value.Content, header, len(respBody) > 0)
+	if f != nil {
+		res.Findings = append(res.Findings, *f)
+	}
+	if media != nil && media.Schema != nil && media.Schema.Value != nil {
+		var body interface{}
+		if err := json.Unmarshal(respBody, &body); err != nil {
+			res.Findings = append(res.Findings, DriftFinding{Path: "$", Type: DriftTypeMismatch, Actual: "invalid JSON", Severity: SeverityBreaking})
+		} else {
+			compareSchemaToValue(media.Schema.Value, "", body, &res)
+		}
+	}
+	res.OK = len(res.Findings) == 0
+	return res
+}
+
+// responseMedia picks the documented media type whose schema the body is checked against.
+// It returns nil when the body should not be validated (no JSON content, or Content-Type drift).
+func responseMedia(content openapi3.Content, header http.Header, hasBody bool) (*openapi3.MediaType, *DriftFinding) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	if header == nil {
+		return jsonMedia(content), nil
+	}
+	raw := header.Get("Content-Type")
+	if raw == "" {
+		if !hasBody {
+			return nil, nil
+		}
+		return jsonMedia(content), &DriftFinding{Path: "header:Content-Type", Type: DriftContentType,
+			Schema: strings.Join(mediaTypes(content), ", "), Actual: "", Severity: SeverityBreaking}
+	}
+	mt, _, err := mime.ParseMediaType(raw)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(raw))
+	}
+	media := content.Get(mt)
+	if media == nil {
+		return nil, &DriftFinding{Path: "header:Content-Type", Type: DriftContentType,
+			Schema: strings.Join(mediaTypes(content), ", "), Actual: raw, Severity: SeverityBreaking}
+	}
+	if !isJSONMedia(mt) {
+		return nil, nil
+	}
+	return media, nil
+}
+
+// jsonMedia returns application/json, else the first (sorted) +json media type.
+func jsonMedia(content openapi3.Content) *openapi3.MediaType {
+	if m := content.Get("application/json"); m != nil {
+		return m
+	}
+	for _, mt := range mediaTypes(content) {
+		if isJSONMedia(mt) {
+			return content[mt]
+		}
+	}
+	return nil
+}
+
+// isJSONMedia reports whether mt is application/json or a structured +json suffix type
+// (application/problem+json, application/vnd.acme.v1+json, ...).
+func isJSONMedia(mt string) bool {
+	if i := strings.IndexByte(mt, ';'); i >= 0 {
+		mt = mt[:i]
+	}
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func mediaTypes(content openapi3.Content) []string {
+	out := make([]string, 0, len(content))
+	for mt := range content {
+		out = append(out, mt)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// headerFindings checks documented response headers. Content-Type is covered by responseMedia
+// (OpenAPI ignores it under headers).
+func headerFindings(headers openapi3.Headers, h http.Header) []DriftFinding {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []DriftFinding
+	for _, name := range names {
+		ref := headers[name]
+		if ref == nil || ref.Value == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		path := "header:" + name
+		values := h.Values(name)
+		if len(values) == 0 {
+			if ref.Value.Required {
+				out = append(out, DriftFinding{Path: path, Type: DriftHeaderMissing, Severity: SeverityBreaking})
+			}
+			continue
+		}
+		if ref.Value.Schema == nil || ref.Value.Schema.Value == nil {
+			continue
+		}
+		s := ref.Value.Schema.Value
+		raw := strings.Join(values, ",")
+		f := schemaFindings(s, path, headerValue(s, raw), false)
+		if len(f) == 0 {
+			continue
+		}
+		sev := SeverityWarning
+		for _, x := range f {
+			if x.Severity == SeverityBreaking || (x.Severity == "" && defaultSeverity(x.Type) == SeverityBreaking) {
+				sev = SeverityBreaking
+			}
+		}
+		out = append(out, DriftFinding{Path: path, Type: DriftHeaderInvalid, Schema: strings.Join(s.Type.Slice(), "|"),
+			Actual: raw, Detail: summarizeFindings(f), Severity: sev})
+	}
+	return out
+}
+
+// headerValue converts a header string into the JSON value its schema describes (simple style),
+// so the body checks can be reused. Values that do not parse stay strings and fail the type check.
+func headerValue(s *openapi3.Schema, raw string) interface{} {
+	switch {
+	case s.Type.Is("array"):
+		var items []interface{}
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			if s.Items != nil && s.Items.Value != nil {
+				items = append(items, headerValue(s.Items.Value, part))
+			} else {
+				items = append(items, part)
+			}
+		}
+		return items
+	case s.Type.Is("integer"), s.Type.Is("number"):
+		if f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+			return f
+		}
+	case s.Type.Is("boolean"):
+		if b, err := strconv.ParseBool(strings.TrimSpace(raw)); err == nil {
+			return b
+		}
+	}
+	return raw
+}
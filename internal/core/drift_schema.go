@@ -0,0 +1,313 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxBranchDetail limits how many findings per failed branch are quoted in Detail.
+const maxBranchDetail = 3
+
+func compareSchemaToValue(s *openapi3.Schema, path string, value interface{}, res *DriftResult) {
+	if path == "" {
+		path = "$"
+	}
+	if f := schemaFindings(s, path, value, false); len(f) > 0 {
+		for i := range f {
+			if f[i].Severity == "" {
+				f[i].Severity = defaultSeverity(f[i].Type)
+			}
+		}
+		res.Findings = append(res.Findings, f...)
+		res.OK = false
+	}
+}
+
+// schemaFindings checks value against s including allOf/oneOf/anyOf/not composition.
+// skipExtra suppresses unknown-property findings at this level only; allOf branches use it
+// because the allowed properties are spread over several schemas.
+func schemaFindings(s *openapi3.Schema, path string, value interface{}, skipExtra bool) []DriftFinding {
+	var out []DriftFinding
+	if value == nil && s.PermitsNull() {
+		return nil
+	}
+	if types := s.Type.Slice(); len(types) > 0 && !typeAllowed(types, value) {
+		schema := strings.Join(types, "|")
+		if value == nil {
+			schema += " (not nullable)"
+		}
+		return []DriftFinding{{Path: path, Type: DriftTypeMismatch, Schema: schema, Actual: typeOf(value)}}
+	}
+
+	out = append(out, constraintFindings(s, path, value)...)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out = append(out, objectFindings(s, path, v, skipExtra)...)
+	case []interface{}:
+		if s.Items != nil && s.Items.Value != nil {
+			for i, item := range v {
+				out = append(out, schemaFindings(s.Items.Value, path+"["+strconv.Itoa(i)+"]", item, false)...)
+			}
+		}
+	}
+	if len(s.Enum) > 0 && value != nil && !enumContains(s.Enum, value) {
+		var allowed []string
+		for _, e := range s.Enum {
+			allowed = append(allowed, enumString(e))
+		}
+		out = append(out, DriftFinding{Path: path, Type: DriftEnumViolation, Actual: enumString(value), Enum: allowed})
+	}
+
+	for _, br := range s.AllOf {
+		if br != nil && br.Value != nil {
+			out = append(out, schemaFindings(br.Value, path, value, true)...)
+		}
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		out = append(out, branchFindings(s, path, value)...)
+	}
+	if s.Not != nil && s.Not.Value != nil && len(schemaFindings(s.Not.Value, path, value, false)) == 0 {
+		out = append(out, DriftFinding{Path: path, Type: DriftNotViolation, Schema: "not " + schemaLabel(s.Not), Actual: typeOf(value)})
+	}
+	return out
+}
+
+func objectFindings(s *openapi3.Schema, path string, obj map[string]interface{}, skipExtra bool) []DriftFinding {
+	var out []DriftFinding
+	for name, prop := range s.Properties {
+		actual, exists := obj[name]
+		if !exists || prop == nil || prop.Value == nil {
+			continue
+		}
+		out = append(out, schemaFindings(prop.Value, path+"."+name, actual, false)...)
+	}
+	for _, name := range s.Required {
+		if _, exists := obj[name]; exists {
+			continue
+		}
+		expected := ""
+		if prop := s.Properties[name]; prop != nil && prop.Value != nil {
+			expected = strings.Join(prop.Value.Type.Slice(), "|")
+		}
+		out = append(out, DriftFinding{Path: path + "." + name, Type: DriftMissing, Schema: expected})
+	}
+	if skipExtra {
+		return out
+	}
+
+	props, addl := objectShape(s)
+	if len(props) == 0 && addl.Has == nil && addl.Schema == nil {
+		// Free-form object or shape defined only by oneOf/anyOf branches.
+		return out
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		if !props[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub := path + "." + name
+		switch {
+		case addl.Schema != nil && addl.Schema.Value != nil:
+			out = append(out, schemaFindings(addl.Schema.Value, sub, obj[name], false)...)
+		case addl.Has != nil && *addl.Has:
+			// explicitly open
+		case addl.Has != nil:
+			out = append(out, DriftFinding{Path: sub, Type: DriftExtra, Actual: typeOf(obj[name]), Detail: "additionalProperties: false", Severity: SeverityBreaking})
+		default:
+			out = append(out, DriftFinding{Path: sub, Type: DriftExtra, Actual: typeOf(obj[name])})
+		}
+	}
+	return out
+}
+
+// objectShape collects declared property names of s and its allOf branches, and the
+// effective additionalProperties rule (the first explicit one wins).
+func objectShape(s *openapi3.Schema) (map[string]bool, openapi3.AdditionalProperties) {
+	props := map[string]bool{}
+	addl := s.AdditionalProperties
+	for name := range s.Properties {
+		props[name] = true
+	}
+	for _, br := range s.AllOf {
+		if br == nil || br.Value == nil {
+			continue
+		}
+		sub, subAddl := objectShape(br.Value)
+		for name := range sub {
+			props[name] = true
+		}
+		if addl.Has == nil && addl.Schema == nil {
+			addl = subAddl
+		}
+	}
+	return props, addl
+}
+
+// branchFindings applies oneOf/anyOf semantics. A discriminator narrows the check to the mapped branch.
+func branchFindings(s *openapi3.Schema, path string, value interface{}) []DriftFinding {
+	kind, branches := "anyOf", s.AnyOf
+	if len(s.OneOf) > 0 {
+		kind, branches = "oneOf", s.OneOf
+	}
+	label := kind + "[" + branchLabels(branches) + "]"
+
+	if d := s.Discriminator; d != nil && d.PropertyName != "" {
+		if obj, ok := value.(map[string]interface{}); ok {
+			tag, _ := obj[d.PropertyName].(string)
+			if tag == "" {
+				return []DriftFinding{{Path: path + "." + d.PropertyName, Type: DriftMissing, Schema: "discriminator", Detail: label}}
+			}
+			idx := discriminatorBranch(d, branches, tag)
+			if idx < 0 {
+				return []DriftFinding{{Path: path, Type: DriftNoBranchMatch, Schema: label, Actual: d.PropertyName + "=" + tag,
+					Detail: fmt.Sprintf("discriminator value %q maps to no branch", tag)}}
+			}
+			return schemaFindings(branches[idx].Value, path, value, false)
+		}
+	}
+
+	var matched []string
+	var failed []string
+	for i, br := range branches {
+		if br == nil || br.Value == nil {
+			continue
+		}
+		f := schemaFindings(br.Value, path, value, false)
+		name := branchLabel(i, br)
+		if len(f) == 0 {
+			matched = append(matched, name)
+			continue
+		}
+		failed = append(failed, name+": "+summarizeFindings(f))
+	}
+	switch {
+	case len(matched) == 0:
+		return []DriftFinding{{Path: path, Type: DriftNoBranchMatch, Schema: label, Actual: typeOf(value), Detail: strings.Join(failed, " | ")}}
+	case kind == "oneOf" && len(matched) > 1:
+		return []DriftFinding{{Path: path, Type: DriftAmbiguousBranch, Schema: label, Actual: typeOf(value), Detail: "matched " + strings.Join(matched, ", ")}}
+	}
+	return nil
+}
+
+func discriminatorBranch(d *openapi3.Discriminator, branches openapi3.SchemaRefs, tag string) int {
+	want := d.Mapping[tag]
+	for i, br := range branches {
+		if br == nil || br.Value == nil {
+			continue
+		}
+		if want != "" && br.Ref == want {
+			return i
+		}
+		if want == "" && refName(br.Ref) == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+func branchLabels(branches openapi3.SchemaRefs) string {
+	names := make([]string, 0, len(branches))
+	for i, br := range branches {
+		names = append(names, branchLabel(i, br))
+	}
+	return strings.Join(names, "|")
+}
+
+func branchLabel(i int, br *openapi3.SchemaRef) string {
+	if br != nil && br.Ref != "" {
+		return refName(br.Ref)
+	}
+	return "#" + strconv.Itoa(i)
+}
+
+func schemaLabel(ref *openapi3.SchemaRef) string {
+	if ref.Ref != "" {
+		return refName(ref.Ref)
+	}
+	return strings.Join(ref.Value.Type.Slice(), "|")
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func summarizeFindings(f []DriftFinding) string {
+	parts := make([]string, 0, maxBranchDetail)
+	for i, x := range f {
+		if i == maxBranchDetail {
+			parts = append(parts, fmt.Sprintf("+%d more", len(f)-maxBranchDetail))
+			break
+		}
+		parts = append(parts, string(x.Type)+" "+x.Path)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// typeAllowed reports whether the JSON value satisfies one of the schema types.
+func typeAllowed(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if value != nil && reflect.TypeOf(value).Kind() == reflect.Bool {
+				return true
+			}
+		case "number":
+			if value != nil && isNumber(value) {
+				return true
+			}
+		case "integer":
+			if f, ok := value.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+			if value != nil && isNumber(value) {
+				if _, isFloat := value.(float64); !isFloat {
+					return true
+				}
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, value) || enumString(e) == enumString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(v interface{}) string {
+	if s := stringify(v); s != "" {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,293 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func loadOp(t *testing.T, spec, path, method string) *openapi3.Operation {
+	t.Helper()
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return doc.Paths.Value(path).GetOperation(method)
+}
+
+func findingTypes(r DriftResult) map[DriftType][]DriftFinding {
+	out := map[DriftType][]DriftFinding{}
+	for _, f := range r.Findings {
+		out[f.Type] = append(out[f.Type], f)
+	}
+	return out
+}
+
+const compositionSpec = `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+  /owner:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Owner'}
+components:
+  schemas:
+    Base:
+      type: object
+      required: [id]
+      properties:
+        id: {type: integer}
+    Owner:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - type: object
+          additionalProperties: false
+          properties:
+            name: {type: string, nullable: true}
+            nick: {type: string}
+    Cat:
+      type: object
+      required: [kind, meow]
+      properties:
+        kind: {type: string}
+        meow: {type: boolean}
+    Dog:
+      type: object
+      required: [kind, bark]
+      properties:
+        kind: {type: string}
+        bark: {type: integer}
+    Pet:
+      oneOf:
+        - $ref: '#/components/schemas/Cat'
+        - $ref: '#/components/schemas/Dog'
+      discriminator:
+        propertyName: kind
+        mapping:
+          cat: '#/components/schemas/Cat'
+          dog: '#/components/schemas/Dog'
+`
+
+func TestDriftAllOfNullableAdditionalProperties(t *testing.T) {
+	op := loadOp(t, compositionSpec, "/owner", "GET")
+
+	ok := RunDrift([]byte(`{"id":1,"name":null}`), op, 200)
+	if !ok.OK {
+		t.Fatalf("expected allOf match with nullable name: %+v", ok.Findings)
+	}
+
+	bad := RunDrift([]byte(`{"name":"x","nick":null,"extra":1}`), op, 200)
+	got := findingTypes(bad)
+	if len(got[DriftMissing]) != 1 || got[DriftMissing][0].Path != "$.id" {
+		t.Fatalf("expected missing id from allOf base: %+v", bad.Findings)
+	}
+	if len(got[DriftTypeMismatch]) != 1 || got[DriftTypeMismatch][0].Path != "$.nick" {
+		t.Fatalf("expected non-nullable nick mismatch: %+v", bad.Findings)
+	}
+	if len(got[DriftExtra]) != 1 || got[DriftExtra][0].Detail != "additionalProperties: false" {
+		t.Fatalf("expected closed-object extra: %+v", bad.Findings)
+	}
+}
+
+func TestDriftOneOfDiscriminator(t *testing.T) {
+	op := loadOp(t, compositionSpec, "/pets", "GET")
+
+	ok := RunDrift([]byte(`[{"kind":"cat","meow":true},{"kind":"dog","bark":3}]`), op, 200)
+	if !ok.OK {
+		t.Fatalf("expected discriminated pets to match: %+v", ok.Findings)
+	}
+
+	bad := RunDrift([]byte(`[{"kind":"dog","meow":true},{"kind":"fish"}]`), op, 200)
+	got := findingTypes(bad)
+	if len(got[DriftMissing]) != 1 || got[DriftMissing][0].Path != "$[0].bark" {
+		t.Fatalf("expected dog branch to require bark: %+v", bad.Findings)
+	}
+	if len(got[DriftNoBranchMatch]) != 1 || !strings.Contains(got[DriftNoBranchMatch][0].Detail, "fish") {
+		t.Fatalf("expected unmapped discriminator: %+v", bad.Findings)
+	}
+}
+
+func TestDriftAnyOfExplainsBranches(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /v:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                anyOf:
+                  - {type: string}
+                  - {type: integer}
+`
+	op := loadOp(t, spec, "/v", "GET")
+	if r := RunDrift([]byte(`7`), op, 200); !r.OK {
+		t.Fatalf("expected integer branch match: %+v", r.Findings)
+	}
+	r := RunDrift([]byte(`true`), op, 200)
+	got := findingTypes(r)
+	if len(got[DriftNoBranchMatch]) != 1 || !strings.Contains(got[DriftNoBranchMatch][0].Detail, "#0: type_mismatch $") {
+		t.Fatalf("expected per-branch explanation: %+v", r.Findings)
+	}
+}
+
+func TestDriftConstraintFindings(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /items:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                maxItems: 2
+                items:
+                  type: object
+                  required: [id]
+                  properties:
+                    id: {type: string, format: uuid}
+                    code: {type: string, pattern: '^[A-Z]{3}$', minLength: 3, maxLength: 3}
+                    mail: {type: string, format: email}
+                    at: {type: string, format: date-time}
+                    link: {type: string, format: uri}
+                    qty: {type: integer, minimum: 1, maximum: 10, exclusiveMaximum: true}
+                    tags: {type: array, uniqueItems: true, minItems: 1, items: {type: string}}
+`
+	op := loadOp(t, spec, "/items", "GET")
+
+	ok := RunDrift([]byte(`[{"id":"0b7e0f6c-2d7a-4d1e-9f3b-1b2c3d4e5f60","code":"ABC","mail":"a@b.io","at":"2024-01-02T03:04:05Z","link":"https://x.io/a","qty":9,"tags":["a","b"]}]`), op, 200)
+	if !ok.OK {
+		t.Fatalf("expected valid item: %+v", ok.Findings)
+	}
+
+	bad := RunDrift([]byte(`[{"id":"nope","code":"abcd","mail":"x","at":"yesterday","link":"/rel","qty":10,"tags":["a","a"]},{"qty":0,"tags":[]},{"id":"0b7e0f6c-2d7a-4d1e-9f3b-1b2c3d4e5f60"}]`), op, 200)
+	want := map[DriftType][]string{
+		DriftItemCountViolation:   {"$"},
+		DriftFormatViolation:      {"$[0].id", "$[0].mail", "$[0].at", "$[0].link"},
+		DriftPatternViolation:     {"$[0].code"},
+		DriftLengthViolation:      {"$[0].code"},
+		DriftRangeViolation:       {"$[0].qty", "$[1].qty"},
+		DriftUniqueItemsViolation: {"$[0].tags"},
+		DriftMissing:              {"$[1].id"},
+	}
+	got := findingTypes(bad)
+	for typ, paths := range want {
+		var have []string
+		for _, f := range got[typ] {
+			have = append(have, f.Path)
+		}
+		for _, p := range paths {
+			if !sliceContains(have, p) {
+				t.Errorf("%s: missing %s (have %v)", typ, p, have)
+			}
+		}
+	}
+	if n := len(got[DriftItemCountViolation]); n != 2 {
+		t.Errorf("expected maxItems and minItems findings, got %d", n)
+	}
+
+	for _, f := range bad.Findings {
+		wantSev := SeverityWarning
+		if f.Type == DriftMissing {
+			wantSev = SeverityBreaking
+		}
+		if f.Severity != wantSev {
+			t.Errorf("%s %s: severity %q, want %q", f.Type, f.Path, f.Severity, wantSev)
+		}
+	}
+	if bad.Breaking() != 1 || !bad.Fails(SeverityBreaking) || !bad.Fails(SeverityWarning) || bad.Fails("") {
+		t.Fatalf("unexpected gating: breaking=%d", bad.Breaking())
+	}
+	warnOnly := DriftResult{Findings: []DriftFinding{{Type: DriftExtra, Severity: SeverityWarning}}}
+	if warnOnly.Fails(SeverityBreaking) || !warnOnly.Fails(SeverityWarning) {
+		t.Fatal("warning-only result must fail only a warning gate")
+	}
+}
+
+func TestDriftResponseHeadersAndContentType(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /orders:
+    get:
+      responses:
+        "200":
+          description: ok
+          headers:
+            X-Rate-Limit: {required: true, schema: {type: integer, maximum: 100}}
+            X-Trace: {schema: {type: string, format: uuid}}
+          content:
+            application/vnd.acme.v1+json:
+              schema: {type: object, required: [id], properties: {id: {type: integer}}}
+        4XX:
+          description: problem
+          content:
+            application/problem+json:
+              schema: {type: object, required: [title], properties: {title: {type: string}}}
+`
+	op := loadOp(t, spec, "/orders", "GET")
+	hdr := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	ok := RunDriftResponse(op, 200, hdr("Content-Type", "application/vnd.acme.v1+json; charset=utf-8", "X-Rate-Limit", "10"), []byte(`{"id":1}`))
+	if !ok.OK {
+		t.Fatalf("expected vendor json to match: %+v", ok.Findings)
+	}
+	problem := RunDriftResponse(op, 404, hdr("Content-Type", "application/problem+json"), []byte(`{"detail":"x"}`))
+	if got := findingTypes(problem); len(got[DriftMissing]) != 1 || got[DriftMissing][0].Path != "$.title" {
+		t.Fatalf("expected problem+json body to be validated: %+v", problem.Findings)
+	}
+
+	bad := RunDriftResponse(op, 200, hdr("Content-Type", "text/html", "X-Trace", "abc"), []byte(`<html>`))
+	got := findingTypes(bad)
+	if len(got[DriftContentType]) != 1 || got[DriftContentType][0].Actual != "text/html" {
+		t.Fatalf("expected content type drift: %+v", bad.Findings)
+	}
+	if len(got[DriftHeaderMissing]) != 1 || got[DriftHeaderMissing][0].Path != "header:X-Rate-Limit" {
+		t.Fatalf("expected missing required header: %+v", bad.Findings)
+	}
+	if h := got[DriftHeaderInvalid]; len(h) != 1 || h[0].Severity != SeverityWarning || !strings.Contains(h[0].Detail, "format_violation") {
+		t.Fatalf("expected invalid trace header: %+v", bad.Findings)
+	}
+	if len(got[DriftTypeMismatch]) != 0 {
+		t.Fatalf("body must not be validated after content type drift: %+v", bad.Findings)
+	}
+
+	typed := RunDriftResponse(op, 200, hdr("Content-Type", "application/vnd.acme.v1+json", "X-Rate-Limit", "lots"), []byte(`{"id":1}`))
+	if h := findingTypes(typed)[DriftHeaderInvalid]; len(h) != 1 || h[0].Severity != SeverityBreaking {
+		t.Fatalf("expected non-integer rate limit header: %+v", typed.Findings)
+	}
+}
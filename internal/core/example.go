@@ -0,0 +1,449 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// FormatFunc generates a string for one OpenAPI string format.
+type FormatFunc func(rng *rand.Rand) string
+
+var (
+	formatMu sync.RWMutex
+	formats  = map[string]FormatFunc{
+		"uuid":      genUUID,
+		"email":     func(rng *rand.Rand) string { return fmt.Sprintf("user%d@example.com", rng.Intn(10000)) },
+		"date-time": func(rng *rand.Rand) string { return genTime(rng).Format(time.RFC3339) },
+		"date":      func(rng *rand.Rand) string { return genTime(rng).Format("2006-01-02") },
+		"time":      func(rng *rand.Rand) string { return genTime(rng).Format("15:04:05") },
+		"ipv4": func(rng *rand.Rand) string {
+			return fmt.Sprintf("10.%d.%d.%d", rng.Intn(256), rng.Intn(256), 1+rng.Intn(254))
+		},
+		"ipv6":     func(rng *rand.Rand) string { return fmt.Sprintf("fd00::%x:%x", rng.Intn(0xffff), 1+rng.Intn(0xfffe)) },
+		"uri":      func(rng *rand.Rand) string { return "https://example.com/" + randomString(rng, 8) },
+		"url":      func(rng *rand.Rand) string { return "https://example.com/" + randomString(rng, 8) },
+		"hostname": func(rng *rand.Rand) string { return "host" + fmt.Sprint(rng.Intn(1000)) + ".example.com" },
+		"byte":     func(rng *rand.Rand) string { return base64.StdEncoding.EncodeToString([]byte(randomString(rng, 9))) },
+		"password": func(rng *rand.Rand) string { return "P@ss" + randomString(rng, 8) },
+	}
+)
+
+// RegisterFormat adds or replaces the generator for a string format (e.g. a company "iban" format).
+func RegisterFormat(name string, fn FormatFunc) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	formats[name] = fn
+}
+
+// maxExampleDepth stops generation on recursive schemas.
+const maxExampleDepth = 6
+
+// ExampleGenerator builds example values from schemas. Output depends only on the seed
+// and the schema, so the explorer, smoke and fuzz runs can reproduce a payload.
+type ExampleGenerator struct {
+	// ExampleName selects a named example (media type or parameter "examples") when present.
+	ExampleName string
+	rng         *rand.Rand
+}
+
+// NewExampleGenerator returns a generator seeded with seed.
+func NewExampleGenerator(seed int64) *ExampleGenerator {
+	return &ExampleGenerator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// ExampleGeneratorFor returns a generator for ep seeded with seed mixed with the endpoint identity,
+// so every endpoint gets its own reproducible sequence regardless of run order or concurrency.
+func ExampleGeneratorFor(ep Endpoint, seed int64, exampleName string) *ExampleGenerator {
+	g := NewExampleGenerator(seed ^ endpointSeed(ep))
+	g.ExampleName = exampleName
+	return g
+}
+
+// Body generates the JSON request body of op: a named example, the media type example,
+// the first named example, or a value generated from the schema.
+func (g *ExampleGenerator) Body(op *openapi3.Operation) ([]byte, error) {
+	if op == nil || op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil, nil
+	}
+	content := op.RequestBody.Value.Content.Get("application/json")
+	if content == nil || content.Schema == nil || content.Schema.Value == nil {
+		return nil, nil
+	}
+	if ex, ok := g.namedExample(content.Examples, true); ok {
+		return json.Marshal(ex)
+	}
+	if content.Example != nil {
+		return json.Marshal(content.Example)
+	}
+	if ex, ok := g.namedExample(content.Examples, false); ok {
+		return json.Marshal(ex)
+	}
+	return json.Marshal(g.value(content.Schema.Value, 0, true))
+}
+
+// Value generates a value for s honouring example, enum, default, format, pattern,
+// numeric bounds, string length and array size.
+func (g *ExampleGenerator) Value(s *openapi3.Schema) interface{} {
+	return g.value(s, 0, false)
+}
+
+// namedExample returns examples[g.ExampleName] when onlySelected, otherwise the first example by name.
+func (g *ExampleGenerator) namedExample(examples openapi3.Examples, onlySelected bool) (interface{}, bool) {
+	if onlySelected {
+		if g.ExampleName == "" {
+			return nil, false
+		}
+		if ex := examples[g.ExampleName]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value, true
+		}
+		return nil, false
+	}
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value, true
+		}
+	}
+	return nil, false
+}
+
+// value generates for s; request skips readOnly properties (request bodies).
+func (g *ExampleGenerator) value(s *openapi3.Schema, depth int, request bool) interface{} {
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case s.Default != nil:
+		return s.Default
+	case len(s.AllOf) > 0:
+		return g.allOf(s, depth, request)
+	case len(s.OneOf) > 0 && s.OneOf[0].Value != nil:
+		return g.value(s.OneOf[0].Value, depth+1, request)
+	case len(s.AnyOf) > 0 && s.AnyOf[0].Value != nil:
+		return g.value(s.AnyOf[0].Value, depth+1, request)
+	}
+	switch schemaKind(s) {
+	case "string":
+		return g.stringValue(s)
+	case "integer":
+		return g.integerValue(s)
+	case "number":
+		return g.numberValue(s)
+	case "boolean":
+		return true
+	case "array":
+		return g.arrayValue(s, depth, request)
+	case "object":
+		return g.objectValue(s, depth, request)
+	}
+	return "example"
+}
+
+func schemaKind(s *openapi3.Schema) string {
+	for _, t := range s.Type.Slice() {
+		if t != "null" {
+			return t
+		}
+	}
+	switch {
+	case len(s.Properties) > 0:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	}
+	return ""
+}
+
+func (g *ExampleGenerator) allOf(s *openapi3.Schema, depth int, request bool) interface{} {
+	merged := map[string]interface{}{}
+	if obj, ok := g.objectValue(s, depth, request).(map[string]interface{}); ok {
+		for k, v := range obj {
+			merged[k] = v
+		}
+	}
+	for _, br := range s.AllOf {
+		if br == nil || br.Value == nil {
+			continue
+		}
+		v := g.value(br.Value, depth+1, request)
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for k, x := range obj {
+			merged[k] = x
+		}
+	}
+	return merged
+}
+
+func (g *ExampleGenerator) stringValue(s *openapi3.Schema) string {
+	v := "example"
+	formatMu.RLock()
+	fn := formats[s.Format]
+	formatMu.RUnlock()
+	switch {
+	case fn != nil:
+		v = fn(g.rng)
+	case s.Pattern != "":
+		if p, ok := genPattern(s.Pattern, g.rng); ok {
+			v = p
+		}
+	}
+	// minLength/maxLength hold for format and pattern values too; a value of the wrong
+	// length is rejected whatever its shape.
+	if n := int(s.MinLength); len(v) < n {
+		v += randomString(g.rng, n-len(v))
+	}
+	if s.MaxLength != nil && uint64(len(v)) > *s.MaxLength {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
+
+// bounds returns the inclusive range allowed by minimum/maximum; step is 1 for integers.
+func bounds(s *openapi3.Schema, step float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(-1), math.Inf(1)
+	if s.Min != nil {
+		lo = *s.Min
+		if s.ExclusiveMin {
+			lo += step
+		}
+	}
+	if s.Max != nil {
+		hi = *s.Max
+		if s.ExclusiveMax {
+			hi -= step
+		}
+	}
+	return lo, hi, s.Min != nil || s.Max != nil
+}
+
+func (g *ExampleGenerator) integerValue(s *openapi3.Schema) int {
+	lo, hi, ok := bounds(s, 1)
+	if !ok {
+		if s.MultipleOf != nil && *s.MultipleOf >= 1 {
+			return int(*s.MultipleOf)
+		}
+		return 1
+	}
+	switch {
+	case math.IsInf(lo, -1):
+		lo = math.Min(hi, 1)
+	case math.IsInf(hi, 1):
+		hi = lo + 100
+	}
+	lo, hi = math.Ceil(lo), math.Floor(hi)
+	v := lo
+	if hi > lo {
+		v = lo + float64(g.rng.Int63n(int64(math.Min(hi-lo, 1<<40))+1))
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		m := *s.MultipleOf
+		if r := math.Ceil(v/m) * m; r <= hi {
+			v = r
+		} else {
+			v = math.Floor(v/m) * m
+		}
+	}
+	return int(v)
+}
+
+func (g *ExampleGenerator) numberValue(s *openapi3.Schema) float64 {
+	lo, hi, ok := bounds(s, 0.01)
+	if !ok {
+		return 1
+	}
+	switch {
+	case math.IsInf(lo, -1):
+		lo = math.Min(hi, 1)
+	case math.IsInf(hi, 1):
+		hi = lo + 100
+	}
+	v := lo + g.rng.Float64()*(hi-lo)
+	return math.Round(v*100) / 100
+}
+
+func (g *ExampleGenerator) arrayValue(s *openapi3.Schema, depth int, request bool) []interface{} {
+	n := int(s.MinItems)
+	if n < 1 {
+		n = 1
+	}
+	if s.MaxItems != nil && uint64(n) > *s.MaxItems {
+		n = int(*s.MaxItems)
+	}
+	out := make([]interface{}, 0, n)
+	if s.Items == nil || s.Items.Value == nil {
+		for i := 0; i < n; i++ {
+			out = append(out, "item")
+		}
+		return out
+	}
+	if depth >= maxExampleDepth {
+		return out
+	}
+	seen := map[string]bool{}
+	for i := 0; len(out) < n && i < n*4; i++ {
+		v := g.value(s.Items.Value, depth+1, request)
+		if s.UniqueItems {
+			b, _ := json.Marshal(v)
+			if seen[string(b)] {
+				str, ok := v.(string)
+				if !ok {
+					continue
+				}
+				// Fixed strings ("example") repeat; number them to keep the items unique.
+				v = numbered(str, len(out), s.Items.Value.MaxLength)
+				b, _ = json.Marshal(v)
+			}
+			if seen[string(b)] {
+				continue
+			}
+			seen[string(b)] = true
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// numbered suffixes v with i, overwriting its tail when maxLength leaves no room.
+func numbered(v string, i int, maxLength *uint64) string {
+	suffix := strconv.Itoa(i)
+	if maxLength != nil && uint64(len(v)+len(suffix)) > *maxLength && uint64(len(suffix)) <= *maxLength {
+		v = v[:*maxLength-uint64(len(suffix))]
+	}
+	return v + suffix
+}
+
+func (g *ExampleGenerator) objectValue(s *openapi3.Schema, depth int, request bool) interface{} {
+	m := make(map[string]interface{})
+	if depth >= maxExampleDepth {
+		return m
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop == nil || prop.Value == nil {
+			m[name] = "example"
+			continue
+		}
+		if request && prop.Value.ReadOnly && !sliceContains(s.Required, name) {
+			continue
+		}
+		m[name] = g.value(prop.Value, depth+1, request)
+	}
+	return m
+}
+
+func genUUID(rng *rand.Rand) string {
+	b := make([]byte, 16)
+	rng.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func genTime(rng *rand.Rand) time.Time {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return base.Add(time.Duration(rng.Int63n(365*24*3600)) * time.Second)
+}
+
+// genPattern produces a string matching the regular expression p.
+// Unbounded repeats are capped so the output stays short.
+func genPattern(p string, rng *rand.Rand) (string, bool) {
+	re, err := syntax.Parse(p, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var sb strings.Builder
+	if !genRegexp(re.Simplify(), rng, &sb) {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+func genRegexp(re *syntax.Regexp, rng *rand.Rand, sb *strings.Builder) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+		return true
+	case syntax.OpCharClass:
+		if len(re.Rune) < 2 {
+			return false
+		}
+		// Prefer printable ASCII so negated classes like [^/] stay readable.
+		var printable []rune
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := max(re.Rune[i], '0'); r <= min(re.Rune[i+1], 'z'); r++ {
+				printable = append(printable, r)
+			}
+		}
+		if len(printable) > 0 {
+			sb.WriteRune(printable[rng.Intn(len(printable))])
+			return true
+		}
+		sb.WriteRune(re.Rune[0])
+		return true
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteByte(fuzzAlphabet[rng.Intn(len(fuzzAlphabet))])
+		return true
+	case syntax.OpCapture:
+		return genRegexp(re.Sub[0], rng, sb)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !genRegexp(sub, rng, sb) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpAlternate:
+		return genRegexp(re.Sub[rng.Intn(len(re.Sub))], rng, sb)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, 3
+		case syntax.OpPlus:
+			min, max = 1, 3
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + 3
+		}
+		n := min
+		if max > min {
+			n += rng.Intn(max - min + 1)
+		}
+		for i := 0; i < n; i++ {
+			if !genRegexp(re.Sub[0], rng, sb) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
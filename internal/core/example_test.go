@@ -0,0 +1,149 @@
+package core
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const exampleSpec = `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /orders/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: string, format: uuid}}
+    post:
+      parameters:
+        - name: region
+          in: query
+          required: true
+          schema: {type: string}
+          examples:
+            eu: {value: eu-west}
+            us: {value: us-east}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [id, email, createdAt, ip, callback, sku, qty, price, tags, code]
+              properties:
+                id: {type: string, format: uuid, readOnly: true}
+                email: {type: string, format: email}
+                createdAt: {type: string, format: date-time}
+                ip: {type: string, format: ipv4}
+                callback: {type: string, format: uri}
+                sku: {type: string, pattern: "^[A-Z]{3}-[0-9]{4}$"}
+                qty: {type: integer, minimum: 5, maximum: 9}
+                price: {type: number, minimum: 10, exclusiveMinimum: true, maximum: 20}
+                tags:
+                  type: array
+                  minItems: 2
+                  maxItems: 3
+                  uniqueItems: true
+                  items: {type: string, minLength: 6}
+                code: {type: string, minLength: 12, maxLength: 12}
+                audit: {type: string, readOnly: true}
+      responses:
+        "201": {description: created}
+`
+
+// generatedBody generates the request body of POST /orders/{id} and decodes it like a response.
+func generatedBody(t *testing.T, seed int64) map[string]interface{} {
+	t.Helper()
+	ep := loadEndpoint(t, exampleSpec, "/orders/{id}", "POST")
+	raw, err := ExampleGeneratorFor(ep, seed, "").Body(ep.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("body is not JSON: %s", raw)
+	}
+	return body
+}
+
+func TestExampleBodySatisfiesSchema(t *testing.T) {
+	ep := loadEndpoint(t, exampleSpec, "/orders/{id}", "POST")
+	schema := ep.Schema.RequestBody.Value.Content.Get("application/json").Schema.Value
+	for _, seed := range []int64{0, 1, 42, 1 << 40} {
+		body := generatedBody(t, seed)
+		var res DriftResult
+		compareSchemaToValue(schema, "", body, &res)
+		if len(res.Findings) > 0 {
+			t.Fatalf("seed %d: generated body drifts from its own schema: %+v\n%v", seed, res.Findings, body)
+		}
+		if _, ok := body["audit"]; ok {
+			t.Errorf("seed %d: optional readOnly property should not be generated for a request: %v", seed, body)
+		}
+	}
+}
+
+func TestExampleGeneratorIsSeeded(t *testing.T) {
+	a, b := generatedBody(t, 42), generatedBody(t, 42)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("same seed produced different bodies:\n%v\n%v", a, b)
+	}
+	if c := generatedBody(t, 7); reflect.DeepEqual(a, c) {
+		t.Errorf("different seeds produced the same body: %v", a)
+	}
+}
+
+func TestExampleNamedAndRequestSpec(t *testing.T) {
+	ep := loadEndpoint(t, exampleSpec, "/orders/{id}", "POST")
+	rs := BuildRequestSpecWith(ep, nil, ExampleGeneratorFor(ep, 3, "us"))
+	if got := rs.Query.Get("region"); got != "us-east" {
+		t.Errorf("named example: region = %q, want us-east", got)
+	}
+	if got := BuildRequestSpec(ep, nil).Query.Get("region"); got != "eu-west" {
+		t.Errorf("default example: region = %q, want first by name (eu-west)", got)
+	}
+	var res DriftResult
+	compareSchemaToValue(ep.PathItemParams[0].Value.Schema.Value, "", rs.PathParams["id"], &res)
+	if len(res.Findings) > 0 {
+		t.Errorf("path param %q is not a uuid: %+v", rs.PathParams["id"], res.Findings)
+	}
+}
+
+func TestExampleStringLengthWithFormatAndPattern(t *testing.T) {
+	maxLen := func(n uint64) *uint64 { return &n }
+	for _, s := range []*openapi3.Schema{
+		{Type: &openapi3.Types{"string"}, Format: "uuid", MaxLength: maxLen(8)},
+		{Type: &openapi3.Types{"string"}, Format: "date", MinLength: 16},
+		{Type: &openapi3.Types{"string"}, Pattern: "^[A-Z]{2}$", MinLength: 5, MaxLength: maxLen(5)},
+		{Type: &openapi3.Types{"string"}, Pattern: "^[a-z]{20}$", MaxLength: maxLen(10)},
+	} {
+		v, ok := NewExampleGenerator(1).Value(s).(string)
+		if !ok {
+			t.Fatalf("format %q pattern %q: not a string", s.Format, s.Pattern)
+		}
+		if uint64(len(v)) < s.MinLength || s.MaxLength != nil && uint64(len(v)) > *s.MaxLength {
+			t.Errorf("format %q pattern %q: %q (%d chars) violates the length bounds", s.Format, s.Pattern, v, len(v))
+		}
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("iban", func(*rand.Rand) string { return "TR000000000000000000000000" })
+	defer func() {
+		formatMu.Lock()
+		delete(formats, "iban")
+		formatMu.Unlock()
+	}()
+	ep := loadEndpoint(t, `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /accounts:
+    get:
+      parameters:
+        - {name: iban, in: query, required: true, schema: {type: string, format: iban}}
+      responses:
+        "200": {description: ok}
+`, "/accounts", "GET")
+	if got := BuildRequestSpec(ep, nil).Query.Get("iban"); got != "TR000000000000000000000000" {
+		t.Errorf("iban = %q, want the registered format", got)
+	}
+}
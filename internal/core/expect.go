@@ -0,0 +1,119 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ExpectationPolicy decides whether a smoke response status satisfies the contract.
+// Returned reason explains a failed verdict and ends up in SmokeResult.Err.
+type ExpectationPolicy interface {
+	Name() string
+	Check(op *openapi3.Operation, status int) (ok bool, reason string)
+}
+
+// Expectation policy names accepted by PolicyByName.
+const (
+	ExpectDocumented = "documented"
+	ExpectStrict     = "strict"
+	ExpectLegacy     = "legacy"
+)
+
+// PolicyByName returns the policy for a CLI/config name; empty means documented.
+func PolicyByName(name string) (ExpectationPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ExpectDocumented:
+		return DocumentedPolicy{}, nil
+	case ExpectStrict:
+		return StrictPolicy{}, nil
+	case ExpectLegacy:
+		return LegacyPolicy{}, nil
+	}
+	return nil, fmt.Errorf("unknown expectation policy %q (want documented|strict|legacy)", name)
+}
+
+// DocumentedPolicy passes any status the operation documents (exact code, NXX range or default).
+type DocumentedPolicy struct{}
+
+func (DocumentedPolicy) Name() string { return ExpectDocumented }
+
+func (DocumentedPolicy) Check(op *openapi3.Operation, status int) (bool, string) {
+	if _, ok := matchResponse(op, status, true); ok {
+		return true, ""
+	}
+	return false, undocumentedReason(op, status)
+}
+
+// StrictPolicy passes only documented 2xx statuses; documented errors still fail.
+type StrictPolicy struct{}
+
+func (StrictPolicy) Name() string { return ExpectStrict }
+
+func (StrictPolicy) Check(op *openapi3.Operation, status int) (bool, string) {
+	key, ok := matchResponse(op, status, false)
+	if !ok {
+		return false, undocumentedReason(op, status)
+	}
+	if status < 200 || status >= 300 {
+		return false, fmt.Sprintf("status %d is documented (%s) but not a success response", status, key)
+	}
+	return true, ""
+}
+
+// LegacyPolicy is the original rule: any 2xx or 4xx passes.
+type LegacyPolicy struct{}
+
+func (LegacyPolicy) Name() string { return ExpectLegacy }
+
+func (LegacyPolicy) Check(_ *openapi3.Operation, status int) (bool, string) {
+	if status >= 200 && status < 300 || status >= 400 && status < 500 {
+		return true, ""
+	}
+	return false, fmt.Sprintf("status %d is neither 2xx nor 4xx", status)
+}
+
+// matchResponse finds the documented response key for status: exact code, then NXX range,
+// then (if allowDefault) "default".
+func matchResponse(op *openapi3.Operation, status int, allowDefault bool) (string, bool) {
+	if op == nil || op.Responses == nil {
+		return "", false
+	}
+	code := strconv.Itoa(status)
+	if op.Responses.Value(code) != nil {
+		return code, true
+	}
+	rng := code[:1] + "XX"
+	for k := range op.Responses.Map() {
+		if strings.EqualFold(k, rng) {
+			return k, true
+		}
+	}
+	if allowDefault && op.Responses.Default() != nil {
+		return "default", true
+	}
+	return "", false
+}
+
+func undocumentedReason(op *openapi3.Operation, status int) string {
+	keys := documentedStatuses(op)
+	if len(keys) == 0 {
+		return fmt.Sprintf("status %d not documented (operation documents no responses)", status)
+	}
+	return fmt.Sprintf("status %d not documented (documented: %s)", status, strings.Join(keys, ", "))
+}
+
+func documentedStatuses(op *openapi3.Operation) []string {
+	if op == nil || op.Responses == nil {
+		return nil
+	}
+	keys := make([]string, 0, op.Responses.Len())
+	for k := range op.Responses.Map() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,413 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// MutationKind names one family of negative inputs derived from the schema.
+type MutationKind string
+
+const (
+	MutMissingRequired MutationKind = "missing_required"
+	MutWrongType       MutationKind = "wrong_type"
+	MutOutOfRange      MutationKind = "out_of_range"
+	MutOverlongString  MutationKind = "overlong_string"
+	MutInvalidEnum     MutationKind = "invalid_enum"
+	MutMalformedJSON   MutationKind = "malformed_json"
+)
+
+// maxFuzzDepth bounds how deep nested body objects are mutated.
+const maxFuzzDepth = 3
+
+// Mutation is one negative case: a deliberately invalid variant of the example request.
+type Mutation struct {
+	Kind   MutationKind
+	Target string // "body /items/qty", "query page", "header X-Tenant", "path id"
+	Desc   string // what was changed, e.g. "set to 101 (maximum 100)"
+	apply  func(*RequestSpec)
+}
+
+// Apply returns a copy of rs with the mutation applied.
+func (m Mutation) Apply(rs RequestSpec) RequestSpec {
+	out := rs.clone()
+	if m.apply != nil {
+		m.apply(&out)
+	}
+	return out
+}
+
+// FuzzResult is the verdict for one mutation sent to one endpoint.
+type FuzzResult struct {
+	Path       string
+	Method     string
+	Kind       MutationKind
+	Target     string
+	Desc       string
+	StatusCode int
+	LatencyMS  int64
+	Err        string // why the verdict failed (5xx, accepted invalid input, undocumented 4xx, request error)
+	OK         bool
+}
+
+// GenerateMutations derives negative cases for ep from its parameter and requestBody schemas.
+// The same seed always yields the same mutations for the same endpoint.
+func GenerateMutations(ep Endpoint, seed int64) []Mutation {
+	rng := rand.New(rand.NewSource(seed ^ endpointSeed(ep)))
+	var out []Mutation
+	for _, p := range ep.Params() {
+		if p.In == openapi3.ParameterInHeader && ignoredHeaderParam(p.Name) {
+			continue
+		}
+		out = append(out, paramMutations(p, rng)...)
+	}
+	out = append(out, bodyMutations(ep, seed, rng)...)
+	return out
+}
+
+// RunFuzz sends every mutation of every endpoint with the smoke worker pool and rate limiter.
+// A mutation passes when the API answers with a documented 4xx; 5xx and 2xx/3xx always fail.
+// onResult, if set, is called once per finished mutation (serialized) for progress reporting.
+func RunFuzz(ctx context.Context, cfg SmokeConfig, endpoints []Endpoint, seed int64, onResult func(done, total int, r FuzzResult)) []FuzzResult {
+	// The base request is generated from the same seed, so one seed reproduces the whole run.
+	cfg.Seed = seed
+	// Mutated bodies are sent byte for byte; expanding them would rewrite the payload under test.
+	cfg.ExpandBody = nil
+	type job struct {
+		ep Endpoint
+		m  Mutation
+	}
+	var jobs []job
+	for _, ep := range endpoints {
+		for _, m := range GenerateMutations(ep, seed) {
+			jobs = append(jobs, job{ep, m})
+		}
+	}
+	results := make([]FuzzResult, len(jobs))
+	var mu sync.Mutex
+	done := 0
+	runPool(ctx, &cfg, len(jobs), func(i int) {
+		r := doOneFuzz(cfg, jobs[i].ep, jobs[i].m)
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = r
+		done++
+		if onResult != nil {
+			onResult(done, len(jobs), r)
+		}
+	})
+	return results
+}
+
+func doOneFuzz(cfg SmokeConfig, ep Endpoint, m Mutation) FuzzResult {
+	res := FuzzResult{Path: ep.Path, Method: ep.Method, Kind: m.Kind, Target: m.Target, Desc: m.Desc}
+	rs := m.Apply(cfg.requestSpec(ep))
+	req, err := cfg.newRequest(ep, rs)
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	client := &http.Client{
+		Timeout:       cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	res.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	resp.Body.Close()
+	res.StatusCode = resp.StatusCode
+	res.OK, res.Err = fuzzVerdict(ep.Schema, resp.StatusCode)
+	return res
+}
+
+// fuzzVerdict expects invalid input to be rejected with a documented 4xx.
+func fuzzVerdict(op *openapi3.Operation, status int) (bool, string) {
+	switch {
+	case status >= 500:
+		return false, fmt.Sprintf("server error %d on invalid input", status)
+	case status < 400:
+		return false, fmt.Sprintf("invalid input accepted with %d", status)
+	}
+	if _, ok := matchResponse(op, status, true); !ok {
+		return false, undocumentedReason(op, status)
+	}
+	return true, ""
+}
+
+func paramMutations(p *openapi3.Parameter, rng *rand.Rand) []Mutation {
+	target := p.In + " " + p.Name
+	in, name := p.In, p.Name
+	set := func(v string) func(*RequestSpec) {
+		return func(rs *RequestSpec) {
+			switch in {
+			case openapi3.ParameterInPath:
+				rs.PathParams[name] = v
+			case openapi3.ParameterInQuery:
+				rs.Query.Set(name, v)
+			case openapi3.ParameterInHeader:
+				rs.Headers[name] = v
+			case openapi3.ParameterInCookie:
+				rs.Cookies[name] = v
+			}
+		}
+	}
+	var out []Mutation
+	if p.Required && p.In != openapi3.ParameterInPath {
+		out = append(out, Mutation{Kind: MutMissingRequired, Target: target, Desc: "omitted required parameter",
+			apply: func(rs *RequestSpec) {
+				rs.Query.Del(name)
+				delete(rs.Headers, name)
+				delete(rs.Cookies, name)
+			}})
+	}
+	if p.Schema == nil || p.Schema.Value == nil {
+		return out
+	}
+	for _, c := range scalarMutations(p.Schema.Value, rng) {
+		v := paramString(c.value)
+		out = append(out, Mutation{Kind: c.kind, Target: target, Desc: c.desc, apply: set(v)})
+	}
+	return out
+}
+
+// bodyMutations mutates the example JSON body (generated with seed): the document as a whole, then each property.
+func bodyMutations(ep Endpoint, seed int64, rng *rand.Rand) []Mutation {
+	op := ep.Schema
+	switch strings.ToUpper(ep.Method) {
+	case "POST", "PUT", "PATCH":
+	default:
+		return nil
+	}
+	if op == nil || op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+	media := op.RequestBody.Value.Content.Get("application/json")
+	if media == nil || media.Schema == nil || media.Schema.Value == nil {
+		return nil
+	}
+	raw, err := ExampleGeneratorFor(ep, seed, "").Body(op)
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+	var example interface{}
+	if err := json.Unmarshal(raw, &example); err != nil {
+		return nil
+	}
+	withBody := func(b []byte) func(*RequestSpec) {
+		return func(rs *RequestSpec) { rs.Body = b }
+	}
+	out := []Mutation{{Kind: MutMalformedJSON, Target: "body", Desc: "truncated JSON document", apply: withBody(raw[:len(raw)/2])}}
+	if v, ok := wrongType(media.Schema.Value, rng); ok {
+		b, _ := json.Marshal(v)
+		out = append(out, Mutation{Kind: MutWrongType, Target: "body", Desc: "document replaced with " + string(b), apply: withBody(b)})
+	}
+	obj, ok := example.(map[string]interface{})
+	if !ok {
+		return out
+	}
+	var walk func(s *openapi3.Schema, ptr []string, depth int)
+	walk = func(s *openapi3.Schema, ptr []string, depth int) {
+		props, required := fuzzShape(s)
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := props[name]
+			path := append(append([]string(nil), ptr...), name)
+			target := "body /" + strings.Join(path, "/")
+			if required[name] {
+				b := mutateJSON(obj, path, nil, true)
+				out = append(out, Mutation{Kind: MutMissingRequired, Target: target, Desc: "omitted required property", apply: withBody(b)})
+			}
+			if v, ok := wrongType(prop, rng); ok {
+				b := mutateJSON(obj, path, v, false)
+				out = append(out, Mutation{Kind: MutWrongType, Target: target, Desc: fmt.Sprintf("set to %s", jsonString(v)), apply: withBody(b)})
+			}
+			for _, c := range scalarMutations(prop, rng) {
+				if c.kind == MutWrongType {
+					continue
+				}
+				b := mutateJSON(obj, path, c.value, false)
+				out = append(out, Mutation{Kind: c.kind, Target: target, Desc: c.desc, apply: withBody(b)})
+			}
+			if depth < maxFuzzDepth && prop.Type.Is("object") {
+				walk(prop, path, depth+1)
+			}
+		}
+	}
+	walk(media.Schema.Value, nil, 1)
+	return out
+}
+
+type scalarMutation struct {
+	kind  MutationKind
+	value interface{}
+	desc  string
+}
+
+// scalarMutations derives out-of-range, overlong, invalid-enum and (for parameters) wrong-type values.
+func scalarMutations(s *openapi3.Schema, rng *rand.Rand) []scalarMutation {
+	var out []scalarMutation
+	switch {
+	case s.Type.Is("integer"), s.Type.Is("number"):
+		out = append(out, scalarMutation{MutWrongType, "not-a-number", "set to \"not-a-number\""})
+		step := float64(1 + rng.Intn(100))
+		if s.Type.Is("number") && !s.Type.Is("integer") {
+			step = step / 2
+		}
+		if s.Min != nil {
+			v := *s.Min - step
+			if s.ExclusiveMin {
+				v = *s.Min
+			}
+			out = append(out, scalarMutation{MutOutOfRange, v, fmt.Sprintf("set to %s (minimum %s)", jsonString(v), jsonString(*s.Min))})
+		}
+		if s.Max != nil {
+			v := *s.Max + step
+			if s.ExclusiveMax {
+				v = *s.Max
+			}
+			out = append(out, scalarMutation{MutOutOfRange, v, fmt.Sprintf("set to %s (maximum %s)", jsonString(v), jsonString(*s.Max))})
+		}
+	case s.Type.Is("boolean"):
+		out = append(out, scalarMutation{MutWrongType, "maybe", "set to \"maybe\""})
+	case s.Type.Is("string"):
+		if s.MaxLength != nil {
+			n := int(*s.MaxLength) + 1 + rng.Intn(16)
+			out = append(out, scalarMutation{MutOverlongString, randomString(rng, n), fmt.Sprintf("%d chars (maxLength %d)", n, *s.MaxLength)})
+		}
+	}
+	if len(s.Enum) > 0 {
+		v := "not-in-enum-" + randomString(rng, 6)
+		out = append(out, scalarMutation{MutInvalidEnum, v, fmt.Sprintf("set to %q (enum %s)", v, enumLabel(s.Enum))})
+	}
+	return out
+}
+
+// wrongType picks a JSON value whose type the schema does not allow.
+func wrongType(s *openapi3.Schema, rng *rand.Rand) (interface{}, bool) {
+	var candidates []interface{}
+	switch {
+	case s.Type.Is("string"):
+		candidates = []interface{}{12345, true, []interface{}{}}
+	case s.Type.Is("integer"), s.Type.Is("number"):
+		candidates = []interface{}{"not-a-number", true, map[string]interface{}{}}
+	case s.Type.Is("boolean"):
+		candidates = []interface{}{"yes", 0}
+	case s.Type.Is("array"):
+		candidates = []interface{}{"not-an-array", map[string]interface{}{}}
+	case s.Type.Is("object"):
+		candidates = []interface{}{"not-an-object", []interface{}{}}
+	default:
+		return nil, false
+	}
+	return candidates[rng.Intn(len(candidates))], true
+}
+
+// fuzzShape collects properties and required names of s and its allOf branches.
+func fuzzShape(s *openapi3.Schema) (map[string]*openapi3.Schema, map[string]bool) {
+	props := map[string]*openapi3.Schema{}
+	required := map[string]bool{}
+	for name, ref := range s.Properties {
+		if ref != nil && ref.Value != nil {
+			props[name] = ref.Value
+		}
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, br := range s.AllOf {
+		if br == nil || br.Value == nil {
+			continue
+		}
+		p, r := fuzzShape(br.Value)
+		for k, v := range p {
+			props[k] = v
+		}
+		for k := range r {
+			required[k] = true
+		}
+	}
+	return props, required
+}
+
+// mutateJSON returns the JSON of a deep copy of doc with the value at path replaced (or removed).
+func mutateJSON(doc map[string]interface{}, path []string, value interface{}, remove bool) []byte {
+	raw, _ := json.Marshal(doc)
+	var cp map[string]interface{}
+	_ = json.Unmarshal(raw, &cp)
+	cur := cp
+	for _, key := range path[:len(path)-1] {
+		next, ok := cur[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			cur[key] = next
+		}
+		cur = next
+	}
+	last := path[len(path)-1]
+	if remove {
+		delete(cur, last)
+	} else {
+		cur[last] = value
+	}
+	b, _ := json.Marshal(cp)
+	return b
+}
+
+func endpointSeed(ep Endpoint) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToUpper(ep.Method) + " " + ep.Path))
+	return int64(h.Sum64())
+}
+
+const fuzzAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomString(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = fuzzAlphabet[rng.Intn(len(fuzzAlphabet))]
+	}
+	return string(b)
+}
+
+func jsonString(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func enumLabel(enum []interface{}) string {
+	parts := make([]string, 0, len(enum))
+	for _, e := range enum {
+		parts = append(parts, enumString(e))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const fuzzSpec = `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /pets/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer, minimum: 1}}
+    put:
+      parameters:
+        - {name: X-Tenant, in: header, required: true, schema: {type: string}}
+        - {name: mode, in: query, schema: {type: string, enum: [fast, slow]}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, maxLength: 8}
+                age: {type: integer, minimum: 0, maximum: 30}
+                owner:
+                  type: object
+                  required: [email]
+                  properties:
+                    email: {type: string}
+      responses:
+        "200": {description: ok}
+        "400": {description: bad}
+`
+
+func TestGenerateMutationsIsSeeded(t *testing.T) {
+	ep := loadEndpoint(t, fuzzSpec, "/pets/{id}", "PUT")
+	a := GenerateMutations(ep, 42)
+	b := GenerateMutations(ep, 42)
+	if len(a) != len(b) {
+		t.Fatalf("mutation count differs: %d vs %d", len(a), len(b))
+	}
+	for i := range a {
+		ra := a[i].Apply(BuildRequestSpec(ep, nil))
+		rb := b[i].Apply(BuildRequestSpec(ep, nil))
+		if a[i].Desc != b[i].Desc || !reflect.DeepEqual(ra, rb) {
+			t.Fatalf("mutation %d not reproducible: %+v vs %+v", i, a[i], b[i])
+		}
+	}
+
+	targets := map[MutationKind]map[string]bool{}
+	for _, m := range a {
+		if targets[m.Kind] == nil {
+			targets[m.Kind] = map[string]bool{}
+		}
+		targets[m.Kind][m.Target] = true
+	}
+	want := map[MutationKind][]string{
+		MutMissingRequired: {"header X-Tenant", "body /name", "body /owner/email"},
+		MutWrongType:       {"path id", "body", "body /age", "body /name"},
+		MutOutOfRange:      {"path id", "body /age"},
+		MutOverlongString:  {"body /name"},
+		MutInvalidEnum:     {"query mode"},
+		MutMalformedJSON:   {"body"},
+	}
+	for kind, ts := range want {
+		for _, target := range ts {
+			if !targets[kind][target] {
+				t.Errorf("missing %s mutation on %s (have %v)", kind, target, targets[kind])
+			}
+		}
+	}
+
+	if c := GenerateMutations(ep, 7); reflect.DeepEqual(describe(a), describe(c)) {
+		t.Fatal("different seeds should vary generated values")
+	}
+}
+
+func loadEndpoint(t *testing.T, spec, path, method string) Endpoint {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := doc.Paths.Value(path)
+	return Endpoint{Path: path, Method: method, Schema: item.GetOperation(method), PathItemParams: item.Parameters}
+}
+
+func describe(ms []Mutation) []string {
+	out := make([]string, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, string(m.Kind)+" "+m.Target+" "+m.Desc)
+	}
+	return out
+}
+
+func TestFuzzVerdict(t *testing.T) {
+	op := loadOp(t, fuzzSpec, "/pets/{id}", "PUT")
+	cases := map[int]bool{400: true, 404: false, 500: false, 200: false}
+	for status, want := range cases {
+		if got, reason := fuzzVerdict(op, status); got != want {
+			t.Errorf("status %d: got %v (%s), want %v", status, got, reason, want)
+		}
+	}
+}
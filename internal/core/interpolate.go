@@ -0,0 +1,26 @@
+package core
+
+import "fmt"
+
+// Expand returns a copy of o with its values passed through expand, the ${...} expander of
+// the selected environment. Only user-supplied values are expanded this way;
+// generated and recorded requests are sent as they are, so a spec or capture cannot read
+// local variables, files or environment.
+func (o ParamOverrides) Expand(expand func(string) (string, error)) (ParamOverrides, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := make(ParamOverrides, len(o))
+	for sel, params := range o {
+		m := make(map[string]string, len(params))
+		for k, val := range params {
+			x, err := expand(val)
+			if err != nil {
+				return nil, fmt.Errorf("path param %s.%s: %w", sel, k, err)
+			}
+			m[k] = x
+		}
+		out[sel] = m
+	}
+	return out, nil
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParamOverridesExpand(t *testing.T) {
+	expand := func(s string) (string, error) {
+		if s == "${nope}" {
+			return "", fmt.Errorf("undefined variable ${nope}")
+		}
+		return strings.ReplaceAll(s, "${region}", "eu"), nil
+	}
+	o := ParamOverrides{"getUser": {"id": "${region}-1"}}
+	got, err := o.Expand(expand)
+	if err != nil || got["getUser"]["id"] != "eu-1" || o["getUser"]["id"] != "${region}-1" {
+		t.Errorf("overrides: %v, %v (input %v)", got, err, o)
+	}
+	if _, err := (ParamOverrides{"getUser": {"id": "${nope}"}}).Expand(expand); err == nil || !strings.Contains(err.Error(), "getUser.id") {
+		t.Errorf("want param in error, got %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is one RFC 6902 JSON Patch operation (add, remove or replace) turning document A
+// into document B. Path is a JSON Pointer (RFC 6901, "" is the whole document). Old is the
+// value of A that is removed or replaced; it is only for display and not part of RFC 6902.
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+	Old   interface{} `json:"old,omitempty"`
+}
+
+// MarshalJSON always writes value for add and replace, even when it is null.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	type plain PatchOp
+	if op.Op == "remove" || op.Value != nil {
+		return json.Marshal(plain(op))
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+		Old   interface{} `json:"old,omitempty"`
+	}{op.Op, op.Path, nil, op.Old})
+}
+
+// String renders op for text views: "replace /total: 10.5 -> 10", "add /items/2: {...}",
+// "remove /meta/host (was "a")".
+func (op PatchOp) String() string {
+	path := op.Path
+	if path == "" {
+		path = "/"
+	}
+	switch op.Op {
+	case "remove":
+		return "remove " + path + " (was " + compactJSON(op.Old) + ")"
+	case "replace":
+		return "replace " + path + ": " + compactJSON(op.Old) + " -> " + compactJSON(op.Value)
+	}
+	return op.Op + " " + path + ": " + compactJSON(op.Value)
+}
+
+// maxPatchValue bounds values rendered by PatchOp.String.
+const maxPatchValue = 120
+
+func compactJSON(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(raw) > maxPatchValue {
+		return string(raw[:maxPatchValue]) + "..."
+	}
+	return string(raw)
+}
+
+// DiffJSON diffs two decoded JSON documents of any type (objects, arrays, scalars, at the
+// root or nested) and returns the patch turning a into b. Arrays are compared by position:
+// shared indexes are diffed, extra elements of b are added, extra elements of a are removed
+// from the highest index down, so the ops can be applied in order.
+func DiffJSON(a, b interface{}) []PatchOp {
+	var res ABCompareResult
+	d := &responseDiffer{res: &res}
+	d.diff(a, b, "$", "")
+	return res.Patch
+}
+
+// escapePointer escapes a key for a JSON Pointer segment.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func (d *responseDiffer) op(op PatchOp) {
+	d.res.Patch = append(d.res.Patch, op)
+}
+
+// structure reports a structural difference at path (JSONPath) unless a rule ignores it; op
+// is its patch operation.
+func (d *responseDiffer) structure(path, diff string, op PatchOp) {
+	if rule, ok := d.rules.ignoredPath(path); ok {
+		d.ignore(diff, "ignorePaths "+rule)
+		return
+	}
+	d.res.BodyStructureDiff = append(d.res.BodyStructureDiff, diff)
+	d.op(op)
+}
+
+// diff walks a and b; path is the JSONPath used by rules and reports, ptr the JSON Pointer
+// of the patch.
+func (d *responseDiffer) diff(a, b interface{}, path, ptr string) {
+	if rule, ok := d.rules.ignoredPath(path); ok {
+		if !jsonEqual(a, b) {
+			d.ignore(path, "ignorePaths "+rule)
+		}
+		return
+	}
+	ta, tb := reflectType(a), reflectType(b)
+	if ta != tb {
+		d.res.BodyStructureDiff = append(d.res.BodyStructureDiff, path+" type: "+ta+" vs "+tb)
+		d.op(PatchOp{Op: "replace", Path: ptr, Value: b, Old: a})
+		return
+	}
+	switch va := a.(type) {
+	case map[string]interface{}:
+		d.object(va, b.(map[string]interface{}), path, ptr)
+	case []interface{}:
+		if u, ok := d.rules.unordered(path); ok {
+			d.unorderedArray(va, b.([]interface{}), path, ptr, u.Key)
+			return
+		}
+		d.array(va, b.([]interface{}), path, ptr)
+	default:
+		d.value(a, b, path, ptr)
+	}
+}
+
+func (d *responseDiffer) object(a, b map[string]interface{}, path, ptr string) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		subPath, subPtr := path+"."+k, ptr+"/"+escapePointer(k)
+		va, okA := a[k]
+		vb, okB := b[k]
+		switch {
+		case !okA:
+			d.structure(subPath, subPath+" only in B", PatchOp{Op: "add", Path: subPtr, Value: vb})
+		case !okB:
+			d.structure(subPath, subPath+" only in A", PatchOp{Op: "remove", Path: subPtr, Old: va})
+		default:
+			d.diff(va, vb, subPath, subPtr)
+		}
+	}
+}
+
+func (d *responseDiffer) array(a, b []interface{}, path, ptr string) {
+	if len(a) != len(b) {
+		d.res.BodyStructureDiff = append(d.res.BodyStructureDiff, path+" array len: "+strconv.Itoa(len(a))+" vs "+strconv.Itoa(len(b)))
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		d.diff(a[i], b[i], path+"["+strconv.Itoa(i)+"]", ptr+"/"+strconv.Itoa(i))
+	}
+	for i := len(a); i < len(b); i++ {
+		if _, ok := d.rules.ignoredPath(path + "[" + strconv.Itoa(i) + "]"); !ok {
+			d.op(PatchOp{Op: "add", Path: ptr + "/" + strconv.Itoa(i), Value: b[i]})
+		}
+	}
+	for i := len(a) - 1; i >= len(b); i-- {
+		if _, ok := d.rules.ignoredPath(path + "[" + strconv.Itoa(i) + "]"); !ok {
+			d.op(PatchOp{Op: "remove", Path: ptr + "/" + strconv.Itoa(i), Old: a[i]})
+		}
+	}
+}
+
+// unorderedArray pairs elements by their key field (or whole value) instead of position.
+// Paired elements are addressed as path[key=value]; in the patch, elements keep their index
+// in A, unpaired ones of A are removed (highest index first) and those of B appended.
+func (d *responseDiffer) unorderedArray(a, b []interface{}, path, ptr, key string) {
+	byKey := map[string][]interface{}{}
+	var order []string
+	for _, v := range b {
+		k := elementKey(v, key)
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = append(byKey[k], v)
+	}
+	var removed []int
+	for i, v := range a {
+		k := elementKey(v, key)
+		sub := path + "[" + k + "]"
+		if len(byKey[k]) == 0 {
+			if rule, ok := d.rules.ignoredPath(sub); ok {
+				d.ignore(sub+" only in A", "ignorePaths "+rule)
+				continue
+			}
+			d.res.BodyStructureDiff = append(d.res.BodyStructureDiff, sub+" only in A")
+			removed = append(removed, i)
+			continue
+		}
+		d.diff(v, byKey[k][0], sub, ptr+"/"+strconv.Itoa(i))
+		byKey[k] = byKey[k][1:]
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		d.op(PatchOp{Op: "remove", Path: ptr + "/" + strconv.Itoa(removed[i]), Old: a[removed[i]]})
+	}
+	for _, k := range order {
+		for _, v := range byKey[k] {
+			sub := path + "[" + k + "]"
+			d.structure(sub, sub+" only in B", PatchOp{Op: "add", Path: ptr + "/-", Value: v})
+		}
+	}
+}
+
+func elementKey(v interface{}, key string) string {
+	if m, ok := v.(map[string]interface{}); ok && key != "" {
+		if kv, ok := m[key]; ok {
+			return key + "=" + fmt.Sprint(kv)
+		}
+	}
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+// value compares two scalars (string, number, boolean or null), applying numeric tolerance
+// and normalizers. Other Go values, which encoding/json does not decode to, are compared as JSON.
+func (d *responseDiffer) value(a, b interface{}, path, ptr string) {
+	switch a.(type) {
+	case string, float64, bool, nil:
+		if a == b {
+			return
+		}
+	default:
+		if jsonEqual(a, b) {
+			return
+		}
+	}
+	if fa, ok := a.(float64); ok {
+		if fb, ok := b.(float64); ok && d.rules.NumericTolerance > 0 && math.Abs(fa-fb) <= d.rules.NumericTolerance {
+			d.ignore(path, "numericTolerance "+strconv.FormatFloat(d.rules.NumericTolerance, 'g', -1, 64))
+			return
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			na, ruleA := d.rules.normalize(sa)
+			nb, ruleB := d.rules.normalize(sb)
+			if na == nb {
+				d.ignore(path, "normalizer "+firstNonEmpty(ruleA, ruleB))
+				return
+			}
+		}
+	}
+	d.res.BodyValueDiff = append(d.res.BodyValueDiff, path)
+	d.op(PatchOp{Op: "replace", Path: ptr, Value: b, Old: a})
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ra, _ := json.Marshal(a)
+	rb, _ := json.Marshal(b)
+	return bytes.Equal(ra, rb)
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func reflectType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// applyPatch applies add/remove/replace ops as RFC 6902 defines them.
+func applyPatch(t *testing.T, doc interface{}, ops []PatchOp) interface{} {
+	t.Helper()
+	for _, op := range ops {
+		if op.Path == "" {
+			doc = op.Value
+			continue
+		}
+		segs := strings.Split(op.Path[1:], "/")
+		for i := range segs {
+			segs[i] = strings.ReplaceAll(strings.ReplaceAll(segs[i], "~1", "/"), "~0", "~")
+		}
+		doc = applyAt(t, doc, segs, op)
+	}
+	return doc
+}
+
+func applyAt(t *testing.T, node interface{}, segs []string, op PatchOp) interface{} {
+	key, last := segs[0], len(segs) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if !last {
+			n[key] = applyAt(t, n[key], segs[1:], op)
+		} else if op.Op == "remove" {
+			delete(n, key)
+		} else {
+			n[key] = op.Value
+		}
+		return n
+	case []interface{}:
+		if key == "-" {
+			return append(n, op.Value)
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil || i > len(n) {
+			t.Fatalf("%s: bad index %q", op, key)
+		}
+		switch {
+		case !last:
+			n[i] = applyAt(t, n[i], segs[1:], op)
+		case op.Op == "add":
+			n = append(n[:i], append([]interface{}{op.Value}, n[i:]...)...)
+		case op.Op == "remove":
+			n = append(n[:i], n[i+1:]...)
+		default:
+			n[i] = op.Value
+		}
+		return n
+	}
+	t.Fatalf("%s: cannot descend into %v", op, node)
+	return nil
+}
+
+func TestDiffJSON(t *testing.T) {
+	cases := []struct {
+		name, a, b string
+		want       []string
+	}{
+		{"root array of scalars", `[1, 2, 3]`, `[1, 5]`, []string{"replace /1: 2 -> 5", "remove /2 (was 3)"}},
+		{"root array grows", `["a"]`, `["a", "b", null]`, []string{"add /1: \"b\"", "add /2: null"}},
+		{"nested arrays of arrays", `{"m": [[1, 2], [3]]}`, `{"m": [[1, 2], [4, 5]]}`, []string{"replace /m/1/0: 3 -> 4", "add /m/1/1: 5"}},
+		{"root scalar", `"x"`, `"y"`, []string{`replace /: "x" -> "y"`}},
+		{"type change", `{"v": [1]}`, `{"v": {"k": 1}}`, []string{`replace /v: [1] -> {"k":1}`}},
+		{"pointer escaping", `{"a/b": 1, "c~d": true}`, `{"a/b": 2}`, []string{"replace /a~1b: 1 -> 2", "remove /c~0d (was true)"}},
+		{"null to value", `{"v": null}`, `{"v": 0, "w": {}}`, []string{"replace /v: null -> 0", "add /w: {}"}},
+		{"equal", `[{"a": [true, null]}]`, `[{"a": [true, null]}]`, nil},
+	}
+	for _, c := range cases {
+		a, b := decodeJSON(t, c.a), decodeJSON(t, c.b)
+		ops := DiffJSON(a, b)
+		var got []string
+		for _, op := range ops {
+			got = append(got, op.String())
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+		if patched := applyPatch(t, decodeJSON(t, c.a), ops); !jsonEqual(patched, b) {
+			t.Errorf("%s: patch does not turn A into B: %v", c.name, patched)
+		}
+	}
+}
+
+func TestPatchOpJSON(t *testing.T) {
+	raw, err := json.Marshal([]PatchOp{
+		{Op: "replace", Path: "/v", Value: nil, Old: 1.0},
+		{Op: "remove", Path: "/w", Old: "x"},
+		{Op: "add", Path: "/x", Value: []interface{}{1.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"replace","path":"/v","value":null,"old":1},{"op":"remove","path":"/w","old":"x"},{"op":"add","path":"/x","value":[1]}]`
+	if string(raw) != want {
+		t.Errorf("got %s\nwant %s", raw, want)
+	}
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOptions controls how LoadSpecs fetches remote specs.
+type LoadOptions struct {
+	// Headers are sent when fetching an http(s) spec (env headers, auth). They only go to the
+	// spec's own host, never to hosts of external $refs.
+	Headers map[string]string
+	Timeout time.Duration // per HTTP request; 0 means 30s
+}
+
+// LoadSpecs loads endpoints from source, which is one of the following. Swagger 2.0 and
+// OpenAPI 3.1 documents are converted to 3.0 first (see parseSpec).
+//   - a spec file; relative external $refs (./schemas/user.yaml#/User) are resolved against it
+//   - an http(s) URL such as http://host/v3/api-docs, fetched with opts.Headers
+//   - a directory; every top-level yaml/json file declaring "openapi"/"swagger" is loaded and the endpoints
+//     are merged into one catalogue (other files are treated as $ref fragments)
+//
+// Every Endpoint records its source in Endpoint.Spec.
+func LoadSpecs(source string, opts LoadOptions) ([]Endpoint, *openapi3.T, error) {
+	if isSpecURL(source) {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse spec url: %w", err)
+		}
+		loader := newSpecLoader(opts, u.Host)
+		data, err := loader.ReadFromURIFunc(loader, u)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetch openapi: %w", err)
+		}
+		doc, err := parseSpec(loader, data, u)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse openapi: %w", err)
+		}
+		return specEndpoints(loader, doc, source)
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file: %w", err)
+	}
+	if info.IsDir() {
+		return loadSpecDir(source, opts)
+	}
+	return loadSpecFile(source, opts)
+}
+
+func isSpecURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// newSpecLoader returns a loader that resolves external refs (files and URLs) and adds
+// opts.Headers to requests for host.
+func newSpecLoader(opts LoadOptions, host string) *openapi3.Loader {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: headerTransport{host: host, headers: opts.Headers, next: http.DefaultTransport},
+	}
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = openapi3.URIMapCache(openapi3.ReadFromURIs(openapi3.ReadFromHTTP(client), openapi3.ReadFromFile))
+	return loader
+}
+
+// headerTransport sets headers on requests to one host.
+type headerTransport struct {
+	host    string
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.host == "" || req.URL.Host != t.host || len(t.headers) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.next.RoundTrip(req)
+}
+
+func loadSpecFile(path string, opts LoadOptions) ([]Endpoint, *openapi3.T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file: %w", err)
+	}
+	loader := newSpecLoader(opts, "")
+	doc, err := parseSpec(loader, data, &url.URL{Path: filepath.ToSlash(path)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse openapi: %w", err)
+	}
+	return specEndpoints(loader, doc, path)
+}
+
+// loadSpecDir merges the specs in dir. The returned document carries the merged paths (the
+// operations of a path defined by several specs are merged per method) and an Info naming
+// the directory; a path+method defined by two specs is an error.
+func loadSpecDir(dir string, opts LoadOptions) ([]Endpoint, *openapi3.T, error) {
+	files, err := specFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no OpenAPI specs in %s", dir)
+	}
+	merged := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: filepath.Base(dir), Version: fmt.Sprintf("%d specs", len(files))},
+		Paths:   openapi3.NewPaths(),
+	}
+	var all []Endpoint
+	seen := map[string]string{}
+	for _, f := range files {
+		eps, doc, err := loadSpecFile(f, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		for _, ep := range eps {
+			key := ep.Method + " " + ep.Path
+			if prev, ok := seen[key]; ok {
+				return nil, nil, fmt.Errorf("%s defined in both %s and %s", key, filepath.Base(prev), filepath.Base(f))
+			}
+			seen[key] = f
+		}
+		for path, item := range doc.Paths.Map() {
+			prev := merged.Paths.Value(path)
+			if prev == nil {
+				merged.Paths.Set(path, item)
+				continue
+			}
+			// Specs sharing a path with different methods: merge their operations into a copy.
+			cp := *prev
+			for method, op := range item.Operations() {
+				cp.SetOperation(method, op)
+			}
+			merged.Paths.Set(path, &cp)
+		}
+		all = append(all, eps...)
+	}
+	return all, merged, nil
+}
+
+// specFiles lists the top-level yaml/json files of dir that declare "openapi" or "swagger", sorted by name.
+func specFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+	var out []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+		var head struct {
+			OpenAPI string `yaml:"openapi"`
+			Swagger string `yaml:"swagger"`
+		}
+		if yaml.Unmarshal(data, &head) == nil && (head.OpenAPI != "" || head.Swagger != "") {
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// specEndpoints validates doc and lists its path+method combinations.
+func specEndpoints(loader *openapi3.Loader, doc *openapi3.T, source string) ([]Endpoint, *openapi3.T, error) {
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, nil, fmt.Errorf("validate openapi: %w", err)
+	}
+	var endpoints []Endpoint
+	specServers := absoluteServers(doc.Servers, source)
+	for path, pathItem := range doc.Paths.Map() {
+		for method, op := range pathItem.Operations() {
+			servers := pathItem.Servers
+			if op.Servers != nil && len(*op.Servers) > 0 {
+				servers = *op.Servers
+			}
+			ep := Endpoint{
+				Path:           path,
+				Method:         strings.ToUpper(method),
+				OperationID:    op.OperationID,
+				Summary:        op.Summary,
+				Tags:           op.Tags,
+				Schema:         op,
+				PathItemParams: pathItem.Parameters,
+				Spec:           source,
+				Servers:        absoluteServers(servers, source),
+				SpecServers:    specServers,
+			}
+			if ep.Summary == "" {
+				ep.Summary = ep.OperationID
+			}
+			if op.Security != nil {
+				ep.Security = op.Security
+			} else if len(doc.Security) > 0 {
+				ep.Security = &doc.Security
+			}
+			if doc.Components != nil {
+				ep.SecuritySchemes = doc.Components.SecuritySchemes
+			}
+			endpoints = append(endpoints, ep)
+		}
+	}
+	return endpoints, doc, nil
+}
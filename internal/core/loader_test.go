@@ -0,0 +1,282 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const refSpec = `openapi: 3.0.3
+info: {title: users, version: "1"}
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "./schemas/user.yaml#/User"}
+`
+
+const userSchema = `User:
+  type: object
+  required: [id]
+  properties:
+    id: {type: integer}
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadSpecsResolvesExternalRefs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"users.yaml": refSpec, "schemas/user.yaml": userSchema})
+	path := filepath.Join(dir, "users.yaml")
+	eps, _, err := LoadOpenAPI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 1 || eps[0].Spec != path {
+		t.Fatalf("unexpected endpoints: %+v", eps)
+	}
+	s := eps[0].Schema.Responses.Value("200").Value.Content.Get("application/json").Schema.Value
+	if s == nil || s.Properties["id"] == nil {
+		t.Fatalf("external $ref not resolved: %+v", s)
+	}
+}
+
+func TestLoadSpecsDirectory(t *testing.T) {
+	orders := `openapi: 3.0.3
+info: {title: orders, version: "1"}
+paths:
+  /orders:
+    get:
+      responses: {"200": {description: ok}}
+`
+	dir := writeFiles(t, map[string]string{
+		"users.yaml":        refSpec,
+		"orders.json":       `{"openapi": "3.0.3", "info": {"title": "o", "version": "1"}, "paths": {"/orders/{id}": {"delete": {"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}], "responses": {"204": {"description": "gone"}}}}}}`,
+		"orders.yaml":       orders,
+		"schemas/user.yaml": userSchema,
+		"notes.yaml":        "owner: team-a\n",
+	})
+	eps, doc, err := LoadSpecs(dir, LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, ep := range eps {
+		got[ep.Method+" "+ep.Path] = filepath.Base(ep.Spec)
+	}
+	want := map[string]string{"GET /users/{id}": "users.yaml", "GET /orders": "orders.yaml", "DELETE /orders/{id}": "orders.json"}
+	if len(got) != len(want) {
+		t.Fatalf("endpoints = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s from %q, want %q", k, got[k], v)
+		}
+	}
+	if doc.Paths.Len() != 3 {
+		t.Errorf("merged doc has %d paths, want 3", doc.Paths.Len())
+	}
+
+	split := writeFiles(t, map[string]string{"a.yaml": orders, "b.yaml": strings.Replace(orders, "get:", "post:", 1)})
+	eps, doc, err = LoadSpecs(split, LoadOptions{})
+	if err != nil {
+		t.Fatalf("same path with different methods: %v", err)
+	}
+	if item := doc.Paths.Value("/orders"); len(eps) != 2 || item == nil || item.Get == nil || item.Post == nil {
+		t.Errorf("operations of a shared path not merged: %d endpoints, %+v", len(eps), item)
+	}
+
+	dup := writeFiles(t, map[string]string{"a.yaml": orders, "b.yaml": orders})
+	if _, _, err := LoadSpecs(dup, LoadOptions{}); err == nil || !strings.Contains(err.Error(), "GET /orders defined in both a.yaml and b.yaml") {
+		t.Errorf("expected duplicate endpoint error, got %v", err)
+	}
+}
+
+func TestLoadSpecsFromURLWithHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v3/api-docs":
+			w.Write([]byte(refSpec))
+		case "/v3/schemas/user.yaml":
+			w.Write([]byte(userSchema))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	if _, _, err := LoadSpecs(ts.URL+"/v3/api-docs", LoadOptions{}); err == nil {
+		t.Fatal("expected 401 without auth")
+	}
+	eps, _, err := LoadSpecs(ts.URL+"/v3/api-docs", LoadOptions{Headers: map[string]string{"Authorization": "Bearer t0k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 1 || eps[0].Spec != ts.URL+"/v3/api-docs" {
+		t.Fatalf("unexpected endpoints: %+v", eps)
+	}
+}
+
+func TestLoadSpecsSwagger2(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"legacy.yaml": `swagger: "2.0"
+info: {title: legacy, version: "1"}
+host: api.example.com
+basePath: /v1
+schemes: [https]
+consumes: [application/json]
+produces: [application/json]
+paths:
+  /pets/{id}:
+    parameters:
+      - {name: id, in: path, required: true, type: integer}
+    put:
+      operationId: putPet
+      parameters:
+        - {name: body, in: body, required: true, schema: {$ref: "#/definitions/Pet"}}
+      responses:
+        200:
+          description: ok
+          schema: {$ref: "#/definitions/Pet"}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+`})
+	eps, doc, err := LoadSpecs(dir, LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 1 || eps[0].OperationID != "putPet" || len(eps[0].PathItemParams) != 1 {
+		t.Fatalf("unexpected endpoints: %+v", eps)
+	}
+	if len(doc.Paths.Map()) != 1 {
+		t.Fatalf("merged doc paths: %v", doc.Paths.Map())
+	}
+	rs := BuildRequestSpec(eps[0], nil)
+	if string(rs.Body) != `{"name":"example"}` {
+		t.Errorf("body from converted requestBody = %s", rs.Body)
+	}
+	if r := RunDrift([]byte(`{"name": 1}`), eps[0].Schema, 200); r.OK {
+		t.Error("expected drift against converted response schema")
+	}
+}
+
+func TestLoadSpecsOpenAPI31(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"new.yaml": `openapi: 3.1.0
+info: {title: new, version: "1", summary: s, license: {name: MIT, identifier: MIT}}
+jsonSchemaDialect: https://spec.openapis.org/oas/3.1/dialect/base
+webhooks:
+  ping: {post: {responses: {"200": {description: ok}}}}
+paths:
+  /items:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "./item.yaml"}
+`,
+		"item.yaml": `type: object
+required: [id, kind]
+properties:
+  id: {type: integer, exclusiveMinimum: 0}
+  kind: {const: item}
+  note: {type: [string, "null"], examples: [hello]}
+  const: {type: string}
+`})
+	eps, _, err := LoadSpecs(filepath.Join(dir, "new.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 1 {
+		t.Fatalf("unexpected endpoints: %+v", eps)
+	}
+	op := eps[0].Schema
+	if r := RunDrift([]byte(`{"id": 1, "kind": "item", "note": null, "const": "x"}`), op, 200); !r.OK {
+		t.Errorf("valid 3.1 body drifted: %+v", r.Findings)
+	}
+	r := RunDrift([]byte(`{"id": 0, "kind": "other"}`), op, 200)
+	types := findingTypes(r)
+	if len(types[DriftRangeViolation]) != 1 || len(types[DriftEnumViolation]) != 1 {
+		t.Errorf("expected range and enum findings from exclusiveMinimum/const, got %+v", r.Findings)
+	}
+}
+
+func TestDowngrade31WalksOnlySchemas(t *testing.T) {
+	raw, err := yamlToJSON([]byte(`openapi: 3.1.0
+info: {title: t, version: "1"}
+paths:
+  /x:
+    get:
+      parameters:
+        - {name: q, in: query, schema: {type: [integer, "null"], exclusiveMinimum: 0}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/const"}
+              example: {type: [a, "null"], const: 1}
+components:
+  schemas:
+    const:
+      type: object
+      properties:
+        examples: {type: array, items: {type: [string, "null"]}, default: {exclusiveMinimum: 5}}
+        mode: {enum: [{const: x}]}
+    examples: {type: string}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		t.Fatal(err)
+	}
+	downgrade31(root)
+	got, _ := json.Marshal(root)
+	for _, want := range []string{
+		`"schema":{"exclusiveMinimum":true,"minimum":0,"nullable":true,"type":"integer"}`,
+		`"example":{"const":1,"type":["a","null"]}`,
+		`"items":{"nullable":true,"type":"string"}`,
+		`"default":{"exclusiveMinimum":5}`,
+		`"mode":{"enum":[{"const":"x"}]}`,
+		`"examples":{"type":"string"}`,
+		`"const":{"properties"`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("downgraded document lacks %s:\n%s", want, got)
+		}
+	}
+}
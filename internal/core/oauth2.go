@@ -0,0 +1,229 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"lazytest/internal/secrets"
+)
+
+// OAuth2 grants of OAuth2Config.Grant.
+const (
+	OAuth2ClientCredentials = "client_credentials"
+	OAuth2Password          = "password"
+)
+
+// OAuth2Config is an OAuth2 token request: the client credentials grant or the resource owner
+// password grant (RFC 6749 4.4 and 4.3).
+type OAuth2Config struct {
+	Grant        string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Username     string // password grant
+	Password     string
+	Scopes       []string
+	Audience     string // sent as audience, as Auth0, Okta and others expect
+	// ClientAuth is "basic" (default, client id and secret as HTTP Basic auth) or "post"
+	// (in the form body).
+	ClientAuth string
+}
+
+// tokenExpiryDelta refreshes tokens this long before they expire (at most half their lifetime).
+const tokenExpiryDelta = 30 * time.Second
+
+// OAuth2Source fetches access tokens for one OAuth2Config and caches them until they expire.
+// A refresh token, when issued, is used first; if it is rejected the grant is repeated.
+type OAuth2Source struct {
+	cfg    OAuth2Config
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	access  string
+	refresh string
+	expiry  time.Time // zero: the server gave no lifetime, the token is kept
+	handle  string    // the first token issued, see Handle
+}
+
+var oauth2Sources = struct {
+	sync.Mutex
+	byConfig map[string]*OAuth2Source
+	byToken  map[string]*OAuth2Source // handle and current token of each source (FreshAuthHeaders)
+}{byConfig: map[string]*OAuth2Source{}, byToken: map[string]*OAuth2Source{}}
+
+// OAuth2TokenSource returns the process-wide source of cfg, so every run and request using
+// the same profile shares one cached token.
+func OAuth2TokenSource(cfg OAuth2Config) *OAuth2Source {
+	key, _ := json.Marshal(cfg)
+	oauth2Sources.Lock()
+	defer oauth2Sources.Unlock()
+	if s, ok := oauth2Sources.byConfig[string(key)]; ok {
+		return s
+	}
+	s := &OAuth2Source{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}, now: time.Now}
+	oauth2Sources.byConfig[string(key)] = s
+	return s
+}
+
+// Token returns a valid access token, fetching a new one when none is cached or it is about
+// to expire.
+func (s *OAuth2Source) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.access != "" && (s.expiry.IsZero() || s.now().Before(s.expiry)) {
+		return s.access, nil
+	}
+	if s.refresh != "" {
+		err := s.fetch(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {s.refresh}})
+		if err == nil {
+			return s.access, nil
+		}
+		s.refresh = ""
+	}
+	form := url.Values{"grant_type": {s.cfg.Grant}}
+	switch s.cfg.Grant {
+	case OAuth2ClientCredentials:
+	case OAuth2Password:
+		form.Set("username", s.cfg.Username)
+		form.Set("password", s.cfg.Password)
+	default:
+		return "", fmt.Errorf("oauth2: unsupported grant %q", s.cfg.Grant)
+	}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+	if err := s.fetch(form); err != nil {
+		return "", err
+	}
+	return s.access, nil
+}
+
+// Handle returns the token that identifies s in auth headers: the first token it issued.
+// Profiles resolve to the handle rather than the current token so headers built once per run
+// (or kept in an explorer request) stay recognizable after any number of refreshes;
+// FreshAuthHeaders swaps it for the current token when a request is sent.
+func (s *OAuth2Source) Handle() (string, error) {
+	if _, err := s.Token(); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handle, nil
+}
+
+type tokenResponse struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	RefreshToken     string      `json:"refresh_token"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+// fetch posts form to the token endpoint and caches the token it returns.
+func (s *OAuth2Source) fetch(form url.Values) error {
+	if s.cfg.TokenURL == "" {
+		return fmt.Errorf("oauth2: tokenURL is required")
+	}
+	basic := s.cfg.ClientAuth != "post" && s.cfg.ClientSecret != ""
+	if !basic {
+		form.Set("client_id", s.cfg.ClientID)
+		if s.cfg.ClientSecret != "" {
+			form.Set("client_secret", s.cfg.ClientSecret)
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("oauth2 token: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basic {
+		// RFC 6749 2.3.1: id and secret are form-encoded before Basic encoding.
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var tr tokenResponse
+	jsonErr := json.Unmarshal(body, &tr)
+	if resp.StatusCode/100 != 2 || tr.Error != "" {
+		msg := strings.TrimSpace(tr.Error + " " + tr.ErrorDescription)
+		if msg == "" {
+			msg = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("oauth2 token %s: %s: %s", s.cfg.TokenURL, resp.Status, secrets.Redact(msg))
+	}
+	if jsonErr != nil || tr.AccessToken == "" {
+		return fmt.Errorf("oauth2 token %s: response has no access_token", s.cfg.TokenURL)
+	}
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return fmt.Errorf("oauth2 token %s: unsupported token_type %q", s.cfg.TokenURL, tr.TokenType)
+	}
+	oauth2Sources.Lock()
+	if s.access != "" && s.access != s.handle {
+		delete(oauth2Sources.byToken, s.access)
+	}
+	oauth2Sources.byToken[tr.AccessToken] = s
+	oauth2Sources.Unlock()
+	s.access = tr.AccessToken
+	if s.handle == "" {
+		s.handle = tr.AccessToken
+	}
+	if tr.RefreshToken != "" {
+		s.refresh = tr.RefreshToken
+		secrets.Remember(tr.RefreshToken)
+	}
+	s.expiry = time.Time{}
+	if secs, err := tr.ExpiresIn.Int64(); err == nil && secs > 0 {
+		lifetime := time.Duration(secs) * time.Second
+		s.expiry = s.now().Add(lifetime - min(tokenExpiryDelta, lifetime/2))
+	}
+	secrets.Remember(tr.AccessToken)
+	return nil
+}
+
+// FreshAuthHeaders returns h with every Bearer token of an OAuth2Source (its handle or current
+// token) replaced by that source's current token, refreshing it when it expired. Headers are built once per run
+// (or kept in an explorer request), so this is what keeps long runs authenticated; h itself
+// is not modified.
+func FreshAuthHeaders(h map[string]string) (map[string]string, error) {
+	out, copied := h, false
+	for k, v := range h {
+		tok, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok {
+			continue
+		}
+		oauth2Sources.Lock()
+		src := oauth2Sources.byToken[tok]
+		oauth2Sources.Unlock()
+		if src == nil {
+			continue
+		}
+		fresh, err := src.Token()
+		if err != nil {
+			return nil, err
+		}
+		if fresh == tok {
+			continue
+		}
+		if !copied {
+			out, copied = copyStrings(h), true
+		}
+		out[k] = "Bearer " + fresh
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer is a stub OAuth2 token endpoint issuing tok-1, tok-2, ... and recording the
+// forms it received.
+type tokenServer struct {
+	*httptest.Server
+	mu            sync.Mutex
+	forms         []map[string]string
+	issued        int
+	rejectRefresh bool
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	ts := &tokenServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form := map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		if id, secret, ok := r.BasicAuth(); ok {
+			form["basic"] = id + ":" + secret
+		}
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		ts.forms = append(ts.forms, form)
+		if form["grant_type"] == "refresh_token" && ts.rejectRefresh {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"refresh token expired"}`))
+			return
+		}
+		if form["grant_type"] == "password" && form["password"] != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		ts.issued++
+		n := strconv.Itoa(ts.issued)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tok-` + n + `","token_type":"Bearer","expires_in":"3600","refresh_token":"ref-` + n + `"}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *tokenServer) form(i int) map[string]string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.forms[i]
+}
+
+func TestOAuth2SourceCachesAndRefreshes(t *testing.T) {
+	ts := newTokenServer(t)
+	src := OAuth2TokenSource(OAuth2Config{
+		Grant: OAuth2ClientCredentials, TokenURL: ts.URL, ClientID: "cli ent", ClientSecret: "s3cret",
+		Scopes: []string{"read", "write"}, Audience: "https://api",
+	})
+	if again := OAuth2TokenSource(src.cfg); again != src {
+		t.Fatal("the same config must share one source")
+	}
+	now := time.Now()
+	src.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		if tok, err := src.Token(); err != nil || tok != "tok-1" {
+			t.Fatalf("Token: %q, %v", tok, err)
+		}
+	}
+	if len(ts.forms) != 1 {
+		t.Fatalf("token fetched %d times, want once (cached)", len(ts.forms))
+	}
+	f := ts.form(0)
+	if f["grant_type"] != "client_credentials" || f["scope"] != "read write" || f["audience"] != "https://api" || f["basic"] != "cli+ent:s3cret" || f["client_secret"] != "" {
+		t.Errorf("token request: %v", f)
+	}
+
+	// Headers built with tok-1 get the refreshed token once it expired.
+	stale := map[string]string{"Authorization": "Bearer tok-1", "X-Other": "v"}
+	now = now.Add(time.Hour)
+	fresh, err := FreshAuthHeaders(stale)
+	if err != nil || fresh["Authorization"] != "Bearer tok-2" || fresh["X-Other"] != "v" || stale["Authorization"] != "Bearer tok-1" {
+		t.Fatalf("FreshAuthHeaders: %v, %v (input %v)", fresh, err, stale)
+	}
+	if f := ts.form(1); f["grant_type"] != "refresh_token" || f["refresh_token"] != "ref-1" {
+		t.Errorf("expired token must be refreshed with the refresh token: %v", f)
+	}
+
+	ts.rejectRefresh = true
+	now = now.Add(time.Hour)
+	if tok, err := src.Token(); err != nil || tok != "tok-3" {
+		t.Fatalf("rejected refresh must fall back to the grant: %q, %v", tok, err)
+	}
+	if f := ts.form(3); f["grant_type"] != "client_credentials" {
+		t.Errorf("fallback request: %v", f)
+	}
+	if h, _ := FreshAuthHeaders(map[string]string{"Authorization": "Bearer not-issued"}); h["Authorization"] != "Bearer not-issued" {
+		t.Errorf("foreign tokens must be kept: %v", h)
+	}
+
+	// Only the handle (the first token) and the current token stay registered.
+	if h, err := src.Handle(); err != nil || h != "tok-1" {
+		t.Errorf("Handle: %q, %v", h, err)
+	}
+	if h, _ := FreshAuthHeaders(map[string]string{"Authorization": "Bearer tok-1"}); h["Authorization"] != "Bearer tok-3" {
+		t.Errorf("handle must map to the current token: %v", h)
+	}
+	oauth2Sources.Lock()
+	var registered []string
+	for tok, s := range oauth2Sources.byToken {
+		if s == src {
+			registered = append(registered, tok)
+		}
+	}
+	oauth2Sources.Unlock()
+	if len(registered) != 2 {
+		t.Errorf("registered tokens %v, want the handle and the current token", registered)
+	}
+}
+
+func TestOAuth2PasswordGrant(t *testing.T) {
+	ts := newTokenServer(t)
+	src := OAuth2TokenSource(OAuth2Config{Grant: OAuth2Password, TokenURL: ts.URL, ClientID: "app", ClientSecret: "cs", ClientAuth: "post", Username: "ann", Password: "pw"})
+	if tok, err := src.Token(); err != nil || tok != "tok-1" {
+		t.Fatalf("Token: %q, %v", tok, err)
+	}
+	if f := ts.form(0); f["grant_type"] != "password" || f["username"] != "ann" || f["client_id"] != "app" || f["client_secret"] != "cs" || f["basic"] != "" {
+		t.Errorf("token request: %v", f)
+	}
+	bad := OAuth2TokenSource(OAuth2Config{Grant: OAuth2Password, TokenURL: ts.URL, ClientID: "app", Username: "ann", Password: "wrong"})
+	if _, err := bad.Token(); err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("want token endpoint error, got %v", err)
+	}
+}
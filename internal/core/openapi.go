@@ -2,10 +2,7 @@
 package core
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/url"
-	"os"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -19,100 +16,63 @@ type Endpoint struct {
 	Summary     string
 	Tags        []string // from operation.tags
 	Schema      *openapi3.Operation
+	// PathItemParams are parameters declared on the path item and shared by all its operations.
+	PathItemParams openapi3.Parameters
+	// Spec is the file or URL the endpoint was loaded from (one of several in a spec directory).
+	Spec string
+	// Servers are declared on the operation or, failing that, its path item; they override
+	// SpecServers, the root servers of the spec (see ResolveBaseURL).
+	Servers     openapi3.Servers
+	SpecServers openapi3.Servers
+	// Security is the operation's security requirement list, else the spec's root one; nil when
+	// neither declares any, empty for an explicitly public operation (security: []).
+	Security *openapi3.SecurityRequirements
+	// SecuritySchemes are the components.securitySchemes of the endpoint's spec.
+	SecuritySchemes openapi3.SecuritySchemes
 }
 
-// LoadOpenAPI reads openapi.yaml/json and returns all path+method combinations.
+// LoadOpenAPI loads a spec file, URL or directory and returns all path+method combinations.
+// See LoadSpecs for the sources and for passing headers.
 func LoadOpenAPI(path string) ([]Endpoint, *openapi3.T, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, nil, fmt.Errorf("read file: %w", err)
-	}
-	loader := openapi3.NewLoader()
-	doc, err := loader.LoadFromData(data)
-	if err != nil {
-		return nil, nil, fmt.Errorf("parse openapi: %w", err)
-	}
-	if err := doc.Validate(loader.Context); err != nil {
-		return nil, nil, fmt.Errorf("validate openapi: %w", err)
-	}
-	var endpoints []Endpoint
-	for path, pathItem := range doc.Paths.Map() {
-		for method, op := range pathItem.Operations() {
-			ep := Endpoint{
-				Path:        path,
-				Method:      strings.ToUpper(method),
-				OperationID: op.OperationID,
-				Summary:     op.Summary,
-				Tags:        op.Tags,
-				Schema:      op,
-			}
-			if ep.Summary == "" {
-				ep.Summary = ep.OperationID
-			}
-			endpoints = append(endpoints, ep)
+	return LoadSpecs(path, LoadOptions{})
+}
+
+// FilterEndpoints keeps endpoints having any of tags and any of methods; empty lists match all.
+func FilterEndpoints(endpoints []Endpoint, tags, methods []string) []Endpoint {
+	var out []Endpoint
+	for _, ep := range endpoints {
+		if len(tags) > 0 && !anyTag(ep.Tags, tags) {
+			continue
 		}
+		if len(methods) > 0 && !sliceContains(upper(methods), strings.ToUpper(ep.Method)) {
+			continue
+		}
+		out = append(out, ep)
 	}
-	return endpoints, doc, nil
+	return out
 }
 
-// ExampleBody generates request body from OpenAPI request body schema.
-// Uses schema.example if present; otherwise simple faker: string->"example", number->1, bool->true, array->[elem], object->fields.
-func ExampleBody(op *openapi3.Operation) ([]byte, error) {
-	if op.RequestBody == nil || op.RequestBody.Value == nil {
-		return nil, nil
-	}
-	content := op.RequestBody.Value.Content.Get("application/json")
-	if content == nil {
-		return nil, nil
-	}
-	if content.Schema == nil || content.Schema.Value == nil {
-		return nil, nil
-	}
-	// Prefer example from schema
-	if content.Example != nil {
-		return json.Marshal(content.Example)
+func anyTag(have, want []string) bool {
+	for _, w := range want {
+		if sliceContains(have, w) {
+			return true
+		}
 	}
-	v := exampleFromSchema(content.Schema.Value)
-	return json.Marshal(v)
+	return false
 }
 
-func exampleFromSchema(s *openapi3.Schema) interface{} {
-	if s.Example != nil {
-		return s.Example
-	}
-	t := ""
-	if s.Type != nil && len(s.Type.Slice()) > 0 {
-		t = s.Type.Slice()[0]
-	}
-	switch t {
-	case "string":
-		if len(s.Enum) > 0 {
-			return s.Enum[0]
-		}
-		return "example"
-	case "number", "integer":
-		return 1
-	case "boolean":
-		return true
-	case "array":
-		var elem interface{} = "item"
-		if s.Items != nil && s.Items.Value != nil {
-			elem = exampleFromSchema(s.Items.Value)
-		}
-		return []interface{}{elem}
-	case "object":
-		m := make(map[string]interface{})
-		for name, prop := range s.Properties {
-			if prop != nil && prop.Value != nil {
-				m[name] = exampleFromSchema(prop.Value)
-			} else {
-				m[name] = "example"
-			}
-		}
-		return m
-	default:
-		return "example"
+func upper(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.ToUpper(strings.TrimSpace(v))
 	}
+	return out
+}
+
+// ExampleBody generates request body from OpenAPI request body schema.
+// It uses a generator with seed 0; see ExampleGenerator for seeded or named examples.
+func ExampleBody(op *openapi3.Operation) ([]byte, error) {
+	return NewExampleGenerator(0).Body(op)
 }
 
 // BuildURL concatenates baseURL and path, resolving path params if needed.
@@ -127,6 +87,13 @@ func BuildURL(baseURL, path string, pathParams map[string]string) (string, error
 	if !strings.HasPrefix(path, "/") {
 		path = "/" + path
 	}
-	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	// Param values are already escaped; keep that form as RawPath so String does not escape
+	// them again (and an escaped "/" stays part of the value).
+	raw := strings.TrimSuffix(u.EscapedPath(), "/") + path
+	unescaped, err := url.PathUnescape(raw)
+	if err != nil {
+		return "", err
+	}
+	u.Path, u.RawPath = unescaped, raw
 	return u.String(), nil
 }
@@ -0,0 +1,17 @@
+package core
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestBuildURLEscapesPathParamsOnce(t *testing.T) {
+	got, err := BuildURL("https://api.example.com/v1/", "/users/{id}/files", map[string]string{"id": "a b/c"})
+	if err != nil || got != "https://api.example.com/v1/users/a%20b%2Fc/files" {
+		t.Fatalf("BuildURL: %s, %v", got, err)
+	}
+	rs := RequestSpec{Path: "/users/{id}", PathParams: map[string]string{"id": "a b/c"}, Query: url.Values{"q": {"x y"}}}
+	if got, err := rs.URL("http://localhost:8080"); err != nil || got != "http://localhost:8080/users/a%20b%2Fc?q=x+y" {
+		t.Errorf("URL: %s, %v", got, err)
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ParamOverrides maps an endpoint selector to pinned parameter values.
+// Selectors are matched from least to most specific, later ones win:
+// "*" (every endpoint), "/users/{id}", "GET /users/{id}", "getUser" (operationId).
+type ParamOverrides map[string]map[string]string
+
+// ForEndpoint flattens the overrides that apply to ep into one name->value map.
+func (o ParamOverrides) ForEndpoint(ep Endpoint) map[string]string {
+	out := map[string]string{}
+	if len(o) == 0 {
+		return out
+	}
+	keys := []string{"*", ep.Path, strings.ToUpper(ep.Method) + " " + ep.Path}
+	if ep.OperationID != "" {
+		keys = append(keys, ep.OperationID)
+	}
+	for _, k := range keys {
+		for name, v := range o[k] {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+// MergeParamOverrides layers several override sets; later layers win per selector+name.
+func MergeParamOverrides(layers ...ParamOverrides) ParamOverrides {
+	out := ParamOverrides{}
+	for _, l := range layers {
+		for sel, vals := range l {
+			if out[sel] == nil {
+				out[sel] = map[string]string{}
+			}
+			for k, v := range vals {
+				out[sel][k] = v
+			}
+		}
+	}
+	return out
+}
+
+// ResolvePathParams returns a value for every path parameter of ep.
+// Pinned overrides win; otherwise the value comes from the parameter definition
+// (example, examples, enum, default) or is generated from its schema.
+func ResolvePathParams(ep Endpoint, overrides ParamOverrides) map[string]string {
+	return resolvePathParams(ep, overrides, NewExampleGenerator(0))
+}
+
+func resolvePathParams(ep Endpoint, overrides ParamOverrides, g *ExampleGenerator) map[string]string {
+	pinned := overrides.ForEndpoint(ep)
+	out := map[string]string{}
+	for _, p := range ep.Params() {
+		if p.In != openapi3.ParameterInPath {
+			continue
+		}
+		if v, ok := pinned[p.Name]; ok {
+			out[p.Name] = v
+			continue
+		}
+		out[p.Name] = paramString(paramRaw(p, g))
+	}
+	// Templated segments without a declared parameter still need a value.
+	for _, name := range templateNames(ep.Path) {
+		if _, ok := out[name]; ok {
+			continue
+		}
+		if v, ok := pinned[name]; ok {
+			out[name] = v
+		} else {
+			out[name] = "1"
+		}
+	}
+	return out
+}
+
+// Params returns the effective parameters of ep: path-item level parameters
+// overridden by operation level ones with the same name and location.
+func (ep Endpoint) Params() []*openapi3.Parameter {
+	var out []*openapi3.Parameter
+	seen := map[string]int{}
+	add := func(refs openapi3.Parameters) {
+		for _, ref := range refs {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			key := ref.Value.In + ":" + ref.Value.Name
+			if i, ok := seen[key]; ok {
+				out[i] = ref.Value
+				continue
+			}
+			seen[key] = len(out)
+			out = append(out, ref.Value)
+		}
+	}
+	add(ep.PathItemParams)
+	if ep.Schema != nil {
+		add(ep.Schema.Parameters)
+	}
+	return out
+}
+
+// ParamValue picks a representative string value for one parameter.
+func ParamValue(p *openapi3.Parameter) string {
+	return paramString(paramRaw(p, NewExampleGenerator(0)))
+}
+
+// paramRaw picks the parameter value before serialization: the example selected by
+// g.ExampleName, example, examples (first by name), then a value generated by g from the schema.
+func paramRaw(p *openapi3.Parameter, g *ExampleGenerator) interface{} {
+	if ex, ok := g.namedExample(p.Examples, true); ok {
+		return ex
+	}
+	if p.Example != nil {
+		return p.Example
+	}
+	if ex, ok := g.namedExample(p.Examples, false); ok {
+		return ex
+	}
+	if p.Schema == nil || p.Schema.Value == nil {
+		return "example"
+	}
+	return g.Value(p.Schema.Value)
+}
+
+func paramString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(x)
+	case bool:
+		return strconv.FormatBool(x)
+	case []interface{}:
+		parts := make([]string, 0, len(x))
+		for _, e := range x {
+			parts = append(parts, paramString(e))
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+func templateNames(path string) []string {
+	var names []string
+	for {
+		i := strings.Index(path, "{")
+		if i < 0 {
+			return names
+		}
+		j := strings.Index(path[i:], "}")
+		if j < 0 {
+			return names
+		}
+		names = append(names, path[i+1:i+j])
+		path = path[i+j+1:]
+	}
+}
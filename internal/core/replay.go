@@ -0,0 +1,362 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReplayRequest is one recorded request of a traffic capture.
+type ReplayRequest struct {
+	Method  string
+	Path    string // URL path as recorded, without scheme and host
+	Query   url.Values
+	Headers map[string]string
+	Body    []byte
+}
+
+// Target is the recorded path with its query string.
+func (r ReplayRequest) Target() string {
+	if len(r.Query) == 0 {
+		return r.Path
+	}
+	return r.Path + "?" + r.Query.Encode()
+}
+
+// LoadCapture reads recorded traffic from a HAR file or from a JSON Lines capture with one
+// request per line:
+//
+//	{"method": "GET", "url": "https://api.example.com/users/7?expand=1", "headers": {"Accept": "application/json"}}
+//	{"method": "POST", "path": "/orders", "body": {"sku": "A1", "qty": 2}}
+//
+// url may be absolute or a path; body is a JSON value or a string holding the raw body.
+// Headers that belong to the recorded connection or carry its credentials (Host, Cookie,
+// Authorization, Content-Length, ...) are dropped: each side sends its own.
+func LoadCapture(path string) ([]ReplayRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har harFile
+	if json.Unmarshal(data, &har) == nil && har.Log != nil {
+		reqs := make([]ReplayRequest, 0, len(har.Log.Entries))
+		for i, e := range har.Log.Entries {
+			headers := map[string]string{}
+			for _, h := range e.Request.Headers {
+				headers[h.Name] = h.Value
+			}
+			var body []byte
+			if e.Request.PostData != nil {
+				body = []byte(e.Request.PostData.Text)
+			}
+			r, err := newReplayRequest(e.Request.Method, e.Request.URL, headers, body)
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %w", path, i, err)
+			}
+			reqs = append(reqs, r)
+		}
+		return reqs, nil
+	}
+	var reqs []ReplayRequest
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64<<10), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var rec captureLine
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		target := rec.URL
+		if target == "" {
+			target = rec.Path
+		}
+		r, err := newReplayRequest(rec.Method, target, rec.Headers, rec.rawBody())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		reqs = append(reqs, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return reqs, nil
+}
+
+type harFile struct {
+	Log *struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type captureLine struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// rawBody is the string content of a string body, else the JSON value itself.
+func (c captureLine) rawBody() []byte {
+	if len(c.Body) == 0 || string(c.Body) == "null" {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(c.Body, &s) == nil {
+		return []byte(s)
+	}
+	return c.Body
+}
+
+func newReplayRequest(method, target string, headers map[string]string, body []byte) (ReplayRequest, error) {
+	if method == "" || target == "" {
+		return ReplayRequest{}, fmt.Errorf("method and url are required")
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return ReplayRequest{}, err
+	}
+	r := ReplayRequest{Method: strings.ToUpper(method), Path: u.Path, Query: u.Query(), Headers: map[string]string{}, Body: body}
+	if r.Path == "" {
+		r.Path = "/"
+	}
+	for k, v := range headers {
+		if !droppedReplayHeader(k) {
+			r.Headers[k] = v
+		}
+	}
+	return r, nil
+}
+
+// droppedReplayHeader reports headers of the recorded connection or session that must not be
+// replayed. Accept-Encoding is dropped so bodies are compared decompressed.
+func droppedReplayHeader(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, ":") || strings.HasPrefix(name, "proxy-") {
+		return true
+	}
+	switch name {
+	case "host", "content-length", "connection", "keep-alive", "transfer-encoding", "te", "upgrade",
+		"accept-encoding", "authorization", "cookie":
+		return true
+	}
+	return false
+}
+
+// MatchEndpoint finds the operation of endpoints that serves method and the concrete path.
+// Literal segments beat templated ones (/users/me over /users/{id}); when nothing matches
+// the whole path, the longest match of its end is used, so a recorded base path prefix
+// (/api/v1/users/7 for /users/{id}) does not matter.
+func MatchEndpoint(endpoints []Endpoint, method, path string) (Endpoint, bool) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestScore := -1, -1
+	for i, ep := range endpoints {
+		if !strings.EqualFold(ep.Method, method) {
+			continue
+		}
+		tmpl := strings.Split(strings.Trim(ep.Path, "/"), "/")
+		if len(tmpl) > len(segs) {
+			continue
+		}
+		score := 0
+		for j, t := range tmpl {
+			s := segs[len(segs)-len(tmpl)+j]
+			switch {
+			case strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") && s != "":
+				score++
+			case t == s:
+				score += 2
+			default:
+				score = -1
+			}
+			if score < 0 {
+				break
+			}
+		}
+		if score < 0 {
+			continue
+		}
+		// Matching the whole path beats any suffix match.
+		if len(tmpl) == len(segs) {
+			score += 4 * len(segs)
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return Endpoint{}, false
+	}
+	return endpoints[best], true
+}
+
+// RunReplayCompare replays every recorded request on both sides of cfg and diffs the
+// responses like RunABCompare. Each request is matched to its spec operation (MatchEndpoint)
+// for security schemes, compare rules and grouping; unmatched requests keep their recorded
+// path and use the sides' default auth. When a side's base URL path already starts the
+// recorded path it is not added twice. The rate limit counts recorded requests; results
+// keep their order.
+func RunReplayCompare(ctx context.Context, cfg ABCompareConfig, endpoints []Endpoint, reqs []ReplayRequest, onResult func(done, total int, r ABCompareResult)) []ABCompareResult {
+	pool := SmokeConfig{Timeout: cfg.Timeout, Workers: cfg.Workers, RateLimitRPS: cfg.RateLimitRPS}
+	results := make([]ABCompareResult, len(reqs))
+	var mu sync.Mutex
+	done := 0
+	runPool(ctx, &pool, len(reqs), func(i int) {
+		rec := reqs[i]
+		ep, ok := MatchEndpoint(endpoints, rec.Method, rec.Path)
+		if !ok {
+			ep = Endpoint{Method: rec.Method, Path: rec.Path}
+		}
+		rs := RequestSpec{
+			Method:  rec.Method,
+			Path:    rec.Path,
+			Query:   url.Values{},
+			Headers: copyStrings(rec.Headers),
+			Cookies: map[string]string{},
+			Body:    rec.Body,
+		}
+		for k, v := range rec.Query {
+			rs.Query[k] = append([]string(nil), v...)
+		}
+		r := compareRequest(ep, rs, replaySide(cfg.A, rec.Path), replaySide(cfg.B, rec.Path), cfg.Rules.ForEndpoint(ep), pool.Timeout)
+		r.Request = rec.Target()
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = r
+		done++
+		if onResult != nil {
+			onResult(done, len(reqs), r)
+		}
+	})
+	return results
+}
+
+// replaySide drops the base URL path of side when the recorded path already carries it.
+func replaySide(side CompareSide, path string) CompareSide {
+	u, err := url.Parse(side.BaseURL)
+	if err != nil {
+		return side
+	}
+	prefix := strings.TrimSuffix(u.Path, "/")
+	if prefix == "" || (path != prefix && !strings.HasPrefix(path, prefix+"/")) {
+		return side
+	}
+	u.Path, u.RawPath = "", ""
+	side.BaseURL = u.String()
+	return side
+}
+
+// CompareGroup aggregates the compare results of one endpoint, e.g. all replayed requests
+// that matched it.
+type CompareGroup struct {
+	Method   string
+	Path     string
+	Requests int
+	Same     int
+	Differ   int
+	Errors   int // a side did not answer
+	// Diffs are the distinct differences, most frequent first.
+	Diffs []DiffCount `json:",omitempty"`
+}
+
+// DiffCount is a difference and the number of results that reported it.
+type DiffCount struct {
+	Diff  string
+	Count int
+}
+
+// maxGroupDiffs bounds the distinct differences kept per CompareGroup.
+const maxGroupDiffs = 20
+
+// GroupCompareResults groups results by method and path. Groups with the most regressions
+// (differences and errors) come first, then by path and method.
+func GroupCompareResults(results []ABCompareResult) []CompareGroup {
+	idx := map[string]int{}
+	var groups []CompareGroup
+	counts := map[string]map[string]int{}
+	for _, r := range results {
+		key := coverageKey(r.Method, r.Path)
+		i, ok := idx[key]
+		if !ok {
+			i = len(groups)
+			idx[key] = i
+			groups = append(groups, CompareGroup{Method: strings.ToUpper(r.Method), Path: r.Path})
+			counts[key] = map[string]int{}
+		}
+		g := &groups[i]
+		g.Requests++
+		switch {
+		case r.ErrA != "" || r.ErrB != "":
+			g.Errors++
+		case r.Differs():
+			g.Differ++
+		default:
+			g.Same++
+		}
+		var diffs []string
+		if r.ErrA != "" {
+			diffs = append(diffs, "A error: "+r.ErrA)
+		}
+		if r.ErrB != "" {
+			diffs = append(diffs, "B error: "+r.ErrB)
+		}
+		diffs = append(diffs, r.HeadersDiff...)
+		for _, d := range r.BodyStructureDiff {
+			diffs = append(diffs, "[struct] "+d)
+		}
+		for _, d := range r.BodyValueDiff {
+			diffs = append(diffs, "[value] "+d)
+		}
+		for _, d := range diffs {
+			counts[key][d]++
+		}
+	}
+	for i := range groups {
+		g := &groups[i]
+		for d, n := range counts[coverageKey(g.Method, g.Path)] {
+			g.Diffs = append(g.Diffs, DiffCount{Diff: d, Count: n})
+		}
+		sort.Slice(g.Diffs, func(a, b int) bool {
+			if g.Diffs[a].Count != g.Diffs[b].Count {
+				return g.Diffs[a].Count > g.Diffs[b].Count
+			}
+			return g.Diffs[a].Diff < g.Diffs[b].Diff
+		})
+		if len(g.Diffs) > maxGroupDiffs {
+			g.Diffs = g.Diffs[:maxGroupDiffs]
+		}
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		ra, rb := groups[a].Differ+groups[a].Errors, groups[b].Differ+groups[b].Errors
+		if ra != rb {
+			return ra > rb
+		}
+		if groups[a].Path != groups[b].Path {
+			return groups[a].Path < groups[b].Path
+		}
+		return groups[a].Method < groups[b].Method
+	})
+	return groups
+}
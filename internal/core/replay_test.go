@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const replayHAR = `{"log": {"version": "1.2", "entries": [
+  {"request": {"method": "GET", "url": "https://prod.example.com/api/users/7?expand=orders",
+    "headers": [{"name": "Accept", "value": "application/json"}, {"name": "Cookie", "value": "sid=prod"}, {"name": ":authority", "value": "prod.example.com"}]}},
+  {"request": {"method": "POST", "url": "https://prod.example.com/api/orders",
+    "headers": [{"name": "Content-Type", "value": "application/json"}],
+    "postData": {"mimeType": "application/json", "text": "{\"sku\":\"A1\"}"}}}
+]}}`
+
+const replayJSONL = `{"method": "get", "url": "/api/users/7"}
+
+{"method": "GET", "path": "/api/users/8", "headers": {"Authorization": "Bearer recorded"}}
+{"method": "GET", "url": "/api/users/me"}
+{"method": "POST", "path": "/api/orders", "body": {"sku": "B2"}}
+{"method": "GET", "path": "/api/health"}
+`
+
+func TestLoadCapture(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"traffic.har": replayHAR, "traffic.jsonl": replayJSONL, "bad.jsonl": "{\"method\": \"GET\"}\n"})
+	har, err := LoadCapture(filepath.Join(dir, "traffic.har"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(har) != 2 || har[0].Target() != "/api/users/7?expand=orders" || har[1].Method != "POST" || string(har[1].Body) != `{"sku":"A1"}` {
+		t.Fatalf("har: %+v", har)
+	}
+	if len(har[0].Headers) != 1 || har[0].Headers["Accept"] != "application/json" {
+		t.Errorf("recorded session and connection headers must be dropped: %v", har[0].Headers)
+	}
+	lines, err := LoadCapture(filepath.Join(dir, "traffic.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 5 || lines[0].Method != "GET" || lines[1].Path != "/api/users/8" || string(lines[3].Body) != `{"sku": "B2"}` {
+		t.Fatalf("jsonl: %+v", lines)
+	}
+	if len(lines[1].Headers) != 0 {
+		t.Errorf("recorded Authorization replayed: %v", lines[1].Headers)
+	}
+	if _, err := LoadCapture(filepath.Join(dir, "bad.jsonl")); err == nil || !strings.Contains(err.Error(), "bad.jsonl:1") {
+		t.Errorf("want error with line number, got %v", err)
+	}
+}
+
+func TestMatchEndpoint(t *testing.T) {
+	eps := []Endpoint{{Method: "GET", Path: "/users/{id}"}, {Method: "GET", Path: "/users/me"}, {Method: "GET", Path: "/api/users/{id}/orders"}}
+	for path, want := range map[string]string{
+		"/users/7":            "/users/{id}",
+		"/users/me":           "/users/me",
+		"/api/users/7":        "/users/{id}",
+		"/api/users/7/orders": "/api/users/{id}/orders",
+		"/orders":             "",
+	} {
+		ep, ok := MatchEndpoint(eps, "get", path)
+		if ok != (want != "") || ep.Path != want {
+			t.Errorf("%s matched %q (%v), want %q", path, ep.Path, ok, want)
+		}
+	}
+}
+
+func TestRunReplayCompare(t *testing.T) {
+	// The candidate renames a field of users and echoes the order body; both serve under /api.
+	env := func(candidate bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer env" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch {
+			case r.URL.Path == "/api/orders" && r.Method == "POST":
+				body, _ := io.ReadAll(r.Body)
+				w.Write(body)
+			case r.URL.Path == "/api/users/me":
+				w.Write([]byte(`{"id":"me"}`))
+			case strings.HasPrefix(r.URL.Path, "/api/users/"):
+				id := strings.TrimPrefix(r.URL.Path, "/api/users/")
+				if candidate {
+					w.Write([]byte(`{"userId":"` + id + `"}`))
+					return
+				}
+				w.Write([]byte(`{"id":"` + id + `"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+	a, b := env(false), env(true)
+	defer a.Close()
+	defer b.Close()
+	dir := writeFiles(t, map[string]string{"traffic.jsonl": replayJSONL})
+	reqs, err := LoadCapture(filepath.Join(dir, "traffic.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps := []Endpoint{{Method: "GET", Path: "/users/{id}"}, {Method: "GET", Path: "/users/me"}, {Method: "POST", Path: "/orders"}}
+	auth := map[string]string{"Authorization": "Bearer env"}
+	cfg := ABCompareConfig{
+		// The baseline URL carries the /api prefix the recorded paths already have.
+		A:            CompareSide{BaseURL: a.URL + "/api", AuthHeader: auth},
+		B:            CompareSide{BaseURL: b.URL, AuthHeader: auth},
+		Workers:      2,
+		RateLimitRPS: 100,
+	}
+	res := RunReplayCompare(context.Background(), cfg, eps, reqs, nil)
+	if len(res) != len(reqs) {
+		t.Fatalf("%d results for %d requests", len(res), len(reqs))
+	}
+	for i, r := range res {
+		if r.StatusA == http.StatusUnauthorized || r.StatusB == http.StatusUnauthorized {
+			t.Errorf("%s: sides must send their own credentials: %+v", r.Name(), r)
+		}
+		if r.Request != reqs[i].Target() {
+			t.Errorf("result %d is %s, want %s (request order)", i, r.Request, reqs[i].Target())
+		}
+	}
+	if res[0].Path != "/users/{id}" || !res[0].Differs() || res[2].Path != "/users/me" || res[2].Differs() {
+		t.Errorf("users: %+v / %+v", res[0], res[2])
+	}
+	if res[3].Differs() || res[3].BodyA != `{"sku": "B2"}` {
+		t.Errorf("recorded body not replayed on both sides: %+v", res[3])
+	}
+	if res[4].Path != "/api/health" || res[4].StatusA != http.StatusNotFound {
+		t.Errorf("unmatched request keeps its recorded path: %+v", res[4])
+	}
+
+	groups := GroupCompareResults(res)
+	if len(groups) != 4 {
+		t.Fatalf("groups: %+v", groups)
+	}
+	g := groups[0]
+	if g.Method != "GET" || g.Path != "/users/{id}" || g.Requests != 2 || g.Differ != 2 || g.Same != 0 {
+		t.Errorf("most regressed endpoint first: %+v", g)
+	}
+	if len(g.Diffs) != 2 || g.Diffs[0].Count != 2 || g.Diffs[0].Diff != "[struct] $.id only in A" {
+		t.Errorf("aggregated diffs: %+v", g.Diffs)
+	}
+	for _, g := range groups[1:] {
+		if g.Requests != 1 || g.Differ != 0 {
+			t.Errorf("unchanged endpoint: %+v", g)
+		}
+	}
+}
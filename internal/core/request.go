@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ResolvedParam is one parameter value placed into a generated request.
+type ResolvedParam struct {
+	In     string // path, query, header, cookie
+	Name   string
+	Value  string
+	Pinned bool // value came from overrides instead of the spec
+}
+
+// RequestSpec is a request derived from an OpenAPI operation, independent of base URL.
+type RequestSpec struct {
+	Method     string
+	Path       string // path template, e.g. /users/{id}
+	PathParams map[string]string
+	Query      url.Values
+	Headers    map[string]string // header parameters
+	Cookies    map[string]string // cookie parameters
+	Body       []byte
+	Params     []ResolvedParam // every value filled in, in declaration order
+}
+
+// BuildRequestSpec fills every path parameter plus all required query, header
+// and cookie parameters of ep, and generates an example JSON body.
+func BuildRequestSpec(ep Endpoint, overrides ParamOverrides) RequestSpec {
+	return BuildRequestSpecWith(ep, overrides, NewExampleGenerator(0))
+}
+
+// BuildRequestSpecWith is BuildRequestSpec with the example values taken from g
+// (see ExampleGeneratorFor for a seeded, per-endpoint generator).
+func BuildRequestSpecWith(ep Endpoint, overrides ParamOverrides, g *ExampleGenerator) RequestSpec {
+	rs := RequestSpec{
+		Method:     strings.ToUpper(ep.Method),
+		Path:       ep.Path,
+		PathParams: resolvePathParams(ep, overrides, g),
+		Query:      url.Values{},
+		Headers:    map[string]string{},
+		Cookies:    map[string]string{},
+	}
+	pinned := overrides.ForEndpoint(ep)
+	for _, name := range templateNames(ep.Path) {
+		_, isPinned := pinned[name]
+		rs.Params = append(rs.Params, ResolvedParam{In: openapi3.ParameterInPath, Name: name, Value: rs.PathParams[name], Pinned: isPinned})
+	}
+	for _, p := range ep.Params() {
+		if p.In == openapi3.ParameterInPath || !p.Required {
+			continue
+		}
+		raw := paramRaw(p, g)
+		switch p.In {
+		case openapi3.ParameterInQuery:
+			vals := queryValues(p, raw)
+			rs.Query[p.Name] = vals
+			rs.Params = append(rs.Params, ResolvedParam{In: p.In, Name: p.Name, Value: strings.Join(vals, ",")})
+		case openapi3.ParameterInHeader:
+			if ignoredHeaderParam(p.Name) {
+				continue
+			}
+			rs.Headers[p.Name] = paramString(raw)
+			rs.Params = append(rs.Params, ResolvedParam{In: p.In, Name: p.Name, Value: rs.Headers[p.Name]})
+		case openapi3.ParameterInCookie:
+			rs.Cookies[p.Name] = paramString(raw)
+			rs.Params = append(rs.Params, ResolvedParam{In: p.In, Name: p.Name, Value: rs.Cookies[p.Name]})
+		}
+	}
+	if ep.Schema != nil {
+		rs.Body, _ = g.Body(ep.Schema)
+	}
+	return rs
+}
+
+// clone copies rs so that its maps can be changed without touching rs.
+func (rs RequestSpec) clone() RequestSpec {
+	out := rs
+	out.PathParams = copyStrings(rs.PathParams)
+	out.Headers = copyStrings(rs.Headers)
+	out.Cookies = copyStrings(rs.Cookies)
+	out.Params = append([]ResolvedParam(nil), rs.Params...)
+	out.Query = url.Values{}
+	for k, v := range rs.Query {
+		out.Query[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+// URL joins baseURL with the resolved path and query string.
+func (rs RequestSpec) URL(baseURL string) (string, error) {
+	urlStr, err := BuildURL(baseURL, rs.Path, rs.PathParams)
+	if err != nil {
+		return "", err
+	}
+	if len(rs.Query) == 0 {
+		return urlStr, nil
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, vs := range rs.Query {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// CookieHeader renders cookie parameters as one Cookie header value (sorted by name).
+func (rs RequestSpec) CookieHeader() string {
+	if len(rs.Cookies) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(rs.Cookies))
+	for k := range rs.Cookies {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, k := range names {
+		parts = append(parts, (&http.Cookie{Name: k, Value: rs.Cookies[k]}).String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// HasBody reports whether the request carries the generated body.
+func (rs RequestSpec) HasBody() bool {
+	return len(rs.Body) > 0 && (rs.Method == "POST" || rs.Method == "PUT" || rs.Method == "PATCH")
+}
+
+// NewHTTPRequest builds the outgoing request against baseURL.
+// Header params go first, then env headers, then auth headers (later wins). Expired OAuth2
+// tokens in authHeader are refreshed (see FreshAuthHeaders).
+func (rs RequestSpec) NewHTTPRequest(baseURL string, headers, authHeader map[string]string) (*http.Request, error) {
+	urlStr, err := rs.URL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	authHeader, err = FreshAuthHeaders(authHeader)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if rs.HasBody() {
+		body = bytes.NewReader(rs.Body)
+	}
+	req, err := http.NewRequest(rs.Method, urlStr, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range rs.Headers {
+		req.Header.Set(k, v)
+	}
+	if c := rs.CookieHeader(); c != "" {
+		req.Header.Set("Cookie", c)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range authHeader {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// queryValues serializes a query value; exploded form arrays become repeated keys.
+func queryValues(p *openapi3.Parameter, raw interface{}) []string {
+	arr, ok := raw.([]interface{})
+	if !ok {
+		return []string{paramString(raw)}
+	}
+	out := make([]string, 0, len(arr))
+	for _, e := range arr {
+		out = append(out, paramString(e))
+	}
+	if sm, err := p.SerializationMethod(); err == nil && !sm.Explode {
+		sep := ","
+		switch sm.Style {
+		case openapi3.SerializationSpaceDelimited:
+			sep = " "
+		case openapi3.SerializationPipeDelimited:
+			sep = "|"
+		}
+		return []string{strings.Join(out, sep)}
+	}
+	return out
+}
+
+// ignoredHeaderParam reports headers that OpenAPI says must be described elsewhere.
+func ignoredHeaderParam(name string) bool {
+	switch strings.ToLower(name) {
+	case "accept", "content-type", "authorization":
+		return true
+	}
+	return false
+}
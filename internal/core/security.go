@@ -0,0 +1,178 @@
+package core
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// AuthSecret is the secret material of an auth profile mapped to a security scheme.
+// Which field is used depends on the scheme type (see SchemeCredential).
+type AuthSecret struct {
+	Token    string // bearer, oauth2 and openIdConnect schemes; apiKey when Key is empty
+	Key      string // apiKey schemes
+	Username string // http basic
+	Password string
+}
+
+// Credential is one credential placed in a request.
+type Credential struct {
+	In    string // header | query | cookie
+	Name  string
+	Value string
+}
+
+// SchemeCredential renders secret for scheme: apiKey goes where the scheme says (header,
+// query or cookie), http bearer/basic, oauth2 and openIdConnect go into Authorization.
+func SchemeCredential(scheme *openapi3.SecurityScheme, secret AuthSecret) (Credential, bool) {
+	if scheme == nil {
+		return Credential{}, false
+	}
+	switch scheme.Type {
+	case "apiKey":
+		v := secret.Key
+		if v == "" {
+			v = secret.Token
+		}
+		if v == "" || scheme.Name == "" {
+			return Credential{}, false
+		}
+		return Credential{In: scheme.In, Name: scheme.Name, Value: v}, true
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "basic":
+			if secret.Username == "" {
+				return Credential{}, false
+			}
+			raw := base64.StdEncoding.EncodeToString([]byte(secret.Username + ":" + secret.Password))
+			return Credential{In: openapi3.ParameterInHeader, Name: "Authorization", Value: "Basic " + raw}, true
+		case "bearer":
+			if secret.Token == "" {
+				return Credential{}, false
+			}
+			return Credential{In: openapi3.ParameterInHeader, Name: "Authorization", Value: "Bearer " + secret.Token}, true
+		}
+	case "oauth2", "openIdConnect":
+		if secret.Token == "" {
+			return Credential{}, false
+		}
+		return Credential{In: openapi3.ParameterInHeader, Name: "Authorization", Value: "Bearer " + secret.Token}, true
+	}
+	return Credential{}, false
+}
+
+// SecuritySchemeNames lists the security schemes declared by the specs of endpoints.
+func SecuritySchemeNames(endpoints []Endpoint) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, ep := range endpoints {
+		for name := range ep.SecuritySchemes {
+			if !seen[name] {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RequiresAuth reports whether ep declares a security requirement that anonymous calls
+// cannot satisfy.
+func (ep Endpoint) RequiresAuth() bool {
+	if ep.Security == nil || len(*ep.Security) == 0 {
+		return false
+	}
+	for _, req := range *ep.Security {
+		if len(req) == 0 {
+			return false // {} in the list: authentication is optional
+		}
+	}
+	return true
+}
+
+// EndpointCredentials picks the credentials for ep from its security requirements: the first
+// alternative all of whose schemes have a secret. public is true when ep goes out without
+// credentials (security: [], or an optional {} alternative and no usable secret). ok is
+// false when the spec declares nothing usable, so the caller keeps its default auth.
+func EndpointCredentials(ep Endpoint, secrets map[string]AuthSecret) (creds []Credential, public, ok bool) {
+	if ep.Security == nil {
+		return nil, false, false
+	}
+	if len(*ep.Security) == 0 {
+		return nil, true, true
+	}
+	optional := false
+	for _, req := range *ep.Security {
+		if len(req) == 0 {
+			optional = true
+			continue
+		}
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var alt []Credential
+		for _, name := range names {
+			secret, has := secrets[name]
+			if !has {
+				alt = nil
+				break
+			}
+			c, usable := SchemeCredential(schemeByName(ep.SecuritySchemes, name), secret)
+			if !usable {
+				alt = nil
+				break
+			}
+			alt = append(alt, c)
+		}
+		if len(alt) > 0 {
+			return alt, false, true
+		}
+	}
+	if optional {
+		return nil, true, true
+	}
+	return nil, false, false
+}
+
+func schemeByName(schemes openapi3.SecuritySchemes, name string) *openapi3.SecurityScheme {
+	if ref := schemes[name]; ref != nil {
+		return ref.Value
+	}
+	return nil
+}
+
+// ApplySecurity places the credentials ep requires into rs (query and cookie credentials)
+// and returns the auth headers to send. fallback, the auth header of the selected profile,
+// is returned when the spec declares no usable security for ep; public operations get none.
+func ApplySecurity(ep Endpoint, rs *RequestSpec, secrets map[string]AuthSecret, fallback map[string]string) map[string]string {
+	creds, public, ok := EndpointCredentials(ep, secrets)
+	switch {
+	case public:
+		return nil
+	case !ok:
+		return fallback
+	}
+	headers := map[string]string{}
+	for _, c := range creds {
+		switch c.In {
+		case openapi3.ParameterInQuery:
+			if rs.Query == nil {
+				rs.Query = map[string][]string{}
+			}
+			rs.Query.Set(c.Name, c.Value)
+		case openapi3.ParameterInCookie:
+			if rs.Cookies == nil {
+				rs.Cookies = map[string]string{}
+			}
+			rs.Cookies[c.Name] = c.Value
+		default:
+			headers[c.Name] = c.Value
+		}
+	}
+	return headers
+}
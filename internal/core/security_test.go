@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+const securitySpec = `openapi: 3.0.3
+info: {title: t, version: "1"}
+security: [{bearer: []}]
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    basic: {type: http, scheme: basic}
+    queryKey: {type: apiKey, in: query, name: api_key}
+    cookieKey: {type: apiKey, in: cookie, name: session}
+    headerKey: {type: apiKey, in: header, name: X-API-Key}
+paths:
+  /bearer:
+    get:
+      responses: {"200": {description: ok}}
+  /basic:
+    get:
+      security: [{basic: []}]
+      responses: {"200": {description: ok}}
+  /query:
+    get:
+      security: [{queryKey: []}]
+      responses: {"200": {description: ok}}
+  /cookie:
+    get:
+      security: [{cookieKey: []}]
+      responses: {"200": {description: ok}}
+  /either:
+    get:
+      security: [{unmapped: []}, {headerKey: []}]
+      responses: {"200": {description: ok}}
+  /optional:
+    get:
+      security: [{unmapped: []}, {}]
+      responses: {"200": {description: ok}}
+  /public:
+    get:
+      security: []
+      responses: {"200": {description: ok}}
+`
+
+func TestSmokeAppliesSecuritySchemes(t *testing.T) {
+	got := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cred := r.Header.Get("Authorization") + r.Header.Get("X-API-Key") + r.URL.Query().Get("api_key")
+		if c, err := r.Cookie("session"); err == nil {
+			cred += "cookie=" + c.Value
+		}
+		got[r.URL.Path] = cred
+		if cred == "" && r.URL.Path != "/public" && r.URL.Path != "/optional" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	dir := writeFiles(t, map[string]string{"api.yaml": securitySpec})
+	eps, _, err := LoadSpecs(filepath.Join(dir, "api.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := SecuritySchemeNames(eps); len(names) != 5 {
+		t.Errorf("scheme names: %v", names)
+	}
+	cfg := SmokeConfig{
+		BaseURL:      ts.URL,
+		Workers:      1,
+		RateLimitRPS: 100,
+		AuthHeader:   map[string]string{"Authorization": "Bearer fallback"},
+		Secrets: map[string]AuthSecret{
+			"bearer":    {Token: "tok"},
+			"basic":     {Username: "u", Password: "p"},
+			"queryKey":  {Key: "qk"},
+			"cookieKey": {Key: "ck"},
+			"headerKey": {Key: "hk"},
+		},
+	}
+	for _, r := range RunSmokeBulk(context.Background(), cfg, eps) {
+		if !r.OK {
+			t.Errorf("%s %s: %+v", r.Method, r.Path, r)
+		}
+	}
+	want := map[string]string{
+		"/bearer":   "Bearer tok",
+		"/basic":    "Basic dTpw",
+		"/query":    "qk",
+		"/cookie":   "cookie=ck",
+		"/either":   "hk",
+		"/optional": "",
+		"/public":   "",
+	}
+	for path, w := range want {
+		if got[path] != w {
+			t.Errorf("%s sent credential %q, want %q", path, got[path], w)
+		}
+	}
+
+	// Without scheme secrets the profile header is the fallback for protected operations.
+	cfg.Secrets = nil
+	got = map[string]string{}
+	RunSmokeBulk(context.Background(), cfg, eps)
+	if got["/bearer"] != "Bearer fallback" || got["/public"] != "" {
+		t.Errorf("fallback: %v", got)
+	}
+
+	// Anonymous runs send nothing and expect protected operations to refuse.
+	cfg.Anonymous = true
+	got = map[string]string{}
+	for _, r := range RunSmokeBulk(context.Background(), cfg, eps) {
+		if !r.OK {
+			t.Errorf("anonymous %s %s: %+v", r.Method, r.Path, r)
+		}
+	}
+	for path, cred := range got {
+		if cred != "" {
+			t.Errorf("anonymous run sent %q to %s", cred, path)
+		}
+	}
+}
+
+func TestAnonymousSmokeFailsWhenProtectedOperationAnswers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	dir := writeFiles(t, map[string]string{"api.yaml": securitySpec})
+	eps, _, err := LoadSpecs(filepath.Join(dir, "api.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := SmokeConfig{BaseURL: ts.URL, Workers: 1, RateLimitRPS: 100, Anonymous: true}
+	for i, r := range RunSmokeBulk(context.Background(), cfg, eps) {
+		if want := !eps[i].RequiresAuth(); r.OK != want {
+			t.Errorf("%s %s: ok=%v, want %v (%s)", r.Method, r.Path, r.OK, want, r.Err)
+		}
+	}
+}
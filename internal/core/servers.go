@@ -0,0 +1,126 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ServerURL expands the {variables} of srv. Values in vars win over the declared defaults;
+// a value outside the variable's enum is an error. Relative URLs are returned as they are.
+func ServerURL(srv *openapi3.Server, vars map[string]string) (string, error) {
+	if srv == nil {
+		return "", fmt.Errorf("no server")
+	}
+	out := srv.URL
+	names := make([]string, 0, len(srv.Variables))
+	for name := range srv.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := srv.Variables[name]
+		if v == nil {
+			continue
+		}
+		val, ok := vars[name]
+		if !ok {
+			val = v.Default
+		}
+		if len(v.Enum) > 0 && !sliceContains(v.Enum, val) {
+			return "", fmt.Errorf("server variable %s=%q not in %v", name, val, v.Enum)
+		}
+		out = strings.ReplaceAll(out, "{"+name+"}", val)
+	}
+	if strings.Contains(out, "{") {
+		return "", fmt.Errorf("server %s has undefined variables", srv.URL)
+	}
+	return strings.TrimSuffix(out, "/"), nil
+}
+
+// ServerURLs expands every absolute server URL in servers, skipping ones that cannot be used.
+func ServerURLs(servers openapi3.Servers, vars map[string]string) []string {
+	var out []string
+	for _, srv := range servers {
+		u, err := ServerURL(srv, vars)
+		if err != nil || !isAbsoluteURL(u) {
+			continue
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// ResolveBaseURL picks the base URL for ep. Precedence: pinned (an explicit --base), envBase
+// (the env baseURL), the servers of the operation or path item, then the root servers of ep's
+// spec. Spec servers are only a fallback unless operationServers (env.yaml operationServers)
+// lets the operation and path servers win over envBase.
+func ResolveBaseURL(ep Endpoint, pinned, envBase string, vars map[string]string, operationServers bool) (string, error) {
+	if pinned != "" {
+		return pinned, nil
+	}
+	if envBase != "" && !operationServers {
+		return envBase, nil
+	}
+	if urls := ServerURLs(ep.Servers, vars); len(urls) > 0 {
+		return urls[0], nil
+	}
+	if envBase != "" {
+		return envBase, nil
+	}
+	if urls := ServerURLs(ep.SpecServers, vars); len(urls) > 0 {
+		return urls[0], nil
+	}
+	return "", fmt.Errorf("no base URL for %s %s: set --base, env baseURL or spec servers", ep.Method, ep.Path)
+}
+
+// CommonSpecServers returns the root servers shared by endpoints, or nil when they were
+// loaded from more than one spec.
+func CommonSpecServers(endpoints []Endpoint) openapi3.Servers {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	for _, ep := range endpoints[1:] {
+		if ep.Spec != endpoints[0].Spec {
+			return nil
+		}
+	}
+	return endpoints[0].SpecServers
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// absoluteServers resolves relative server URLs ("/api/v1") against the URL the spec was
+// fetched from, as OpenAPI specifies. Without an http(s) source they stay relative.
+func absoluteServers(servers openapi3.Servers, source string) openapi3.Servers {
+	if len(servers) == 0 || !isSpecURL(source) {
+		return servers
+	}
+	base, err := url.Parse(source)
+	if err != nil {
+		return servers
+	}
+	out := make(openapi3.Servers, 0, len(servers))
+	for _, srv := range servers {
+		if srv == nil || isAbsoluteURL(srv.URL) || strings.HasPrefix(srv.URL, "{") {
+			out = append(out, srv)
+			continue
+		}
+		// Joined as strings: url.ResolveReference would escape {variables}.
+		cp := *srv
+		if strings.HasPrefix(srv.URL, "/") {
+			cp.URL = base.Scheme + "://" + base.Host + srv.URL
+		} else {
+			dir := base.Path[:strings.LastIndex(base.Path, "/")+1]
+			cp.URL = base.Scheme + "://" + base.Host + dir + strings.TrimPrefix(srv.URL, "./")
+		}
+		out = append(out, &cp)
+	}
+	return out
+}
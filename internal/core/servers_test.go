@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const serversSpec = `openapi: 3.0.3
+info: {title: t, version: "1"}
+servers:
+  - url: https://{region}.api.example.com/{version}
+    variables:
+      region: {default: eu, enum: [eu, us]}
+      version: {default: v1}
+paths:
+  /users:
+    get:
+      responses: {"200": {description: ok}}
+  /files:
+    servers:
+      - url: https://files.example.com
+    get:
+      responses: {"200": {description: ok}}
+    post:
+      servers:
+        - url: https://upload.example.com/{region}
+          variables:
+            region: {default: eu}
+      responses: {"201": {description: ok}}
+`
+
+func TestResolveBaseURLPrecedence(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"api.yaml": serversSpec})
+	eps, _, err := LoadOpenAPI(filepath.Join(dir, "api.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byKey := map[string]Endpoint{}
+	for _, ep := range eps {
+		byKey[ep.Method+" "+ep.Path] = ep
+	}
+	cases := []struct {
+		ep              string
+		pinned, envBase string
+		vars            map[string]string
+		opServers       bool
+		want            string
+	}{
+		{"GET /users", "", "", nil, false, "https://eu.api.example.com/v1"},
+		{"GET /users", "", "", map[string]string{"region": "us", "version": "v2"}, false, "https://us.api.example.com/v2"},
+		{"GET /users", "", "http://env", nil, false, "http://env"},
+		// Spec servers are a fallback: the env base URL wins over operation/path servers...
+		{"GET /files", "", "http://env", nil, false, "http://env"},
+		{"POST /files", "", "http://env", map[string]string{"region": "tr"}, false, "http://env"},
+		{"GET /files", "", "", nil, false, "https://files.example.com"},
+		{"POST /files", "", "", map[string]string{"region": "tr"}, false, "https://upload.example.com/tr"},
+		// ...unless the environment opts in with operationServers.
+		{"GET /files", "", "http://env", nil, true, "https://files.example.com"},
+		{"GET /users", "", "http://env", nil, true, "http://env"},
+		{"POST /files", "http://pinned", "http://env", nil, true, "http://pinned"},
+	}
+	for _, c := range cases {
+		got, err := ResolveBaseURL(byKey[c.ep], c.pinned, c.envBase, c.vars, c.opServers)
+		if err != nil || got != c.want {
+			t.Errorf("%s pinned=%q envBase=%q vars=%v operationServers=%v: got %q, %v; want %q", c.ep, c.pinned, c.envBase, c.vars, c.opServers, got, err, c.want)
+		}
+	}
+	if _, err := ResolveBaseURL(byKey["GET /users"], "", "", map[string]string{"region": "asia"}, false); err == nil || !strings.Contains(err.Error(), "no base URL") {
+		t.Errorf("expected an error for a region outside the enum, got %v", err)
+	}
+	if got := ServerURLs(CommonSpecServers(eps), nil); len(got) != 1 || got[0] != "https://eu.api.example.com/v1" {
+		t.Errorf("common spec servers = %v", got)
+	}
+}
+
+func TestSmokeUsesSpecServers(t *testing.T) {
+	var hits []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, r.URL.Path)
+		switch r.URL.Path {
+		case "/v3/api-docs":
+			w.Write([]byte(`openapi: 3.0.3
+info: {title: t, version: "1"}
+servers: [{url: /api}]
+paths:
+  /ping:
+    get:
+      responses: {"200": {description: ok}}
+`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+	eps, _, err := LoadSpecs(ts.URL+"/v3/api-docs", LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := RunSmokeBulk(context.Background(), SmokeConfig{Workers: 1, RateLimitRPS: 100}, eps)
+	if len(res) != 1 || !res[0].OK {
+		t.Fatalf("smoke without base URL: %+v", res)
+	}
+	if hits[len(hits)-1] != "/api/ping" {
+		t.Errorf("relative server not resolved against the spec URL, requests: %v", hits)
+	}
+}
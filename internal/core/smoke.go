@@ -1,8 +1,8 @@
 package core
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"sync"
@@ -17,16 +17,68 @@ type SmokeResult struct {
 	LatencyMS  int64
 	Err        string
 	OK         bool
+	// Contract is the drift verdict on the response body (set when SmokeConfig.CheckContract).
+	Contract *DriftResult `json:",omitempty"`
 }
 
 // SmokeConfig configures smoke test run.
 type SmokeConfig struct {
-	BaseURL    string
-	Headers    map[string]string
-	Timeout    time.Duration
-	Workers    int
+	BaseURL      string
+	Headers      map[string]string
+	Timeout      time.Duration
+	Workers      int
 	RateLimitRPS int
-	AuthHeader map[string]string
+	AuthHeader   map[string]string
+	PathParams   ParamOverrides    // pinned path param values per endpoint selector
+	Expect       ExpectationPolicy // status verdict; nil means DocumentedPolicy
+	// CheckContract keeps each response body and runs RunDrift on it in the same pass.
+	CheckContract bool
+	// Seed and ExampleName drive generated example data (see ExampleGeneratorFor).
+	Seed        int64
+	ExampleName string
+	// BaseURL is the env base URL (or an explicit --base with PinBaseURL); the spec servers
+	// are used when it is empty. OperationServers lets operation/path servers win over an
+	// env base URL (see ResolveBaseURL).
+	PinBaseURL       bool
+	OperationServers bool
+	ServerVars       map[string]string // server variable values (override the spec defaults)
+	// Secrets maps security scheme names to profile secrets; operations with a spec security
+	// requirement get those credentials instead of AuthHeader (see ApplySecurity).
+	Secrets map[string]AuthSecret
+	// Anonymous sends no credentials; operations requiring auth then pass only on 401/403.
+	Anonymous bool
+	// ExpandBody expands the ${...} references of generated bodies (spec examples) when the
+	// environment opts in with expandExamples; nil sends them as generated.
+	ExpandBody func(string) (string, error)
+}
+
+// newRequest resolves the base URL of ep (see ResolveBaseURL) and builds the HTTP request for rs.
+func (cfg SmokeConfig) newRequest(ep Endpoint, rs RequestSpec) (*http.Request, error) {
+	pinned := ""
+	if cfg.PinBaseURL {
+		pinned = cfg.BaseURL
+	}
+	base, err := ResolveBaseURL(ep, pinned, cfg.BaseURL, cfg.ServerVars, cfg.OperationServers)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ExpandBody != nil && rs.HasBody() {
+		body, err := cfg.ExpandBody(string(rs.Body))
+		if err != nil {
+			return nil, fmt.Errorf("request body: %w", err)
+		}
+		rs.Body = []byte(body)
+	}
+	var auth map[string]string
+	if !cfg.Anonymous {
+		auth = ApplySecurity(ep, &rs, cfg.Secrets, cfg.AuthHeader)
+	}
+	return rs.NewHTTPRequest(base, cfg.Headers, auth)
+}
+
+// requestSpec builds the request for ep with cfg's pinned params and example seed.
+func (cfg SmokeConfig) requestSpec(ep Endpoint) RequestSpec {
+	return BuildRequestSpecWith(ep, cfg.PathParams, ExampleGeneratorFor(ep, cfg.Seed, cfg.ExampleName))
 }
 
 // RunSmoke runs smoke tests for endpoints using worker pool and RPS limiter.
@@ -42,27 +94,11 @@ func RunSmokeSingle(cfg SmokeConfig, ep Endpoint) SmokeResult {
 func doOneSmoke(cfg SmokeConfig, ep Endpoint) SmokeResult {
 	res := SmokeResult{Path: ep.Path, Method: ep.Method}
 	start := time.Now()
-	urlStr, err := BuildURL(cfg.BaseURL, ep.Path, nil)
+	req, err := cfg.newRequest(ep, cfg.requestSpec(ep))
 	if err != nil {
 		res.Err = err.Error()
 		return res
 	}
-	body, _ := ExampleBody(ep.Schema)
-	req, err := http.NewRequest(ep.Method, urlStr, nil)
-	if err != nil {
-		res.Err = err.Error()
-		return res
-	}
-	if len(body) > 0 && (ep.Method == "POST" || ep.Method == "PUT" || ep.Method == "PATCH") {
-		req.Body = io.NopCloser(bytes.NewReader(body))
-		req.Header.Set("Content-Type", "application/json")
-	}
-	for k, v := range cfg.Headers {
-		req.Header.Set(k, v)
-	}
-	for k, v := range cfg.AuthHeader {
-		req.Header.Set(k, v)
-	}
 	client := &http.Client{
 		Timeout:       cfg.Timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
@@ -75,51 +111,75 @@ func doOneSmoke(cfg SmokeConfig, ep Endpoint) SmokeResult {
 	}
 	defer resp.Body.Close()
 	res.StatusCode = resp.StatusCode
-	// Success: expected 2xx or documented 4xx
-	res.OK = resp.StatusCode >= 200 && resp.StatusCode < 300 || (resp.StatusCode >= 400 && resp.StatusCode < 500)
+	policy := cfg.Expect
+	if policy == nil {
+		policy = DocumentedPolicy{}
+	}
+	res.OK, res.Err = policy.Check(ep.Schema, resp.StatusCode)
+	if cfg.Anonymous && ep.RequiresAuth() {
+		res.OK, res.Err = anonymousVerdict(resp.StatusCode)
+	}
+	if cfg.CheckContract {
+		body, err := io.ReadAll(resp.Body)
+		var dr DriftResult
+		if err != nil {
+			// A truncated body is a transport failure, not contract drift.
+			res.OK, res.Err = false, "read body: "+err.Error()
+			dr = DriftResult{StatusCode: resp.StatusCode, Err: res.Err}
+		} else {
+			dr = RunDriftResponse(ep.Schema, resp.StatusCode, resp.Header, body)
+		}
+		dr.Path = ep.Path
+		dr.Method = ep.Method
+		res.Contract = &dr
+	}
 	return res
 }
 
-// FetchResponse performs one HTTP request and returns status code, body, and error.
-// Used for contract drift (need response body to compare to schema).
-func FetchResponse(cfg SmokeConfig, ep Endpoint) (statusCode int, body []byte, err error) {
-	urlStr, err := BuildURL(cfg.BaseURL, ep.Path, nil)
-	if err != nil {
-		return 0, nil, err
+// anonymousVerdict: a protected operation must reject a call without credentials.
+func anonymousVerdict(status int) (bool, string) {
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return true, ""
 	}
-	reqBody, _ := ExampleBody(ep.Schema)
-	var bodyReader io.Reader
-	if len(reqBody) > 0 && (ep.Method == "POST" || ep.Method == "PUT" || ep.Method == "PATCH") {
-		bodyReader = bytes.NewReader(reqBody)
-	}
-	req, err := http.NewRequest(ep.Method, urlStr, bodyReader)
+	return false, fmt.Sprintf("protected operation answered %d to an anonymous call (want 401 or 403)", status)
+}
+
+// FetchResponse performs one HTTP request and returns status code, headers, body, and error.
+// Used for contract drift (need response body and headers to compare to schema).
+func FetchResponse(cfg SmokeConfig, ep Endpoint) (statusCode int, header http.Header, body []byte, err error) {
+	req, err := cfg.newRequest(ep, cfg.requestSpec(ep))
 	if err != nil {
-		return 0, nil, err
-	}
-	if len(reqBody) > 0 {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	for k, v := range cfg.Headers {
-		req.Header.Set(k, v)
-	}
-	for k, v := range cfg.AuthHeader {
-		req.Header.Set(k, v)
+		return 0, nil, nil, err
 	}
 	client := &http.Client{Timeout: cfg.Timeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
 	}
 	defer resp.Body.Close()
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return resp.StatusCode, nil, err
+		return resp.StatusCode, resp.Header, nil, err
 	}
-	return resp.StatusCode, body, nil
+	return resp.StatusCode, resp.Header, body, nil
 }
 
 // RunSmokeBulk runs smoke for all endpoints with worker pool and RPS.
 func RunSmokeBulk(ctx context.Context, cfg SmokeConfig, endpoints []Endpoint) []SmokeResult {
+	results := make([]SmokeResult, len(endpoints))
+	var mu sync.Mutex
+	runPool(ctx, &cfg, len(endpoints), func(i int) {
+		r := doOneSmoke(cfg, endpoints[i])
+		mu.Lock()
+		results[i] = r
+		mu.Unlock()
+	})
+	return results
+}
+
+// runPool applies smoke defaults to cfg and calls fn for each index 0..n-1
+// from cfg.Workers goroutines, throttled to cfg.RateLimitRPS.
+func runPool(ctx context.Context, cfg *SmokeConfig, n int, fn func(i int)) {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 5 * time.Second
 	}
@@ -127,13 +187,11 @@ func RunSmokeBulk(ctx context.Context, cfg SmokeConfig, endpoints []Endpoint) []
 		cfg.RateLimitRPS = 10
 	}
 	type job struct{ idx int }
-	jobs := make(chan job, len(endpoints))
-	for i := 0; i < len(endpoints); i++ {
+	jobs := make(chan job, n)
+	for i := 0; i < n; i++ {
 		jobs <- job{i}
 	}
 	close(jobs)
-	results := make([]SmokeResult, len(endpoints))
-	var mu sync.Mutex
 	ticker := time.NewTicker(time.Second / time.Duration(cfg.RateLimitRPS))
 	defer ticker.Stop()
 	var wg sync.WaitGroup
@@ -151,16 +209,16 @@ func RunSmokeBulk(ctx context.Context, cfg SmokeConfig, endpoints []Endpoint) []
 					return
 				default:
 				}
-				<-ticker.C
-				r := doOneSmoke(cfg, endpoints[j.idx])
-				mu.Lock()
-				results[j.idx] = r
-				mu.Unlock()
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				fn(j.idx)
 			}
 		}()
 	}
 	wg.Wait()
-	return results
 }
 
 // P50P95 computes approximate p50 and p95 from latencies (in ms).
@@ -0,0 +1,345 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ChangeKind classifies one difference between two versions of a spec.
+type ChangeKind string
+
+const (
+	ChangeEndpointRemoved      ChangeKind = "endpoint_removed"
+	ChangeEndpointAdded        ChangeKind = "endpoint_added"
+	ChangeParamRequired        ChangeKind = "param_required"         // new required parameter, or optional -> required
+	ChangeParamAdded           ChangeKind = "param_added"            // new optional parameter
+	ChangeParamRemoved         ChangeKind = "param_removed"          // the server ignores what clients still send
+	ChangeEnumNarrowed         ChangeKind = "enum_narrowed"          // values removed from an enum
+	ChangeEnumWidened          ChangeKind = "enum_widened"           // values added to an enum
+	ChangeTypeChanged          ChangeKind = "type_changed"           // parameter or field type differs
+	ChangeRequestFieldRequired ChangeKind = "request_field_required" // new required request field, or optional -> required
+	ChangeRequestFieldAdded    ChangeKind = "request_field_added"    // new optional request field
+	ChangeResponseFieldRemoved ChangeKind = "response_field_removed"
+	ChangeResponseFieldAdded   ChangeKind = "response_field_added"
+	ChangeResponseRemoved      ChangeKind = "response_removed" // a documented status code is gone
+)
+
+// SpecChange is one difference between an old and a new spec.
+type SpecChange struct {
+	Kind     ChangeKind `json:"kind"`
+	Breaking bool       `json:"breaking"` // clients written against the old spec may fail against the new one
+	Method   string     `json:"method"`   // endpoint the change belongs to
+	Path     string     `json:"path"`
+	Location string     `json:"location,omitempty"` // "query:limit", "request $.items[].qty", "response 200 $.name"
+	Detail   string     `json:"detail,omitempty"`
+}
+
+// SpecDiff is the result of DiffSpecs. Changes are sorted by path, method, location.
+type SpecDiff struct {
+	Changes  []SpecChange `json:"changes"`
+	Breaking int          `json:"breaking"`
+}
+
+// maxDiffDepth bounds the walk of recursive schemas.
+const maxDiffDepth = 8
+
+var templateParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// diffKey matches endpoints across versions; renamed path parameters ({id} -> {userId}) still match.
+func diffKey(ep Endpoint) string {
+	return strings.ToUpper(ep.Method) + " " + templateParam.ReplaceAllString(ep.Path, "{}")
+}
+
+// DiffSpecs lists the differences between the endpoints of two spec versions and classifies each
+// as breaking or not. Request-side changes break when they reject requests that used to be valid
+// (new required input, removed enum values); response-side changes break when they drop data or
+// produce values clients did not expect (removed fields, new enum values).
+func DiffSpecs(oldEps, newEps []Endpoint) SpecDiff {
+	var d SpecDiff
+	newByKey := map[string]Endpoint{}
+	for _, ep := range newEps {
+		newByKey[diffKey(ep)] = ep
+	}
+	oldKeys := map[string]bool{}
+	for _, o := range oldEps {
+		key := diffKey(o)
+		oldKeys[key] = true
+		n, ok := newByKey[key]
+		if !ok {
+			d.add(SpecChange{Kind: ChangeEndpointRemoved, Breaking: true, Method: o.Method, Path: o.Path})
+			continue
+		}
+		c := &endpointDiff{d: &d, method: n.Method, path: n.Path}
+		c.params(o, n)
+		c.requestBody(o.Schema, n.Schema)
+		c.responses(o.Schema, n.Schema)
+	}
+	for _, n := range newEps {
+		if !oldKeys[diffKey(n)] {
+			d.add(SpecChange{Kind: ChangeEndpointAdded, Method: n.Method, Path: n.Path})
+		}
+	}
+	sort.SliceStable(d.Changes, func(i, j int) bool {
+		a, b := d.Changes[i], d.Changes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Location < b.Location
+	})
+	return d
+}
+
+// Fails reports whether d should fail a --fail-on gate: breaking fails on breaking changes,
+// warning on any change at all.
+func (d SpecDiff) Fails(min Severity) bool {
+	switch min {
+	case SeverityBreaking:
+		return d.Breaking > 0
+	case SeverityWarning:
+		return len(d.Changes) > 0
+	}
+	return false
+}
+
+func (d *SpecDiff) add(c SpecChange) {
+	d.Changes = append(d.Changes, c)
+	if c.Breaking {
+		d.Breaking++
+	}
+}
+
+// endpointDiff collects the changes of one endpoint present in both versions.
+type endpointDiff struct {
+	d            *SpecDiff
+	method, path string
+}
+
+func (c *endpointDiff) add(kind ChangeKind, breaking bool, location, detail string) {
+	c.d.add(SpecChange{Kind: kind, Breaking: breaking, Method: c.method, Path: c.path, Location: location, Detail: detail})
+}
+
+func (c *endpointDiff) params(o, n Endpoint) {
+	oldParams := map[string]*openapi3.Parameter{}
+	for _, p := range o.Params() {
+		oldParams[paramKey(o.Path, p)] = p
+	}
+	newParams := map[string]*openapi3.Parameter{}
+	for _, p := range n.Params() {
+		key := paramKey(n.Path, p)
+		newParams[key] = p
+		op, existed := oldParams[key]
+		switch {
+		case !existed && p.Required:
+			c.add(ChangeParamRequired, true, p.In+":"+p.Name, "new required parameter")
+		case !existed:
+			c.add(ChangeParamAdded, false, p.In+":"+p.Name, "")
+		case p.Required && !op.Required:
+			c.add(ChangeParamRequired, true, p.In+":"+p.Name, "optional parameter became required")
+		}
+		if existed && op.Schema != nil && p.Schema != nil {
+			c.schema(op.Schema.Value, p.Schema.Value, p.In+":"+p.Name, "", true, 0)
+		}
+	}
+	for key, p := range oldParams {
+		if _, ok := newParams[key]; !ok && p.In != openapi3.ParameterInPath {
+			c.add(ChangeParamRemoved, false, p.In+":"+p.Name, "")
+		}
+	}
+}
+
+// paramKey identifies a parameter of the endpoint at path; path parameters match by their
+// position in the path template ("path#0", "path#1", ...) so renames are not changes.
+func paramKey(path string, p *openapi3.Parameter) string {
+	if p.In == openapi3.ParameterInPath {
+		for i, m := range templateParam.FindAllString(path, -1) {
+			if m[1:len(m)-1] == p.Name {
+				return fmt.Sprintf("path#%d", i)
+			}
+		}
+		return "path:" + p.Name
+	}
+	return p.In + ":" + strings.ToLower(p.Name)
+}
+
+func (c *endpointDiff) requestBody(o, n *openapi3.Operation) {
+	os, ns := requestSchema(o), requestSchema(n)
+	switch {
+	case ns == nil:
+		return
+	case os == nil:
+		if n.RequestBody.Value.Required {
+			c.add(ChangeRequestFieldRequired, true, "request", "new required request body")
+		}
+		return
+	}
+	c.schema(os, ns, "request", "$", true, 0)
+}
+
+func requestSchema(op *openapi3.Operation) *openapi3.Schema {
+	if op == nil || op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+	if m := jsonMedia(op.RequestBody.Value.Content); m != nil && m.Schema != nil {
+		return m.Schema.Value
+	}
+	return nil
+}
+
+func (c *endpointDiff) responses(o, n *openapi3.Operation) {
+	if o == nil || o.Responses == nil {
+		return
+	}
+	codes := make([]string, 0, o.Responses.Len())
+	for code := range o.Responses.Map() {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		var nr *openapi3.ResponseRef
+		if n != nil && n.Responses != nil {
+			nr = n.Responses.Value(code)
+		}
+		if nr == nil || nr.Value == nil {
+			// Only a removed success response changes what clients get back.
+			if strings.HasPrefix(code, "2") {
+				c.add(ChangeResponseRemoved, true, "response "+code, "")
+			}
+			continue
+		}
+		or := o.Responses.Value(code)
+		if or == nil || or.Value == nil {
+			continue
+		}
+		om, nm := jsonMedia(or.Value.Content), jsonMedia(nr.Value.Content)
+		if om == nil || nm == nil || om.Schema == nil || nm.Schema == nil {
+			continue
+		}
+		c.schema(om.Schema.Value, nm.Schema.Value, "response "+code, "$", false, 0)
+	}
+}
+
+// schema compares one schema in both versions. request selects request-side classification.
+// loc names the parameter or body; path is the JSON path inside it ("" for parameters).
+func (c *endpointDiff) schema(o, n *openapi3.Schema, loc, path string, request bool, depth int) {
+	if o == nil || n == nil || depth > maxDiffDepth {
+		return
+	}
+	where := loc
+	if path != "" {
+		where = loc + " " + path
+	}
+	ot, nt := o.Type.Slice(), n.Type.Slice()
+	if len(ot) > 0 && len(nt) > 0 && !sameTypes(ot, nt) {
+		c.add(ChangeTypeChanged, true, where, strings.Join(ot, "|")+" -> "+strings.Join(nt, "|"))
+		return
+	}
+	c.enum(o, n, where, request)
+	if o.Items != nil && n.Items != nil {
+		c.schema(o.Items.Value, n.Items.Value, loc, path+"[]", request, depth+1)
+	}
+	if len(o.Properties) == 0 && len(n.Properties) == 0 {
+		return
+	}
+	names := map[string]bool{}
+	for name := range o.Properties {
+		names[name] = true
+	}
+	for name := range n.Properties {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		op, np := o.Properties[name], n.Properties[name]
+		field := loc + " " + path + "." + name
+		switch {
+		case np == nil && request:
+			// Clients may keep sending it; servers typically ignore unknown fields.
+		case np == nil:
+			if !writeOnly(op) {
+				c.add(ChangeResponseFieldRemoved, true, field, "")
+			}
+		case op == nil && request:
+			if sliceContains(n.Required, name) && !readOnly(np) {
+				c.add(ChangeRequestFieldRequired, true, field, "new required field")
+			} else if !readOnly(np) {
+				c.add(ChangeRequestFieldAdded, false, field, "")
+			}
+		case op == nil:
+			if !writeOnly(np) {
+				c.add(ChangeResponseFieldAdded, false, field, "")
+			}
+		default:
+			if request && sliceContains(n.Required, name) && !sliceContains(o.Required, name) && !readOnly(np) {
+				c.add(ChangeRequestFieldRequired, true, field, "optional field became required")
+			}
+			c.schema(op.Value, np.Value, loc, path+"."+name, request, depth+1)
+		}
+	}
+}
+
+func (c *endpointDiff) enum(o, n *openapi3.Schema, where string, request bool) {
+	if len(o.Enum) == 0 && len(n.Enum) == 0 {
+		return
+	}
+	oldVals, newVals := enumStrings(o.Enum), enumStrings(n.Enum)
+	var removed, added []string
+	for _, v := range oldVals {
+		if len(newVals) > 0 && !sliceContains(newVals, v) {
+			removed = append(removed, v)
+		}
+	}
+	for _, v := range newVals {
+		if len(oldVals) > 0 && !sliceContains(oldVals, v) {
+			added = append(added, v)
+		}
+	}
+	if len(oldVals) == 0 {
+		// A free value became an enum: every other value is now rejected.
+		c.add(ChangeEnumNarrowed, request, where, "now limited to "+strings.Join(newVals, ", "))
+		return
+	}
+	if len(removed) > 0 {
+		c.add(ChangeEnumNarrowed, request, where, "removed "+strings.Join(removed, ", "))
+	}
+	if len(added) > 0 {
+		c.add(ChangeEnumWidened, !request, where, "added "+strings.Join(added, ", "))
+	}
+}
+
+func enumStrings(values []interface{}) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, fmt.Sprint(v))
+	}
+	return out
+}
+
+func sameTypes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, t := range a {
+		if !sliceContains(b, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func readOnly(ref *openapi3.SchemaRef) bool {
+	return ref != nil && ref.Value != nil && ref.Value.ReadOnly
+}
+func writeOnly(ref *openapi3.SchemaRef) bool {
+	return ref != nil && ref.Value != nil && ref.Value.WriteOnly
+}
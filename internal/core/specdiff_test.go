@@ -0,0 +1,174 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const diffOld = `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+        - {name: fields, in: query, schema: {type: string}}
+        - {name: sort, in: query, schema: {type: string, enum: [asc, desc]}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id: {type: integer}
+                  name: {type: string}
+                  email: {type: string}
+                  status: {type: string, enum: [active, blocked]}
+    put:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+                age: {type: integer}
+                tags: {type: array, items: {type: object, properties: {key: {type: string}}}}
+      responses: {"200": {description: ok}}
+  /legacy:
+    get:
+      responses: {"200": {description: ok}}
+`
+
+const diffNew = `openapi: 3.0.3
+info: {title: t, version: "2"}
+paths:
+  /users/{userId}:
+    get:
+      parameters:
+        - {name: userId, in: path, required: true, schema: {type: integer}}
+        - {name: fields, in: query, required: true, schema: {type: string}}
+        - {name: sort, in: query, schema: {type: string, enum: [asc]}}
+        - {name: page, in: query, schema: {type: integer}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id: {type: string}
+                  name: {type: string}
+                  status: {type: string, enum: [active, blocked, deleted]}
+                  created: {type: string}
+    put:
+      parameters:
+        - {name: userId, in: path, required: true, schema: {type: integer}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name, age, email]
+              properties:
+                name: {type: string}
+                age: {type: integer}
+                email: {type: string}
+                nick: {type: string}
+                tags: {type: array, items: {type: object, required: [key], properties: {key: {type: string}}}}
+      responses: {"200": {description: ok}}
+  /health:
+    get:
+      responses: {"200": {description: ok}}
+`
+
+func TestDiffSpecs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"old.yaml": diffOld, "new.yaml": diffNew})
+	oldEps, _, err := LoadOpenAPI(filepath.Join(dir, "old.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newEps, _, err := LoadOpenAPI(filepath.Join(dir, "new.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := DiffSpecs(oldEps, newEps)
+	got := map[string]bool{}
+	for _, c := range d.Changes {
+		got[c.Method+" "+string(c.Kind)+" "+c.Location] = c.Breaking
+	}
+	want := map[string]bool{
+		"GET endpoint_removed ":                           true,
+		"GET endpoint_added ":                             false,
+		"GET param_required query:fields":                 true,
+		"GET enum_narrowed query:sort":                    true,
+		"GET param_added query:page":                      false,
+		"GET type_changed response 200 $.id":              true,
+		"GET response_field_removed response 200 $.email": true,
+		"GET response_field_added response 200 $.created": false,
+		"GET enum_widened response 200 $.status":          true,
+		"PUT request_field_required request $.age":        true,
+		"PUT request_field_required request $.email":      true,
+		"PUT request_field_added request $.nick":          false,
+		"PUT request_field_required request $.tags[].key": true,
+	}
+	for k, breaking := range want {
+		b, ok := got[k]
+		if !ok {
+			t.Errorf("missing change %q", k)
+			continue
+		}
+		if b != breaking {
+			t.Errorf("%q breaking=%v, want %v", k, b, breaking)
+		}
+	}
+	for k := range got {
+		if _, ok := want[k]; !ok {
+			t.Errorf("unexpected change %q", k)
+		}
+	}
+	if d.Breaking != 9 || !d.Fails(SeverityBreaking) {
+		t.Errorf("breaking = %d, want 9", d.Breaking)
+	}
+	if same := DiffSpecs(oldEps, oldEps); len(same.Changes) != 0 || same.Fails(SeverityWarning) {
+		t.Errorf("diff of a spec with itself: %+v", same.Changes)
+	}
+}
+
+func TestDiffSpecsMatchesPathParamsByPosition(t *testing.T) {
+	spec := func(org, id string) string {
+		return `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /orgs/{` + org + `}/repos/{` + id + `}:
+    get:
+      parameters:
+        - {name: ` + org + `, in: path, required: true, schema: {type: string}}
+        - {name: ` + id + `, in: path, required: true, schema: {type: integer}}
+      responses: {"200": {description: ok}}
+`
+	}
+	dir := writeFiles(t, map[string]string{"old.yaml": spec("org", "id"), "new.yaml": spec("owner", "repoId")})
+	oldEps, _, err := LoadOpenAPI(filepath.Join(dir, "old.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newEps, _, err := LoadOpenAPI(filepath.Join(dir, "new.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same := DiffSpecs(oldEps, oldEps); len(same.Changes) != 0 {
+		t.Errorf("diff of a spec with itself: %+v", same.Changes)
+	}
+	if renamed := DiffSpecs(oldEps, newEps); len(renamed.Changes) != 0 {
+		t.Errorf("renamed path params must match by position: %+v", renamed.Changes)
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// RequestIssue is one way an outgoing request breaks the operation's contract.
+type RequestIssue struct {
+	In      string // path, query, header, cookie or body
+	Name    string // parameter name, or JSON pointer inside the body ("/items/0/qty")
+	Message string
+}
+
+// ValidateRequest checks req against the parameters and requestBody of ep using
+// kin-openapi request validation. Security requirements are not checked.
+// req.Body is consumed and restored, so the request can still be sent afterwards.
+func ValidateRequest(ep Endpoint, req *http.Request) []RequestIssue {
+	if ep.Schema == nil {
+		return nil
+	}
+	pathParams, ok := matchPathParams(ep.Path, req.URL)
+	if !ok {
+		return []RequestIssue{{In: "path", Message: "URL path " + req.URL.Path + " does not match " + ep.Path}}
+	}
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route: &routers.Route{
+			Spec:      &openapi3.T{},
+			Path:      ep.Path,
+			PathItem:  &openapi3.PathItem{Parameters: ep.PathItemParams},
+			Method:    ep.Method,
+			Operation: ep.Schema,
+		},
+		Options: &openapi3filter.Options{
+			MultiError:         true,
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+	err := openapi3filter.ValidateRequest(context.Background(), input)
+	if err == nil {
+		return nil
+	}
+	var out []RequestIssue
+	for _, e := range flattenErrors(err) {
+		out = append(out, requestIssues(e)...)
+	}
+	return out
+}
+
+// matchPathParams extracts template values from u. The template is matched against the end of
+// the URL path so a base URL with its own path prefix still works.
+func matchPathParams(template string, u *url.URL) (map[string]string, bool) {
+	names := templateNames(template)
+	pattern := regexp.QuoteMeta(template)
+	for _, n := range names {
+		pattern = strings.Replace(pattern, regexp.QuoteMeta("{"+n+"}"), "([^/]*)", 1)
+	}
+	re, err := regexp.Compile(pattern + "/?$")
+	if err != nil {
+		return nil, false
+	}
+	m := re.FindStringSubmatch(u.EscapedPath())
+	if m == nil {
+		return nil, false
+	}
+	out := make(map[string]string, len(names))
+	for i, n := range names {
+		v, err := url.PathUnescape(m[i+1])
+		if err != nil {
+			v = m[i+1]
+		}
+		out[n] = v
+	}
+	return out, true
+}
+
+// flattenErrors expands (nested) MultiErrors. Only the error itself is inspected, not its
+// wrap chain, so a RequestError wrapping a MultiError stays intact.
+func flattenErrors(err error) []error {
+	if me, ok := err.(openapi3.MultiError); ok {
+		var out []error
+		for _, e := range me {
+			out = append(out, flattenErrors(e)...)
+		}
+		return out
+	}
+	return []error{err}
+}
+
+// requestIssues turns one kin-openapi error into issues; body schema errors become one issue per field.
+func requestIssues(err error) []RequestIssue {
+	var re *openapi3filter.RequestError
+	if !errors.As(err, &re) {
+		return []RequestIssue{{Message: err.Error()}}
+	}
+	issue := RequestIssue{In: "body"}
+	if re.Parameter != nil {
+		issue.In, issue.Name = re.Parameter.In, re.Parameter.Name
+	}
+	if re.Err == nil {
+		issue.Message = re.Reason
+		return []RequestIssue{issue}
+	}
+	var out []RequestIssue
+	for _, e := range flattenErrors(re.Err) {
+		x := issue
+		var se *openapi3.SchemaError
+		if errors.As(e, &se) {
+			if x.In == "body" {
+				x.Name = "/" + strings.Join(se.JSONPointer(), "/")
+			}
+			x.Message = se.Reason
+		} else {
+			x.Message = e.Error()
+			if re.Reason != "" && !strings.Contains(x.Message, re.Reason) {
+				x.Message = re.Reason + ": " + x.Message
+			}
+		}
+		out = append(out, x)
+	}
+	return out
+}
@@ -0,0 +1,321 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// parseSpec parses a root document of any supported version into OpenAPI 3.0:
+// Swagger 2.0 is converted with openapi2conv, OpenAPI 3.1 is downgraded (see downgrade31)
+// and 3.0 is loaded as is. External refs are resolved relative to location.
+func parseSpec(loader *openapi3.Loader, data []byte, location *url.URL) (*openapi3.T, error) {
+	raw, err := yamlToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var head struct {
+		Swagger string `json:"swagger"`
+		OpenAPI string `json:"openapi"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, fmt.Errorf("spec root must be an object: %w", err)
+	}
+	switch {
+	case head.Swagger != "":
+		if !strings.HasPrefix(head.Swagger, "2.") {
+			return nil, fmt.Errorf("unsupported swagger version %q", head.Swagger)
+		}
+		var doc2 openapi2.T
+		if err := json.Unmarshal(raw, &doc2); err != nil {
+			return nil, fmt.Errorf("swagger 2.0: %w", err)
+		}
+		doc, err := openapi2conv.ToV3WithLoader(&doc2, loader, location)
+		if err != nil {
+			return nil, fmt.Errorf("convert swagger 2.0: %w", err)
+		}
+		return doc, nil
+	case strings.HasPrefix(head.OpenAPI, "3.1"):
+		var root map[string]interface{}
+		if err := json.Unmarshal(raw, &root); err != nil {
+			return nil, err
+		}
+		downgrade31(root)
+		if raw, err = json.Marshal(root); err != nil {
+			return nil, err
+		}
+		// Referenced fragments use the same 3.1 schema dialect.
+		read := loader.ReadFromURIFunc
+		loader.ReadFromURIFunc = func(l *openapi3.Loader, u *url.URL) ([]byte, error) {
+			b, err := read(l, u)
+			if err != nil {
+				return nil, err
+			}
+			frag, err := yamlToJSON(b)
+			if err != nil {
+				return b, nil
+			}
+			var v interface{}
+			if json.Unmarshal(frag, &v) != nil {
+				return b, nil
+			}
+			downgradeFragment(v)
+			return json.Marshal(v)
+		}
+	}
+	return loader.LoadFromDataWithPath(raw, location)
+}
+
+// yamlToJSON converts a YAML (or JSON) document to JSON, stringifying non-string map keys
+// such as unquoted status codes.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonCompatible(v))
+}
+
+func jsonCompatible(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = jsonCompatible(e)
+		}
+		return x
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			out[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return out
+	case []interface{}:
+		for i, e := range x {
+			x[i] = jsonCompatible(e)
+		}
+		return x
+	}
+	return v
+}
+
+// downgrade31 rewrites an OpenAPI 3.1 document into the 3.0 shape kin-openapi understands.
+// Constructs without a 3.0 equivalent (webhooks, jsonSchemaDialect, license identifiers)
+// are dropped; they do not affect requests or response checks.
+func downgrade31(root map[string]interface{}) {
+	root["openapi"] = "3.0.3"
+	delete(root, "jsonSchemaDialect")
+	delete(root, "webhooks")
+	if _, ok := root["paths"]; !ok {
+		root["paths"] = map[string]interface{}{}
+	}
+	if info, ok := root["info"].(map[string]interface{}); ok {
+		delete(info, "summary")
+		if lic, ok := info["license"].(map[string]interface{}); ok {
+			delete(lic, "identifier")
+		}
+	}
+	if comps, ok := root["components"].(map[string]interface{}); ok {
+		delete(comps, "pathItems")
+	}
+	downgradeDocument(root)
+}
+
+// downgradeDocument rewrites every schema of an OpenAPI document (see downgradeSchema).
+// Only schema positions are visited, so component names, examples, enums and defaults that
+// happen to look like schema keywords are left alone.
+func downgradeDocument(root map[string]interface{}) {
+	for _, item := range asMap(root["paths"]) {
+		downgradePathItem(item)
+	}
+	comps := asMap(root["components"])
+	for _, sch := range asMap(comps["schemas"]) {
+		downgradeSchema(sch)
+	}
+	for _, prm := range asMap(comps["parameters"]) {
+		downgradeParameter(prm)
+	}
+	for _, h := range asMap(comps["headers"]) {
+		downgradeParameter(h)
+	}
+	for _, body := range asMap(comps["requestBodies"]) {
+		downgradeContent(asMap(body)["content"])
+	}
+	for _, resp := range asMap(comps["responses"]) {
+		downgradeResponse(resp)
+	}
+	for _, cb := range asMap(comps["callbacks"]) {
+		for _, item := range asMap(cb) {
+			downgradePathItem(item)
+		}
+	}
+}
+
+// downgradeFragment rewrites an externally referenced file: a whole document, a single
+// schema, parameter or response, or a map of named ones.
+func downgradeFragment(v interface{}) {
+	m := asMap(v)
+	switch {
+	case m == nil:
+	case m["openapi"] != nil || m["paths"] != nil || m["components"] != nil:
+		downgradeDocument(m)
+	case isSchema(m):
+		downgradeSchema(m)
+	case m["in"] != nil:
+		downgradeParameter(m)
+	case m["content"] != nil || m["headers"] != nil:
+		downgradeResponse(m)
+	default:
+		for _, e := range m {
+			downgradeFragment(e)
+		}
+	}
+}
+
+// isSchema reports whether m has a JSON Schema keyword.
+func isSchema(m map[string]interface{}) bool {
+	for _, k := range []string{"type", "$ref", "properties", "items", "allOf", "anyOf", "oneOf", "not", "enum", "const", "format", "additionalProperties"} {
+		if _, ok := m[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func downgradePathItem(v interface{}) {
+	item := asMap(v)
+	for _, prm := range asList(item["parameters"]) {
+		downgradeParameter(prm)
+	}
+	for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+		op := asMap(item[method])
+		if op == nil {
+			continue
+		}
+		for _, prm := range asList(op["parameters"]) {
+			downgradeParameter(prm)
+		}
+		downgradeContent(asMap(op["requestBody"])["content"])
+		for _, resp := range asMap(op["responses"]) {
+			downgradeResponse(resp)
+		}
+		for _, cb := range asMap(op["callbacks"]) {
+			for _, cbItem := range asMap(cb) {
+				downgradePathItem(cbItem)
+			}
+		}
+	}
+}
+
+// downgradeParameter rewrites the schemas of a parameter or header.
+func downgradeParameter(v interface{}) {
+	m := asMap(v)
+	downgradeSchema(m["schema"])
+	downgradeContent(m["content"])
+}
+
+func downgradeResponse(v interface{}) {
+	m := asMap(v)
+	for _, h := range asMap(m["headers"]) {
+		downgradeParameter(h)
+	}
+	downgradeContent(m["content"])
+}
+
+// downgradeContent rewrites the schemas of a content map (media type -> media type object).
+func downgradeContent(v interface{}) {
+	for _, mt := range asMap(v) {
+		m := asMap(mt)
+		downgradeSchema(m["schema"])
+		for _, enc := range asMap(m["encoding"]) {
+			for _, h := range asMap(asMap(enc)["headers"]) {
+				downgradeParameter(h)
+			}
+		}
+	}
+}
+
+// downgradeSchema rewrites JSON Schema 2020-12 keywords of a schema and its subschemas into
+// their 3.0 forms: type arrays with "null" become nullable, numeric exclusiveMinimum/Maximum
+// become minimum/maximum plus the boolean flag, const becomes a one-value enum and schema
+// examples (a list) become example. Literal values (example, enum, default) are not walked.
+func downgradeSchema(v interface{}) {
+	m := asMap(v)
+	if m == nil {
+		return
+	}
+	for _, k := range []string{"items", "not", "additionalProperties", "contains", "propertyNames", "if", "then", "else"} {
+		downgradeSchema(m[k])
+	}
+	for _, k := range []string{"properties", "patternProperties", "$defs", "definitions"} {
+		for _, sub := range asMap(m[k]) {
+			downgradeSchema(sub)
+		}
+	}
+	for _, k := range []string{"allOf", "anyOf", "oneOf"} {
+		for _, sub := range asList(m[k]) {
+			downgradeSchema(sub)
+		}
+	}
+	downgradeSchemaNode(m)
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asList(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+func downgradeSchemaNode(m map[string]interface{}) {
+	if types, ok := m["type"].([]interface{}); ok {
+		var kept []interface{}
+		for _, t := range types {
+			if t == "null" {
+				m["nullable"] = true
+				continue
+			}
+			kept = append(kept, t)
+		}
+		switch len(kept) {
+		case 0:
+			delete(m, "type")
+		case 1:
+			m["type"] = kept[0]
+		default:
+			m["type"] = kept
+		}
+	} else if m["type"] == "null" {
+		delete(m, "type")
+		m["nullable"] = true
+	}
+	for _, k := range [][2]string{{"exclusiveMinimum", "minimum"}, {"exclusiveMaximum", "maximum"}} {
+		if n, ok := m[k[0]].(float64); ok {
+			m[k[1]] = n
+			m[k[0]] = true
+		}
+	}
+	if c, ok := m["const"]; ok {
+		if _, has := m["enum"]; !has {
+			m["enum"] = []interface{}{c}
+		}
+		delete(m, "const")
+	}
+	if ex, ok := m["examples"].([]interface{}); ok {
+		if _, has := m["example"]; !has && len(ex) > 0 {
+			m["example"] = ex[0]
+		}
+		delete(m, "examples")
+	}
+	for _, k := range []string{"$schema", "$id", "$comment", "$anchor", "prefixItems", "contentMediaType", "contentEncoding", "unevaluatedProperties", "unevaluatedItems", "dependentRequired", "dependentSchemas"} {
+		delete(m, k)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"lazytest/internal/appsvc"
+	"lazytest/internal/core"
 )
 
 type App struct {
@@ -99,6 +100,9 @@ func (a *App) BuildExampleRequest(endpointID, envName, authProfile string, overr
 func (a *App) SendRequest(req appsvc.RequestDTO) (appsvc.ResponseDTO, error) {
 	return a.svc.SendRequest(req)
 }
+func (a *App) ValidateRequest(req appsvc.RequestDTO) ([]appsvc.RequestIssueDTO, error) {
+	return a.svc.ValidateRequest(req)
+}
 
 // Run use-cases (Smoke/Drift/Compare/LT/TCP) are forwarded to app-service.
 func (a *App) StartSmoke(cfg appsvc.SmokeStartConfig) (string, error) {
@@ -121,6 +125,7 @@ func (a *App) StartTCP(planPath string, cfg appsvc.TCPStartConfig) (string, erro
 func (a *App) CancelRun(runID string) bool                         { return a.svc.CancelRun(runID) }
 func (a *App) GetRunResult(runID string) (appsvc.ResultDTO, error) { return a.svc.GetRunResult(runID) }
 func (a *App) ListReports() []appsvc.ResultDTO                     { return a.svc.ListHistory() }
+func (a *App) Coverage() core.Coverage                             { return a.svc.Coverage() }
 
 func (a *App) OpenFileDialog(pattern string) (string, error) {
 	_ = pattern
@@ -7,6 +7,7 @@ import (
 	"errors"
 
 	"lazytest/internal/appsvc"
+	"lazytest/internal/core"
 )
 
 type App struct {
@@ -31,6 +32,9 @@ func (a *App) BuildExampleRequest(endpointID, envName, authProfile string, overr
 func (a *App) SendRequest(req appsvc.RequestDTO) (appsvc.ResponseDTO, error) {
 	return a.svc.SendRequest(req)
 }
+func (a *App) ValidateRequest(req appsvc.RequestDTO) ([]appsvc.RequestIssueDTO, error) {
+	return a.svc.ValidateRequest(req)
+}
 func (a *App) StartSmoke(cfg appsvc.SmokeStartConfig) (string, error) {
 	return a.svc.StartSmoke(cfg, a.workspace.EnvName, a.workspace.AuthProfile, a.workspace.BaseURL)
 }
@@ -49,6 +53,7 @@ func (a *App) StartTCP(planPath string, cfg appsvc.TCPStartConfig) (string, erro
 func (a *App) CancelRun(runID string) bool                         { return a.svc.CancelRun(runID) }
 func (a *App) GetRunResult(runID string) (appsvc.ResultDTO, error) { return a.svc.GetRunResult(runID) }
 func (a *App) ListReports() []appsvc.ResultDTO                     { return a.svc.ListHistory() }
+func (a *App) Coverage() core.Coverage                             { return a.svc.Coverage() }
 func (a *App) OpenFileDialog(pattern string) (string, error) {
 	return "", errors.New("desktop build tag required")
 }
@@ -10,6 +10,7 @@ import (
 
 	"lazytest/internal/appsvc"
 	"lazytest/internal/core"
+	"lazytest/internal/secrets"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -809,6 +810,7 @@ func (ui *fyneUI) sendRequest() {
 		Headers:   parseHeaderMap(ui.headersEntry.Text),
 		Body:      ui.bodyEntry.Text,
 		TimeoutMS: 15000,
+		EnvName:   strings.TrimSpace(ui.envNameEntry.Text),
 	}
 	if req.Method == "" || req.URL == "" {
 		ui.setError(fmt.Errorf("method and URL are required"), true)
@@ -988,7 +990,7 @@ func (ui *fyneUI) applyMetrics(e appsvc.RunMetricsEvent) {
 }
 
 func (ui *fyneUI) applyLog(e appsvc.RunLogEvent) {
-	line := fmt.Sprintf("[%s] %s", strings.ToUpper(e.Level), e.Msg)
+	line := secrets.Redact(fmt.Sprintf("[%s] %s", strings.ToUpper(e.Level), e.Msg))
 	if ui.activeRunKind == "smoke" {
 		appendEntryLine(ui.smokeLiveEntry, line, 8000)
 	}
@@ -1091,16 +1093,28 @@ func (ui *fyneUI) renderLastCompareResult() {
 			lines = append(lines, "- "+d)
 		}
 	}
+	if len(cmp.Patch) > 0 {
+		lines = append(lines, "", "Body patch (A -> B):")
+		for _, op := range cmp.Patch {
+			lines = append(lines, "- "+op.String())
+		}
+	}
+	if len(cmp.Ignored) > 0 {
+		lines = append(lines, "", "Ignored:")
+		for _, d := range cmp.Ignored {
+			lines = append(lines, "- "+d.Diff+" ("+d.Rule+")")
+		}
+	}
 	if ui.compareOnlyDiff.Checked {
 		filtered := make([]string, 0, len(lines))
 		for _, line := range lines {
 			trim := strings.TrimSpace(line)
-			if strings.HasPrefix(trim, "Status:") && cmp.StatusMatch && len(cmp.HeadersDiff) == 0 && len(cmp.BodyStructureDiff) == 0 && len(cmp.BodyValueDiff) == 0 && cmp.ErrA == "" && cmp.ErrB == "" {
+			if strings.HasPrefix(trim, "Status:") && !cmp.Differs() {
 				continue
 			}
 			filtered = append(filtered, line)
 		}
-		if len(cmp.HeadersDiff) == 0 && len(cmp.BodyStructureDiff) == 0 && len(cmp.BodyValueDiff) == 0 && cmp.ErrA == "" && cmp.ErrB == "" && cmp.StatusMatch {
+		if !cmp.Differs() {
 			filtered = append(filtered, "No differences")
 		}
 		lines = filtered
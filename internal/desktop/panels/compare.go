@@ -12,6 +12,7 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"lazytest/internal/appsvc"
+	"lazytest/internal/core"
 	"lazytest/internal/desktop/widgets"
 )
 
@@ -22,8 +23,14 @@ type ComparePanel struct {
 	onStart func(string, string)
 
 	endpoint  *widget.SelectEntry
+	runAll    *widget.Check
+	tag       *widget.Entry
+	method    *widget.SelectEntry
+	export    *widget.Entry
 	envA      *widget.Entry
 	envB      *widget.Entry
+	authA     *widget.Entry
+	authB     *widget.Entry
 	onlyDiff  *widget.Check
 	timeout   *widget.Entry
 	progress  *widgets.ProgressCard
@@ -39,6 +46,12 @@ func NewComparePanel(app DesktopApp, state SharedState, status func(string), onS
 			return
 		}
 		p.progress.Set(s.Status, s.Progress.Done, s.Progress.Total)
+		if s.Status == "completed" {
+			if text := p.resultText(s.RunID); text != "" {
+				p.result.SetText(text)
+				return
+			}
+		}
 		if s.Summary != "" {
 			p.result.SetText(s.Summary)
 		}
@@ -48,10 +61,22 @@ func NewComparePanel(app DesktopApp, state SharedState, status func(string), onS
 
 func (p *ComparePanel) build() {
 	p.endpoint = widget.NewSelectEntry(nil)
+	p.runAll = widget.NewCheck("Compare all GET endpoints (or the tag/method filter)", nil)
+	p.tag = widget.NewEntry()
+	p.tag.SetPlaceHolder("tag filter (optional)")
+	p.method = widget.NewSelectEntry([]string{"", "GET", "POST", "PUT", "PATCH", "DELETE"})
+	p.method.SetPlaceHolder("method filter (default GET)")
+	p.export = widget.NewEntry()
+	p.export.SetText("./out")
+	p.export.SetPlaceHolder("compare.json, compare.junit.xml, compare.html")
 	p.envA = widget.NewEntry()
 	p.envA.SetText("dev")
 	p.envB = widget.NewEntry()
 	p.envB.SetText("test")
+	p.authA = widget.NewEntry()
+	p.authA.SetPlaceHolder("auth profile (default: workspace)")
+	p.authB = widget.NewEntry()
+	p.authB.SetPlaceHolder("auth profile (default: workspace)")
 	p.onlyDiff = widget.NewCheck("Only differences", nil)
 	p.onlyDiff.SetChecked(true)
 	p.timeout = widget.NewEntry()
@@ -63,13 +88,20 @@ func (p *ComparePanel) build() {
 		timeout := 10000
 		fmt.Sscanf(strings.TrimSpace(p.timeout.Text), "%d", &timeout)
 		cfg := appsvc.CompareStartConfig{
-			EndpointID: strings.TrimSpace(p.endpoint.Text),
-			EnvA:       strings.TrimSpace(p.envA.Text),
-			EnvB:       strings.TrimSpace(p.envB.Text),
-			OnlyDiff:   p.onlyDiff.Checked,
-			TimeoutMS:  timeout,
+			EndpointID:   strings.TrimSpace(p.endpoint.Text),
+			EnvA:         strings.TrimSpace(p.envA.Text),
+			EnvB:         strings.TrimSpace(p.envB.Text),
+			AuthProfileA: strings.TrimSpace(p.authA.Text),
+			AuthProfileB: strings.TrimSpace(p.authB.Text),
+			OnlyDiff:     p.onlyDiff.Checked,
+			TimeoutMS:    timeout,
+			ExportDir:    strings.TrimSpace(p.export.Text),
 		}
-		if cfg.EndpointID == "" {
+		if p.runAll.Checked {
+			cfg.EndpointID = ""
+			cfg.Tag = strings.TrimSpace(p.tag.Text)
+			cfg.Method = strings.TrimSpace(p.method.Text)
+		} else if cfg.EndpointID == "" {
 			p.status("compare: endpoint is required")
 			return
 		}
@@ -85,11 +117,17 @@ func (p *ComparePanel) build() {
 	p.container = container.NewScroll(container.NewVBox(
 		widget.NewLabelWithStyle("A/B Compare", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		widget.NewForm(
+			widget.NewFormItem("Run Mode", p.runAll),
 			widget.NewFormItem("Endpoint", p.endpoint),
+			widget.NewFormItem("Tag", p.tag),
+			widget.NewFormItem("Method", p.method),
 			widget.NewFormItem("Env A", p.envA),
+			widget.NewFormItem("Auth A", p.authA),
 			widget.NewFormItem("Env B", p.envB),
+			widget.NewFormItem("Auth B", p.authB),
 			widget.NewFormItem("Only Diff", p.onlyDiff),
 			widget.NewFormItem("Timeout(ms)", p.timeout),
+			widget.NewFormItem("Export Dir", p.export),
 		),
 		startBtn,
 		p.progress.Container(),
@@ -108,6 +146,53 @@ func (p *ComparePanel) refreshEndpoints() {
 	p.endpoint.SetOptions(ids)
 }
 
+// resultText renders the differences and body patches of a finished compare run; with Only
+// Diff, endpoints without differences are left out.
+func (p *ComparePanel) resultText(runID string) string {
+	var results []core.ABCompareResult
+	for _, r := range p.app.ListReports() {
+		if r.RunID != runID {
+			continue
+		}
+		switch v := r.Data.(type) {
+		case core.ABCompareResult:
+			results = []core.ABCompareResult{v}
+		case []core.ABCompareResult:
+			results = v
+		}
+		break
+	}
+	if results == nil {
+		return ""
+	}
+	var lines []string
+	for _, r := range results {
+		if p.onlyDiff.Checked && !r.Differs() {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: Status A=%d B=%d", r.Name(), r.StatusA, r.StatusB))
+		if r.ErrA != "" {
+			lines = append(lines, "  A error: "+r.ErrA)
+		}
+		if r.ErrB != "" {
+			lines = append(lines, "  B error: "+r.ErrB)
+		}
+		for _, d := range r.HeadersDiff {
+			lines = append(lines, "  [header] "+d)
+		}
+		for _, op := range r.Patch {
+			lines = append(lines, "  "+op.String())
+		}
+		if len(r.Ignored) > 0 {
+			lines = append(lines, fmt.Sprintf("  %d difference(s) ignored by compare rules", len(r.Ignored)))
+		}
+	}
+	if len(lines) == 0 {
+		return "No differences"
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (p *ComparePanel) Container() fyne.CanvasObject { return p.container }
 func (p *ComparePanel) OnShow()                      { p.refreshEndpoints() }
 func (p *ComparePanel) OnHide()                      {}
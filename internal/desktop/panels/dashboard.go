@@ -29,6 +29,7 @@ type methodCount struct {
 
 // DashboardPanel displays a richer command-center style dashboard.
 type DashboardPanel struct {
+	app      DesktopApp
 	state    SharedState
 	navigate func(string)
 
@@ -37,6 +38,7 @@ type DashboardPanel struct {
 	runCard       *widgets.MetricCard
 	successCard   *widgets.MetricCard
 	qualityCard   *widgets.MetricCard
+	coverageCard  *widgets.MetricCard
 
 	selectedInfo  *widget.Label
 	workspaceInfo *widget.Label
@@ -44,6 +46,7 @@ type DashboardPanel struct {
 	progress      *widget.ProgressBar
 	progressMeta  *widget.Label
 	qualityGate   *canvas.Text
+	coverageInfo  *widget.Label
 
 	methodRows []methodCount
 	methodList *widget.List
@@ -54,8 +57,8 @@ type DashboardPanel struct {
 	container fyne.CanvasObject
 }
 
-func NewDashboardPanel(state SharedState, navigate func(string)) *DashboardPanel {
-	p := &DashboardPanel{state: state, navigate: navigate}
+func NewDashboardPanel(app DesktopApp, state SharedState, navigate func(string)) *DashboardPanel {
+	p := &DashboardPanel{app: app, state: state, navigate: navigate}
 	p.buildUI()
 
 	state.OnSpecLoad(func(_ *appsvc.SpecSummary) { p.refresh() })
@@ -84,12 +87,14 @@ func (p *DashboardPanel) buildUI() {
 	p.runCard = widgets.NewMetricCard("Run", "IDLE", "No active run", color.RGBA{R: 0x6C, G: 0x4A, B: 0x1E, A: 0xFF})
 	p.successCard = widgets.NewMetricCard("Success Ratio", "n/a", "No assertions yet", color.RGBA{R: 0x1E, G: 0x3D, B: 0x67, A: 0xFF})
 	p.qualityCard = widgets.NewMetricCard("Error / p95", "n/a", "Awaiting metrics", color.RGBA{R: 0x4A, G: 0x2A, B: 0x66, A: 0xFF})
+	p.coverageCard = widgets.NewMetricCard("Coverage", "n/a", "No runs yet", color.RGBA{R: 0x1E, G: 0x5A, B: 0x5E, A: 0xFF})
 	metrics := widgets.CreateMetricCardGrid(
 		p.specCard,
 		p.endpointsCard,
 		p.runCard,
 		p.successCard,
 		p.qualityCard,
+		p.coverageCard,
 	)
 
 	p.workspaceInfo = widget.NewLabel("Workspace metadata unavailable")
@@ -108,6 +113,10 @@ func (p *DashboardPanel) buildUI() {
 	p.qualityGate.TextStyle = fyne.TextStyle{Bold: true, Monospace: true}
 	p.qualityGate.TextSize = 11
 
+	p.coverageInfo = widget.NewLabel("No spec loaded")
+	p.coverageInfo.Wrapping = fyne.TextWrapWord
+	p.coverageInfo.TextStyle = fyne.TextStyle{Monospace: true}
+
 	p.methodList = p.newMethodList()
 	p.rpsChart = widgets.NewLineChart(80)
 	p.errChart = widgets.NewLineChart(80)
@@ -144,6 +153,7 @@ func (p *DashboardPanel) buildUI() {
 		p.panelCard("Quick Actions", quickActions),
 		p.panelCard("Workspace Context", p.workspaceInfo),
 		p.panelCard("Endpoint Method Mix", methodBox),
+		p.panelCard("Tag Coverage", p.coverageInfo),
 	)
 	rightCol := container.NewVBox(
 		p.panelCard("Run Telemetry", container.NewVBox(p.runInfo, p.progress, p.progressMeta, telemetry, p.qualityGate)),
@@ -264,6 +274,30 @@ func (p *DashboardPanel) refresh() {
 	s := p.state.GetRunSnapshot()
 	p.updateRunCards(s)
 	p.updateRunTelemetry(s)
+	if s.Status != "running" {
+		p.updateCoverage()
+	}
+}
+
+// updateCoverage shows how much of the loaded spec the run history has exercised.
+func (p *DashboardPanel) updateCoverage() {
+	if p.app == nil {
+		return
+	}
+	cov := p.app.Coverage()
+	if cov.OperationsTotal == 0 {
+		p.coverageCard.SetValue("n/a")
+		p.coverageCard.SetSubtitle("No spec loaded")
+		p.coverageInfo.SetText("No spec loaded")
+		return
+	}
+	p.coverageCard.SetValue(fmt.Sprintf("%.1f%%", cov.OperationsPct))
+	p.coverageCard.SetSubtitle(fmt.Sprintf("ops %d/%d | resp %.0f%% | params %.0f%%", cov.OperationsHit, cov.OperationsTotal, cov.ResponsesPct, cov.ParamsPct))
+	lines := make([]string, 0, len(cov.Tags))
+	for _, t := range cov.Tags {
+		lines = append(lines, fmt.Sprintf("%-16s ops %5.1f%% (%d/%d)  resp %5.1f%%", t.Tag, t.OperationsPct, t.OperationsHit, t.Operations, t.ResponsesPct))
+	}
+	p.coverageInfo.SetText(strings.Join(lines, "\n"))
 }
 
 func (p *DashboardPanel) updateRunCards(s appsvc.RunSnapshot) {
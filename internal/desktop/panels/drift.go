@@ -22,6 +22,9 @@ type DriftPanel struct {
 	onStart func(string, string)
 
 	endpoint  *widget.SelectEntry
+	runAll    *widget.Check
+	tag       *widget.Entry
+	method    *widget.SelectEntry
 	timeout   *widget.Entry
 	export    *widget.Entry
 	progress  *widgets.ProgressCard
@@ -46,6 +49,11 @@ func NewDriftPanel(app DesktopApp, state SharedState, status func(string), onSta
 
 func (p *DriftPanel) build() {
 	p.endpoint = widget.NewSelectEntry(nil)
+	p.runAll = widget.NewCheck("Check all endpoints", nil)
+	p.tag = widget.NewEntry()
+	p.tag.SetPlaceHolder("tag filter (optional)")
+	p.method = widget.NewSelectEntry([]string{"", "GET", "POST", "PUT", "PATCH", "DELETE"})
+	p.method.SetPlaceHolder("method filter (optional)")
 	p.timeout = widget.NewEntry()
 	p.timeout.SetText("10000")
 	p.export = widget.NewEntry()
@@ -55,13 +63,21 @@ func (p *DriftPanel) build() {
 
 	startBtn := widget.NewButton("Run Drift", func() {
 		endpoint := strings.TrimSpace(p.endpoint.Text)
-		if endpoint == "" {
+		if p.runAll.Checked {
+			endpoint = ""
+		} else if endpoint == "" {
 			p.status("drift: endpoint is required")
 			return
 		}
 		timeout := 10000
 		fmt.Sscanf(strings.TrimSpace(p.timeout.Text), "%d", &timeout)
-		runID, err := p.app.StartDrift(appsvc.DriftStartConfig{EndpointID: endpoint, TimeoutMS: timeout, ExportDir: strings.TrimSpace(p.export.Text)})
+		runID, err := p.app.StartDrift(appsvc.DriftStartConfig{
+			EndpointID: endpoint,
+			TimeoutMS:  timeout,
+			ExportDir:  strings.TrimSpace(p.export.Text),
+			Tag:        strings.TrimSpace(p.tag.Text),
+			Method:     strings.TrimSpace(p.method.Text),
+		})
 		if err != nil {
 			p.status("drift start failed: " + err.Error())
 			return
@@ -73,7 +89,10 @@ func (p *DriftPanel) build() {
 	p.container = container.NewScroll(container.NewVBox(
 		widget.NewLabelWithStyle("Drift Analysis", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		widget.NewForm(
+			widget.NewFormItem("Run Mode", p.runAll),
 			widget.NewFormItem("Endpoint", p.endpoint),
+			widget.NewFormItem("Tag", p.tag),
+			widget.NewFormItem("Method", p.method),
 			widget.NewFormItem("Timeout(ms)", p.timeout),
 			widget.NewFormItem("Export Dir", p.export),
 		),
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -28,9 +29,15 @@ type ExplorerPanel struct {
 	detail   *widget.Label
 	headers  *widget.Entry
 	body     *widget.Entry
+	issues   *widget.Label
+	block    *widget.Check
 	respMeta *widget.Label
 	respBody *widget.Entry
 
+	// example is the request built for the selected endpoint; validation applies the edited
+	// headers/body to it instead of rebuilding it on every keystroke.
+	example *appsvc.RequestDTO
+
 	filtered  []appsvc.EndpointDTO
 	container fyne.CanvasObject
 }
@@ -81,6 +88,12 @@ func (p *ExplorerPanel) build() {
 	p.detail.Wrapping = fyne.TextWrapWord
 	p.headers = widget.NewMultiLineEntry()
 	p.body = widget.NewMultiLineEntry()
+	p.headers.OnChanged = func(string) { p.validate() }
+	p.body.OnChanged = func(string) { p.validate() }
+	p.issues = widget.NewLabel("")
+	p.issues.Wrapping = fyne.TextWrapWord
+	p.block = widget.NewCheck("Block requests that break the contract", nil)
+	p.block.SetChecked(true)
 	p.respMeta = widget.NewLabel("Response: -")
 	p.respBody = widget.NewMultiLineEntry()
 	p.respBody.Disable()
@@ -97,6 +110,8 @@ func (p *ExplorerPanel) build() {
 		widget.NewCard("Endpoint Detail", "", p.detail),
 		widget.NewCard("Headers (JSON)", "", p.headers),
 		widget.NewCard("Body", "", p.body),
+		p.issues,
+		p.block,
 		sendBtn,
 		widget.NewCard("Response", p.respMeta.Text, p.respBody),
 	)
@@ -135,11 +150,13 @@ func (p *ExplorerPanel) refreshFilter() {
 
 func (p *ExplorerPanel) loadExample(ep appsvc.EndpointDTO) {
 	ws := p.state.GetWorkspace()
-	req, err := p.app.BuildExampleRequest(ep.ID, ws.EnvName, ws.AuthProfile, map[string]string{"baseURL": ws.BaseURL})
+	req, err := p.app.BuildExampleRequest(ep.ID, ws.EnvName, ws.AuthProfile, exampleOverrides(ws))
 	if err != nil {
+		p.example = nil
 		p.status("example request error: " + err.Error())
 		return
 	}
+	p.example = &req
 	h := map[string]string{}
 	for k, v := range req.Headers {
 		h[k] = v
@@ -147,20 +164,39 @@ func (p *ExplorerPanel) loadExample(ep appsvc.EndpointDTO) {
 	b, _ := json.MarshalIndent(h, "", "  ")
 	p.headers.SetText(string(b))
 	p.body.SetText(req.Body)
+
+	lines := []string{fmt.Sprintf("%s\n%s\n%s", ep.ID, ep.OperationID, ep.Summary), "", req.Method + " " + req.URL}
+	for _, prm := range req.Params {
+		line := fmt.Sprintf("  %s %s = %s", prm.In, prm.Name, prm.Value)
+		if prm.Pinned {
+			line += " (pinned)"
+		}
+		lines = append(lines, line)
+	}
+	p.detail.SetText(strings.Join(lines, "\n"))
 }
 
-func (p *ExplorerPanel) sendRequest() {
+// exampleOverrides passes the workspace base URL and example seed to BuildExampleRequest.
+func exampleOverrides(ws appsvc.Workspace) map[string]string {
+	return map[string]string{"baseURL": ws.BaseURL, "seed": strconv.FormatInt(ws.ExampleSeed, 10)}
+}
+
+// currentRequest rebuilds the example request for the selected endpoint and applies the edited headers/body.
+func (p *ExplorerPanel) currentRequest() (appsvc.RequestDTO, error) {
 	ep := p.state.GetSelectedEndpoint()
 	if ep == nil {
-		p.status("Select an endpoint first")
-		return
+		return appsvc.RequestDTO{}, fmt.Errorf("select an endpoint first")
 	}
 	ws := p.state.GetWorkspace()
-	req, err := p.app.BuildExampleRequest(ep.ID, ws.EnvName, ws.AuthProfile, map[string]string{"baseURL": ws.BaseURL})
+	req, err := p.app.BuildExampleRequest(ep.ID, ws.EnvName, ws.AuthProfile, exampleOverrides(ws))
 	if err != nil {
-		p.status("request build error: " + err.Error())
-		return
+		return req, err
 	}
+	return p.withEdits(req), nil
+}
+
+// withEdits returns req with the edited headers/body.
+func (p *ExplorerPanel) withEdits(req appsvc.RequestDTO) appsvc.RequestDTO {
 	if strings.TrimSpace(p.headers.Text) != "" {
 		var m map[string]string
 		if err := json.Unmarshal([]byte(p.headers.Text), &m); err == nil {
@@ -168,6 +204,54 @@ func (p *ExplorerPanel) sendRequest() {
 		}
 	}
 	req.Body = p.body.Text
+	return req
+}
+
+// validate checks the edited example request against the spec and shows the issues inline.
+// It runs on every keystroke, so it reuses the request built when the endpoint was selected.
+func (p *ExplorerPanel) validate() {
+	if p.issues == nil || p.example == nil {
+		return
+	}
+	p.showIssues(p.withEdits(*p.example))
+}
+
+// showIssues validates req against the spec and shows the issues inline.
+func (p *ExplorerPanel) showIssues(req appsvc.RequestDTO) []appsvc.RequestIssueDTO {
+	issues, err := p.app.ValidateRequest(req)
+	if err != nil {
+		p.issues.SetText("validation unavailable: " + err.Error())
+		return nil
+	}
+	p.issues.SetText(formatIssues(issues))
+	return issues
+}
+
+func formatIssues(issues []appsvc.RequestIssueDTO) string {
+	if len(issues) == 0 {
+		return ""
+	}
+	lines := []string{fmt.Sprintf("Request breaks the contract (%d):", len(issues))}
+	for _, is := range issues {
+		where := is.In
+		if is.Name != "" {
+			where += " " + is.Name
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", where, is.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *ExplorerPanel) sendRequest() {
+	req, err := p.currentRequest()
+	if err != nil {
+		p.status("request build error: " + err.Error())
+		return
+	}
+	if issues := p.showIssues(req); len(issues) > 0 && p.block.Checked {
+		p.status(fmt.Sprintf("request blocked: %d contract issue(s)", len(issues)))
+		return
+	}
 	resp, err := p.app.SendRequest(req)
 	if err != nil {
 		p.respMeta.SetText("Request failed: " + err.Error())
@@ -2,7 +2,10 @@
 
 package panels
 
-import "lazytest/internal/appsvc"
+import (
+	"lazytest/internal/appsvc"
+	"lazytest/internal/core"
+)
 
 // DesktopApp defines desktop backend methods used by panels.
 // Java analogy: this behaves like a facade interface injected into each panel.
@@ -14,12 +17,14 @@ type DesktopApp interface {
 	ListEndpoints(filter appsvc.EndpointFilter) []appsvc.EndpointDTO
 	BuildExampleRequest(endpointID, envName, authProfile string, overrides map[string]string) (appsvc.RequestDTO, error)
 	SendRequest(req appsvc.RequestDTO) (appsvc.ResponseDTO, error)
+	ValidateRequest(req appsvc.RequestDTO) ([]appsvc.RequestIssueDTO, error)
 	StartSmoke(cfg appsvc.SmokeStartConfig) (string, error)
 	StartDrift(cfg appsvc.DriftStartConfig) (string, error)
 	StartCompare(cfg appsvc.CompareStartConfig) (string, error)
 	StartLT(planPath string, cfg appsvc.LTStartConfig) (string, error)
 	CancelRun(runID string) bool
 	ListReports() []appsvc.ResultDTO
+	Coverage() core.Coverage
 	SubscribeRun(runID string) (<-chan any, func())
 	TrackActiveRun(runID string)
 	CancelActiveRun() bool
@@ -25,6 +25,7 @@ type LoadTestPanel struct {
 	planPath  *widget.Entry
 	maxError  *widget.Entry
 	maxP95    *widget.Entry
+	useAuth   *widget.Check
 	progress  *widgets.ProgressCard
 	summary   *widgets.DiffViewer
 	container fyne.CanvasObject
@@ -52,6 +53,7 @@ func (p *LoadTestPanel) build() {
 	p.maxError.SetText("1.0")
 	p.maxP95 = widget.NewEntry()
 	p.maxP95.SetText("1000")
+	p.useAuth = widget.NewCheck("Send workspace auth profile (OAuth2 tokens refresh during the run)", nil)
 	p.progress = widgets.NewProgressCard("Load Test Progress")
 	p.summary = widgets.NewDiffViewer(10000)
 
@@ -80,7 +82,12 @@ func (p *LoadTestPanel) build() {
 		fmt.Sscanf(strings.TrimSpace(p.maxError.Text), "%f", &maxErr)
 		maxP95 := int64(1000)
 		fmt.Sscanf(strings.TrimSpace(p.maxP95.Text), "%d", &maxP95)
-		runID, err := p.app.StartLT(planPath, appsvc.LTStartConfig{MaxErrorPct: maxErr, MaxP95Ms: maxP95})
+		cfg := appsvc.LTStartConfig{MaxErrorPct: maxErr, MaxP95Ms: maxP95}
+		if p.useAuth.Checked {
+			ws := p.state.GetWorkspace()
+			cfg.EnvName, cfg.AuthProfile = ws.EnvName, ws.AuthProfile
+		}
+		runID, err := p.app.StartLT(planPath, cfg)
 		if err != nil {
 			p.status("load test start failed: " + err.Error())
 			return
@@ -102,6 +109,7 @@ func (p *LoadTestPanel) build() {
 			widget.NewFormItem("Max Error %", p.maxError),
 			widget.NewFormItem("Max p95(ms)", p.maxP95),
 		),
+		p.useAuth,
 		container.NewHBox(startBtn, cancelBtn),
 		p.progress.Container(),
 		widget.NewCard("Summary", "", p.summary.Container()),
@@ -24,6 +24,9 @@ type SmokePanel struct {
 	workers  *widget.Entry
 	timeout  *widget.Entry
 	export   *widget.Entry
+	expect   *widget.Select
+	contract *widget.Check
+	anon     *widget.Check
 	progress *widgets.ProgressCard
 	logs     *widgets.LogViewer
 	result   *widgets.DiffViewer
@@ -56,6 +59,10 @@ func (p *SmokePanel) build() {
 	p.timeout.SetText("10000")
 	p.export = widget.NewEntry()
 	p.export.SetText("./out")
+	p.expect = widget.NewSelect([]string{"documented", "strict", "legacy"}, nil)
+	p.expect.SetSelected("documented")
+	p.contract = widget.NewCheck("Validate response bodies against schema", nil)
+	p.anon = widget.NewCheck("Send without credentials (protected operations must answer 401/403)", nil)
 	p.endpointSelect = widget.NewSelectEntry([]string{})
 	p.endpointSelect.SetPlaceHolder("single endpoint id")
 	p.progress = widgets.NewProgressCard("Smoke Progress")
@@ -67,7 +74,7 @@ func (p *SmokePanel) build() {
 		fmt.Sscanf(strings.TrimSpace(p.workers.Text), "%d", &workers)
 		timeout := 10000
 		fmt.Sscanf(strings.TrimSpace(p.timeout.Text), "%d", &timeout)
-		cfg := appsvc.SmokeStartConfig{RunAll: p.runAll.Checked, Workers: workers, TimeoutMS: timeout, ExportDir: strings.TrimSpace(p.export.Text)}
+		cfg := appsvc.SmokeStartConfig{RunAll: p.runAll.Checked, Workers: workers, TimeoutMS: timeout, ExportDir: strings.TrimSpace(p.export.Text), Expect: p.expect.Selected, CheckContract: p.contract.Checked, Anonymous: p.anon.Checked}
 		if !p.runAll.Checked && strings.TrimSpace(p.endpointSelect.Text) != "" {
 			cfg.EndpointIDs = []string{strings.TrimSpace(p.endpointSelect.Text)}
 		}
@@ -91,6 +98,9 @@ func (p *SmokePanel) build() {
 		widget.NewFormItem("Endpoint", p.endpointSelect),
 		widget.NewFormItem("Workers", p.workers),
 		widget.NewFormItem("Timeout(ms)", p.timeout),
+		widget.NewFormItem("Expect", p.expect),
+		widget.NewFormItem("Contract", p.contract),
+		widget.NewFormItem("Anonymous", p.anon),
 		widget.NewFormItem("Export Dir", p.export),
 	)
 
@@ -5,6 +5,7 @@ package panels
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -27,6 +28,8 @@ type WorkspacePanel struct {
 	envName  *widget.Entry
 	baseURL  *widget.Entry
 	authProf *widget.Entry
+	seed     *widget.Entry
+	servers  *widget.Select // spec servers; picking one fills Base URL
 
 	container fyne.CanvasObject
 }
@@ -35,7 +38,9 @@ func NewWorkspacePanel(app DesktopApp, state SharedState, win fyne.Window, statu
 	p := &WorkspacePanel{app: app, state: state, win: win, status: status}
 	p.build()
 	p.syncFromState(state.GetWorkspace())
+	p.setServers(state.GetSpecSummary())
 	state.OnWorkspaceChange(func(ws appsvc.Workspace) { p.syncFromState(ws) })
+	state.OnSpecLoad(p.setServers)
 	return p
 }
 
@@ -46,6 +51,14 @@ func (p *WorkspacePanel) build() {
 	p.envName = widget.NewEntry()
 	p.baseURL = widget.NewEntry()
 	p.authProf = widget.NewEntry()
+	p.seed = widget.NewEntry()
+	p.seed.SetPlaceHolder("0")
+	p.servers = widget.NewSelect(nil, func(url string) {
+		if url != "" {
+			p.baseURL.SetText(url)
+		}
+	})
+	p.servers.PlaceHolder = "(load a spec with servers)"
 
 	pick := func(title string, entry *widget.Entry) *widget.Button {
 		return widget.NewButton("Browse", func() {
@@ -106,7 +119,9 @@ func (p *WorkspacePanel) build() {
 			widget.NewFormItem("Auth Config", authRow),
 			widget.NewFormItem("Environment", p.envName),
 			widget.NewFormItem("Base URL", p.baseURL),
+			widget.NewFormItem("Spec Servers", p.servers),
 			widget.NewFormItem("Auth Profile", p.authProf),
+			widget.NewFormItem("Example Seed", p.seed),
 		),
 		container.NewHBox(saveBtn, loadSpecBtn),
 	)
@@ -121,15 +136,26 @@ func (p *WorkspacePanel) readWorkspace() (appsvc.Workspace, error) {
 	if filepath.Ext(spec) == "" {
 		return appsvc.Workspace{}, fmt.Errorf("OpenAPI spec path looks invalid")
 	}
-	return appsvc.Workspace{
-		Version:     1,
-		SpecPath:    spec,
-		EnvPath:     strings.TrimSpace(p.envPath.Text),
-		AuthPath:    strings.TrimSpace(p.authPath.Text),
-		EnvName:     strings.TrimSpace(p.envName.Text),
-		BaseURL:     strings.TrimSpace(p.baseURL.Text),
-		AuthProfile: strings.TrimSpace(p.authProf.Text),
-	}, nil
+	var seed int64
+	if v := strings.TrimSpace(p.seed.Text); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return appsvc.Workspace{}, fmt.Errorf("example seed must be an integer")
+		}
+		seed = n
+	}
+	// Start from the current workspace so fields this form does not edit (path params,
+	// token alias) survive a save.
+	ws := p.state.GetWorkspace()
+	ws.Version = 1
+	ws.SpecPath = spec
+	ws.EnvPath = strings.TrimSpace(p.envPath.Text)
+	ws.AuthPath = strings.TrimSpace(p.authPath.Text)
+	ws.EnvName = strings.TrimSpace(p.envName.Text)
+	ws.BaseURL = strings.TrimSpace(p.baseURL.Text)
+	ws.AuthProfile = strings.TrimSpace(p.authProf.Text)
+	ws.ExampleSeed = seed
+	return ws, nil
 }
 
 func (p *WorkspacePanel) syncFromState(ws appsvc.Workspace) {
@@ -139,6 +165,17 @@ func (p *WorkspacePanel) syncFromState(ws appsvc.Workspace) {
 	p.envName.SetText(ws.EnvName)
 	p.baseURL.SetText(ws.BaseURL)
 	p.authProf.SetText(ws.AuthProfile)
+	p.seed.SetText(strconv.FormatInt(ws.ExampleSeed, 10))
+}
+
+// setServers offers the servers of the loaded spec as Base URL choices.
+func (p *WorkspacePanel) setServers(summary *appsvc.SpecSummary) {
+	var urls []string
+	if summary != nil {
+		urls = summary.Servers
+	}
+	p.servers.ClearSelected()
+	p.servers.SetOptions(urls)
 }
 
 func (p *WorkspacePanel) OnShow()                      {}
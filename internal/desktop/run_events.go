@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"lazytest/internal/appsvc"
+	"lazytest/internal/secrets"
 )
 
 // RunEventAggregator normalizes run events for UI panels.
@@ -119,7 +120,7 @@ func (a *runAgg) appendLog(lines []string, line string) []string {
 	if line == "" {
 		return lines
 	}
-	lines = append(lines, line)
+	lines = append(lines, secrets.Redact(line))
 	if len(lines) > a.logLimit {
 		lines = lines[len(lines)-a.logLimit:]
 	}
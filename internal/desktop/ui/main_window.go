@@ -81,7 +81,7 @@ func (mw *MainWindow) setupUI() {
 	mw.statusBar.SetStatus("Ready")
 
 	mw.panelMap = map[string]Panel{
-		"Dashboard": panels.NewDashboardPanel(mw.state, func(id string) {
+		"Dashboard": panels.NewDashboardPanel(mw.app, mw.state, func(id string) {
 			if mw.nav != nil {
 				mw.nav.SelectItem(id)
 				return
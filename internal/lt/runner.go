@@ -37,6 +37,9 @@ type Runner struct {
 	Plan   *Plan
 	Config RunConfig
 	Metrics *Metrics
+	// Auth returns the credential headers of each request (nil: only the plan's headers).
+	// It is called per request so OAuth2 tokens are refreshed during long runs.
+	Auth func() (map[string]string, error)
 }
 
 // Run executes the plan until context is cancelled or hold-for elapses. Metrics are recorded.
@@ -105,6 +108,16 @@ func (r *Runner) Run(ctx context.Context) error {
 					for k, v := range req.Headers {
 						httpReq.Header.Set(k, ResolveVars(v, vars))
 					}
+					if r.Auth != nil {
+						auth, err := r.Auth()
+						if err != nil {
+							r.Metrics.Record(0, false, 0)
+							continue
+						}
+						for k, v := range auth {
+							httpReq.Header.Set(k, v)
+						}
+					}
 					if body != nil {
 						httpReq.Header.Set("Content-Type", "application/json")
 					}
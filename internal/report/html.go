@@ -0,0 +1,200 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"os"
+	"strings"
+
+	"lazytest/internal/core"
+)
+
+// maxAlignLines bounds the line alignment of side-by-side bodies; larger bodies are shown
+// line by line without alignment.
+const maxAlignLines = 2000
+
+// DiffRow is one line of a side-by-side body diff.
+type DiffRow struct {
+	Left, Right string
+	Kind        string // same | changed | removed (only in A) | added (only in B)
+}
+
+// SideBySide aligns the lines of a and b (JSON is pretty-printed first) for a two-column view.
+func SideBySide(a, b string) []DiffRow {
+	la, lb := strings.Split(prettyJSON(a), "\n"), strings.Split(prettyJSON(b), "\n")
+	if len(la) > maxAlignLines || len(lb) > maxAlignLines {
+		var rows []DiffRow
+		for i := 0; i < len(la) || i < len(lb); i++ {
+			var r DiffRow
+			if i < len(la) {
+				r.Left = la[i]
+			}
+			if i < len(lb) {
+				r.Right = lb[i]
+			}
+			r.Kind = "same"
+			if r.Left != r.Right {
+				r.Kind = "changed"
+			}
+			rows = append(rows, r)
+		}
+		return rows
+	}
+	// Longest common subsequence of lines; unmatched runs on both sides pair up as changes.
+	lcs := make([][]int, len(la)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(lb)+1)
+	}
+	for i := len(la) - 1; i >= 0; i-- {
+		for j := len(lb) - 1; j >= 0; j-- {
+			if la[i] == lb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var rows []DiffRow
+	var removed, added []string
+	flush := func() {
+		for len(removed) > 0 || len(added) > 0 {
+			switch {
+			case len(removed) > 0 && len(added) > 0:
+				rows = append(rows, DiffRow{Left: removed[0], Right: added[0], Kind: "changed"})
+				removed, added = removed[1:], added[1:]
+			case len(removed) > 0:
+				rows = append(rows, DiffRow{Left: removed[0], Kind: "removed"})
+				removed = removed[1:]
+			default:
+				rows = append(rows, DiffRow{Right: added[0], Kind: "added"})
+				added = added[1:]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(la) || j < len(lb) {
+		switch {
+		case i < len(la) && j < len(lb) && la[i] == lb[j]:
+			flush()
+			rows = append(rows, DiffRow{Left: la[i], Right: lb[j], Kind: "same"})
+			i++
+			j++
+		case j >= len(lb) || (i < len(la) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, la[i])
+			i++
+		default:
+			added = append(added, lb[j])
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+func prettyJSON(s string) string {
+	var buf bytes.Buffer
+	if json.Indent(&buf, []byte(s), "", "  ") != nil {
+		return s
+	}
+	return buf.String()
+}
+
+// WriteHTMLCompare writes the A/B compare report rep (see CompareReportFromResults) as a
+// standalone HTML page: a table of all endpoints and, per endpoint, the differences and a
+// side-by-side diff of the bodies. Replay reports start with the per-endpoint aggregation.
+func WriteHTMLCompare(path string, rep *JSONReport) error {
+	type section struct {
+		Title  string
+		Status string
+		Open   bool
+		Result core.ABCompareResult
+		Rows   []DiffRow
+	}
+	rep = rep.redacted()
+	sum := rep.AB
+	if sum == nil {
+		sum = &ABSummary{}
+	}
+	data := struct {
+		Generated string
+		Summary   *ABSummary
+		Sections  []section
+	}{Generated: rep.Generated, Summary: sum}
+	for _, r := range sum.Results {
+		sec := section{Title: r.Name(), Result: r}
+		switch {
+		case r.ErrA != "" || r.ErrB != "":
+			sec.Status = "error"
+		case r.Differs():
+			sec.Status = "differ"
+		default:
+			sec.Status = "same"
+		}
+		sec.Open = sec.Status != "same"
+		if r.BodyA != "" || r.BodyB != "" {
+			sec.Rows = SideBySide(r.BodyA, r.BodyB)
+		}
+		data.Sections = append(data.Sections, sec)
+	}
+	var buf bytes.Buffer
+	if err := compareHTML.Execute(&buf, data); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+var compareHTML = template.Must(template.New("compare").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>lazytest A/B compare {{.Summary.EnvA}} vs {{.Summary.EnvB}}</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+.same { color: #2a7a2a; } .differ { color: #b26b00; } .error { color: #b00020; }
+table.diff { width: 100%; table-layout: fixed; font-family: monospace; font-size: 12px; }
+table.diff td { white-space: pre-wrap; word-break: break-all; border: none; }
+tr.changed td { background: #fff3cd; } tr.removed td.l { background: #f8d7da; } tr.added td.r { background: #d4edda; }
+.ignored { color: #777; }
+</style>
+</head>
+<body>
+<h1>A/B compare: {{.Summary.EnvA}} vs {{.Summary.EnvB}}</h1>
+<p>Generated {{.Generated}}: {{.Summary.Total}} endpoints, <span class="same">{{.Summary.Same}} same</span>,
+<span class="differ">{{.Summary.Differ}} differ</span>, <span class="error">{{.Summary.Errors}} errors</span>,
+{{.Summary.Ignored}} ignored differences.</p>
+{{if .Summary.Endpoints}}<h2>By endpoint</h2>
+<table>
+<tr><th>Endpoint</th><th>Requests</th><th>Same</th><th>Differ</th><th>Errors</th><th>Most frequent differences</th></tr>
+{{range .Summary.Endpoints}}<tr><td>{{.Method}} {{.Path}}</td><td>{{.Requests}}</td><td class="same">{{.Same}}</td><td class="differ">{{.Differ}}</td><td class="error">{{.Errors}}</td>
+<td>{{range .Diffs}}{{.Count}}x {{.Diff}}<br>{{end}}</td></tr>
+{{end}}</table>
+<h2>Requests</h2>{{end}}
+<table>
+<tr><th>Endpoint</th><th>Status A</th><th>Status B</th><th>Result</th></tr>
+{{range .Sections}}<tr><td>{{.Title}}</td><td>{{.Result.StatusA}}</td><td>{{.Result.StatusB}}</td><td class="{{.Status}}">{{.Status}}</td></tr>
+{{end}}</table>
+{{range .Sections}}
+<details{{if .Open}} open{{end}}>
+<summary class="{{.Status}}">{{.Title}}: {{.Status}}</summary>
+{{with .Result}}
+{{if .ErrA}}<p class="error">A: {{.ErrA}}</p>{{end}}
+{{if .ErrB}}<p class="error">B: {{.ErrB}}</p>{{end}}
+<ul>
+{{range .HeadersDiff}}<li>[header] {{.}}</li>{{end}}
+{{range .BodyStructureDiff}}<li>[struct] {{.}}</li>{{end}}
+{{range .BodyValueDiff}}<li>[value] {{.}}</li>{{end}}
+{{range .Ignored}}<li class="ignored">[ignored] {{.Diff}} ({{.Rule}})</li>{{end}}
+</ul>
+{{end}}
+{{if .Rows}}<table class="diff">
+<tr><th>A</th><th>B</th></tr>
+{{range .Rows}}<tr class="{{.Kind}}"><td class="l">{{.Left}}</td><td class="r">{{.Right}}</td></tr>
+{{end}}</table>{{end}}
+</details>
+{{end}}
+</body>
+</html>
+`))
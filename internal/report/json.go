@@ -2,6 +2,7 @@ package report
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"time"
 
@@ -11,12 +12,15 @@ import (
 
 // JSONReport is the root structure for JSON output.
 type JSONReport struct {
-	Generated string        `json:"generated"`
-	Duration  string        `json:"duration_seconds"`
-	Smoke     *SmokeSummary `json:"smoke,omitempty"`
-	Drift     *DriftSummary `json:"drift,omitempty"`
-	AB        *ABSummary    `json:"ab_compare,omitempty"`
-	TCP       *TCPSummary   `json:"tcp,omitempty"`
+	Generated string           `json:"generated"`
+	Duration  string           `json:"duration_seconds"`
+	Smoke     *SmokeSummary    `json:"smoke,omitempty"`
+	Drift     *DriftSummary    `json:"drift,omitempty"`
+	Fuzz      *FuzzSummary     `json:"fuzz,omitempty"`
+	AB        *ABSummary       `json:"ab_compare,omitempty"`
+	TCP       *TCPSummary      `json:"tcp,omitempty"`
+	SpecDiff  *SpecDiffSummary `json:"spec_diff,omitempty"`
+	Coverage  *core.Coverage   `json:"coverage,omitempty"`
 }
 
 // SmokeSummary summarizes smoke test results.
@@ -25,14 +29,29 @@ type SmokeSummary struct {
 	Passed  int                `json:"passed"`
 	Failed  int                `json:"failed"`
 	Results []core.SmokeResult `json:"results"`
+	// Contract counts are filled when responses were also checked against the schema.
+	ContractChecked int `json:"contract_checked,omitempty"`
+	ContractOK      int `json:"contract_ok,omitempty"`
+	ContractDrifted int `json:"contract_drifted,omitempty"`
 }
 
 // DriftSummary summarizes drift results.
 type DriftSummary struct {
-	Total   int                `json:"total"`
-	OK      int                `json:"ok"`
-	Drifted int                `json:"drifted"`
-	Results []core.DriftResult `json:"results"`
+	Total    int                `json:"total"`
+	OK       int                `json:"ok"`
+	Drifted  int                `json:"drifted"`
+	Breaking int                `json:"breaking"` // endpoints with at least one breaking finding
+	Results  []core.DriftResult `json:"results"`
+}
+
+// FuzzSummary summarizes fuzz results; Seed replays the same mutations.
+type FuzzSummary struct {
+	Seed         int64             `json:"seed"`
+	Total        int               `json:"total"`
+	Passed       int               `json:"passed"`
+	Failed       int               `json:"failed"`
+	ServerErrors int               `json:"server_errors"`
+	Results      []core.FuzzResult `json:"results"`
 }
 
 // TCPSummary summarizes tcp run results.
@@ -41,66 +60,160 @@ type TCPSummary struct {
 	Result tcp.Result `json:"result"`
 }
 
-// ABSummary summarizes A/B compare results.
+// ABSummary summarizes A/B compare results, one per endpoint or replayed request.
 type ABSummary struct {
-	Path   string               `json:"path"`
-	Method string               `json:"method"`
-	Result core.ABCompareResult `json:"result"`
+	EnvA    string                 `json:"env_a"`
+	EnvB    string                 `json:"env_b"`
+	Total   int                    `json:"total"`
+	Same    int                    `json:"same"`
+	Differ  int                    `json:"differ"`
+	Errors  int                    `json:"errors"`  // a side did not answer
+	Ignored int                    `json:"ignored"` // differences silenced by compare rules
+	Results []core.ABCompareResult `json:"results"`
+	// Endpoints groups replayed requests by the endpoint they matched (compare replay only).
+	Endpoints []core.CompareGroup `json:"endpoints,omitempty"`
 }
 
 // WriteJSON writes a JSON report to path.
 func WriteJSON(path string, r *JSONReport) error {
-	data, err := json.MarshalIndent(r, "", "  ")
+	data, err := json.MarshalIndent(r.redacted(), "", "  ")
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(path, data, 0644)
 }
 
+// ReadJSON reads a JSON report written by WriteJSON.
+func ReadJSON(path string) (*JSONReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r JSONReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// AddToCoverage records the results of r (smoke, drift, fuzz, A/B compare) in b.
+func AddToCoverage(b *core.CoverageBuilder, r *JSONReport) {
+	if r.Smoke != nil {
+		b.AddSmoke(r.Smoke.Results)
+	}
+	if r.Drift != nil {
+		b.AddDrift(r.Drift.Results)
+	}
+	if r.Fuzz != nil {
+		b.AddFuzz(r.Fuzz.Results)
+	}
+	if r.AB != nil {
+		for _, res := range r.AB.Results {
+			b.AddCompare(res)
+		}
+	}
+}
+
 // SmokeReportFromResults builds JSONReport from smoke results.
 func SmokeReportFromResults(results []core.SmokeResult, duration time.Duration) *JSONReport {
 	var passed, failed int
+	sum := &SmokeSummary{Total: len(results), Results: results}
 	for _, r := range results {
 		if r.OK {
 			passed++
 		} else {
 			failed++
 		}
+		if r.Contract != nil {
+			sum.ContractChecked++
+			if r.Contract.OK {
+				sum.ContractOK++
+			} else {
+				sum.ContractDrifted++
+			}
+		}
 	}
+	sum.Passed, sum.Failed = passed, failed
 	return &JSONReport{
 		Generated: time.Now().Format(time.RFC3339),
 		Duration:  duration.String(),
-		Smoke: &SmokeSummary{
-			Total:   len(results),
-			Passed:  passed,
-			Failed:  failed,
-			Results: results,
-		},
+		Smoke:     sum,
 	}
 }
 
 // DriftReportFromResults builds JSONReport from drift results.
 func DriftReportFromResults(results []core.DriftResult, duration time.Duration) *JSONReport {
-	var ok, drifted int
+	var ok, drifted, breaking int
 	for _, r := range results {
 		if r.OK {
 			ok++
 		} else {
 			drifted++
 		}
+		if r.Breaking() > 0 {
+			breaking++
+		}
 	}
 	return &JSONReport{
 		Generated: time.Now().Format(time.RFC3339),
 		Duration:  duration.String(),
 		Drift: &DriftSummary{
-			Total:   len(results),
-			OK:      ok,
-			Drifted: drifted,
-			Results: results,
+			Total:    len(results),
+			OK:       ok,
+			Drifted:  drifted,
+			Breaking: breaking,
+			Results:  results,
 		},
 	}
 }
 
+// FuzzReportFromResults builds JSONReport from fuzz results.
+func FuzzReportFromResults(results []core.FuzzResult, seed int64, duration time.Duration) *JSONReport {
+	sum := &FuzzSummary{Seed: seed, Total: len(results), Results: results}
+	for _, r := range results {
+		if r.OK {
+			sum.Passed++
+		} else {
+			sum.Failed++
+		}
+		if r.StatusCode >= 500 {
+			sum.ServerErrors++
+		}
+	}
+	return &JSONReport{
+		Generated: time.Now().Format(time.RFC3339),
+		Duration:  duration.String(),
+		Fuzz:      sum,
+	}
+}
+
+// CompareReportFromResults builds JSONReport from A/B compare results between envA and envB.
+// Replayed requests (see core.RunReplayCompare) are also grouped by endpoint.
+func CompareReportFromResults(envA, envB string, results []core.ABCompareResult, duration time.Duration) *JSONReport {
+	sum := &ABSummary{EnvA: envA, EnvB: envB, Total: len(results), Results: results}
+	replay := false
+	for _, r := range results {
+		switch {
+		case r.ErrA != "" || r.ErrB != "":
+			sum.Errors++
+		case r.Differs():
+			sum.Differ++
+		default:
+			sum.Same++
+		}
+		sum.Ignored += len(r.Ignored)
+		replay = replay || r.Request != ""
+	}
+	if replay {
+		sum.Endpoints = core.GroupCompareResults(results)
+	}
+	return &JSONReport{
+		Generated: time.Now().Format(time.RFC3339),
+		Duration:  duration.String(),
+		AB:        sum,
+	}
+}
+
 func TCPReportFromResult(result tcp.Result, duration time.Duration) *JSONReport {
 	return &JSONReport{
 		Generated: time.Now().Format(time.RFC3339),
@@ -108,3 +221,36 @@ func TCPReportFromResult(result tcp.Result, duration time.Duration) *JSONReport
 		TCP:       &TCPSummary{Plan: result.PlanName, Result: result},
 	}
 }
+
+// SpecDiffSummary summarizes a breaking-change diff between two spec versions.
+type SpecDiffSummary struct {
+	Old      string            `json:"old"`
+	New      string            `json:"new"`
+	Total    int               `json:"total"`
+	Breaking int               `json:"breaking"`
+	Changes  []core.SpecChange `json:"changes"`
+}
+
+// SpecDiffReport builds JSONReport from a spec diff.
+func SpecDiffReport(oldSpec, newSpec string, d core.SpecDiff, duration time.Duration) *JSONReport {
+	return &JSONReport{
+		Generated: time.Now().Format(time.RFC3339),
+		Duration:  duration.String(),
+		SpecDiff: &SpecDiffSummary{
+			Old:      oldSpec,
+			New:      newSpec,
+			Total:    len(d.Changes),
+			Breaking: d.Breaking,
+			Changes:  d.Changes,
+		},
+	}
+}
+
+// CoverageReport builds JSONReport from a coverage model.
+func CoverageReport(cov core.Coverage) *JSONReport {
+	return &JSONReport{
+		Generated: time.Now().Format(time.RFC3339),
+		Duration:  time.Duration(0).String(),
+		Coverage:  &cov,
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"lazytest/internal/core"
@@ -23,12 +25,19 @@ type JUnitTestSuites struct {
 
 // JUnitTestSuite represents one testsuite (e.g. smoke or drift).
 type JUnitTestSuite struct {
-	XMLName  xml.Name        `xml:"testsuite"`
-	Name     string          `xml:"name,attr"`
-	Tests    int             `xml:"tests,attr"`
-	Failures int             `xml:"failures,attr"`
-	Time     string          `xml:"time,attr"`
-	Cases    []JUnitTestCase `xml:"testcase"`
+	XMLName    xml.Name        `xml:"testsuite"`
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	Cases      []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitProperty is a suite-level key/value (e.g. the fuzz seed).
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 // JUnitTestCase is one test case.
@@ -38,6 +47,7 @@ type JUnitTestCase struct {
 	Classname string        `xml:"classname,attr"`
 	Time      string        `xml:"time,attr"`
 	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
 }
 
 // JUnitFailure holds failure message.
@@ -49,6 +59,7 @@ type JUnitFailure struct {
 
 // WriteJUnitSmoke writes smoke results to JUnit XML file.
 func WriteJUnitSmoke(path string, results []core.SmokeResult, duration time.Duration) error {
+	results = redactSmoke(results)
 	suite := JUnitTestSuite{
 		Name:  "lazytest-smoke",
 		Tests: len(results),
@@ -80,6 +91,23 @@ func WriteJUnitSmoke(path string, results []core.SmokeResult, duration time.Dura
 		Time:     fmt.Sprintf("%.3f", duration.Seconds()),
 		Suites:   []JUnitTestSuite{suite},
 	}
+	// Contract verdicts from the same pass go into their own suite, one testcase per response.
+	contract := JUnitTestSuite{Name: "lazytest-contract", Time: suite.Time}
+	for _, r := range results {
+		if r.Contract == nil {
+			continue
+		}
+		tc := driftTestCase(*r.Contract, "lazytest.contract")
+		if tc.Failure != nil {
+			contract.Failures++
+		}
+		contract.Cases = append(contract.Cases, tc)
+	}
+	if contract.Tests = len(contract.Cases); contract.Tests > 0 {
+		root.Suites = append(root.Suites, contract)
+		root.Tests += contract.Tests
+		root.Failures += contract.Failures
+	}
 	data, err := xml.MarshalIndent(root, "", "  ")
 	if err != nil {
 		return err
@@ -87,8 +115,43 @@ func WriteJUnitSmoke(path string, results []core.SmokeResult, duration time.Dura
 	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
 }
 
+// driftTestCase renders one drift result as a testcase; findings become the failure body.
+func driftTestCase(r core.DriftResult, classname string) JUnitTestCase {
+	tc := JUnitTestCase{
+		Name:      r.Method + " " + r.Path,
+		Classname: classname,
+		Time:      "0",
+	}
+	if r.Err != "" {
+		tc.Failure = &JUnitFailure{Message: r.Err, Type: "RequestError", Body: r.Err}
+		return tc
+	}
+	if !r.OK {
+		msg, body := "", ""
+		for _, f := range r.Findings {
+			msg += string(f.Type) + " " + f.Path + "; "
+			body += "[" + string(f.Severity) + "] " + string(f.Type) + " " + f.Path
+			if f.Detail != "" {
+				body += ": " + f.Detail
+			}
+			body += "\n"
+		}
+		typ := "ContractDrift"
+		if r.Breaking() == 0 {
+			typ = "ContractWarning"
+		}
+		tc.Failure = &JUnitFailure{
+			Message: msg,
+			Type:    typ,
+			Body:    body,
+		}
+	}
+	return tc
+}
+
 // WriteJUnitDrift writes drift results to JUnit XML file.
 func WriteJUnitDrift(path string, results []core.DriftResult, duration time.Duration) error {
+	results = redactDrift(results)
 	suite := JUnitTestSuite{
 		Name:  "lazytest-drift",
 		Tests: len(results),
@@ -96,23 +159,53 @@ func WriteJUnitDrift(path string, results []core.DriftResult, duration time.Dura
 	}
 	var failures int
 	for _, r := range results {
-		name := r.Method + " " + r.Path
+		tc := driftTestCase(r, "lazytest.drift")
+		if tc.Failure != nil {
+			failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Failures = failures
+	root := JUnitTestSuites{
+		Name:     "lazytest",
+		Tests:    len(results),
+		Failures: failures,
+		Time:     fmt.Sprintf("%.3f", duration.Seconds()),
+		Suites:   []JUnitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
+
+// WriteJUnitFuzz writes fuzz results to JUnit XML file, one testcase per mutation.
+// The seed is recorded as a suite property so a failing run can be replayed.
+func WriteJUnitFuzz(path string, results []core.FuzzResult, seed int64, duration time.Duration) error {
+	results = redactFuzz(results)
+	suite := JUnitTestSuite{
+		Name:       "lazytest-fuzz",
+		Tests:      len(results),
+		Time:       fmt.Sprintf("%.3f", duration.Seconds()),
+		Properties: []JUnitProperty{{Name: "seed", Value: strconv.FormatInt(seed, 10)}},
+	}
+	var failures int
+	for _, r := range results {
 		tc := JUnitTestCase{
-			Name:      name,
-			Classname: "lazytest.drift",
-			Time:      "0",
+			Name:      fmt.Sprintf("%s %s [%s %s]", r.Method, r.Path, r.Kind, r.Target),
+			Classname: "lazytest.fuzz",
+			Time:      fmt.Sprintf("%.3f", float64(r.LatencyMS)/1000),
 		}
 		if !r.OK {
 			failures++
-			msg := ""
-			for _, f := range r.Findings {
-				msg += string(f.Type) + " " + f.Path + "; "
-			}
-			tc.Failure = &JUnitFailure{
-				Message: msg,
-				Type:    "ContractDrift",
-				Body:    msg,
+			typ := "FuzzFailure"
+			if r.StatusCode >= 500 {
+				typ = "ServerError"
+			} else if r.StatusCode == 0 {
+				typ = "RequestError"
 			}
+			tc.Failure = &JUnitFailure{Message: r.Err, Type: typ, Body: r.Desc}
 		}
 		suite.Cases = append(suite.Cases, tc)
 	}
@@ -121,7 +214,58 @@ func WriteJUnitDrift(path string, results []core.DriftResult, duration time.Dura
 		Name:     "lazytest",
 		Tests:    len(results),
 		Failures: failures,
-		Time:     fmt.Sprintf("%.3f", duration.Seconds()),
+		Time:     suite.Time,
+		Suites:   []JUnitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
+
+// WriteJUnitCompare writes A/B compare results to JUnit XML file, one testcase per endpoint.
+// Endpoints whose sides still differ after compare rules fail; ignored differences do not.
+func WriteJUnitCompare(path string, results []core.ABCompareResult, duration time.Duration) error {
+	results = redactCompare(results)
+	suite := JUnitTestSuite{
+		Name:  "lazytest-compare",
+		Tests: len(results),
+		Time:  fmt.Sprintf("%.3f", duration.Seconds()),
+	}
+	for _, r := range results {
+		tc := JUnitTestCase{Name: r.Name(), Classname: "lazytest.compare", Time: "0"}
+		switch {
+		case r.ErrA != "" || r.ErrB != "":
+			msg := strings.TrimSpace("A: " + r.ErrA + " B: " + r.ErrB)
+			tc.Failure = &JUnitFailure{Message: msg, Type: "RequestError", Body: msg}
+		case r.Differs():
+			var body string
+			for _, d := range r.HeadersDiff {
+				body += "[header] " + d + "\n"
+			}
+			for _, d := range r.BodyStructureDiff {
+				body += "[struct] " + d + "\n"
+			}
+			for _, d := range r.BodyValueDiff {
+				body += "[value] " + d + "\n"
+			}
+			tc.Failure = &JUnitFailure{
+				Message: fmt.Sprintf("status %d vs %d, %d difference(s)", r.StatusA, r.StatusB, len(r.HeadersDiff)+len(r.BodyStructureDiff)+len(r.BodyValueDiff)),
+				Type:    "ABDifference",
+				Body:    body,
+			}
+		}
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	root := JUnitTestSuites{
+		Name:     "lazytest",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
 		Suites:   []JUnitTestSuite{suite},
 	}
 	data, err := xml.MarshalIndent(root, "", "  ")
@@ -152,3 +296,57 @@ func WriteJUnitTCP(path string, result tcp.Result) error {
 	}
 	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
 }
+
+// WriteJUnitSpecDiff writes a spec diff to JUnit XML file, one testcase per changed endpoint.
+// Endpoints with breaking changes fail; endpoints with only non-breaking changes pass with
+// their list as system-out.
+func WriteJUnitSpecDiff(path string, d core.SpecDiff, duration time.Duration) error {
+	suite := JUnitTestSuite{Name: "lazytest-spec-diff", Time: fmt.Sprintf("%.3f", duration.Seconds())}
+	index := map[string]int{}
+	bodies := map[string]string{}
+	breaking := map[string][]string{}
+	for _, c := range d.Changes {
+		name := c.Method + " " + c.Path
+		if _, ok := index[name]; !ok {
+			index[name] = len(suite.Cases)
+			suite.Cases = append(suite.Cases, JUnitTestCase{Name: name, Classname: "lazytest.specdiff", Time: "0"})
+		}
+		line := string(c.Kind)
+		if c.Location != "" {
+			line += " " + c.Location
+		}
+		if c.Detail != "" {
+			line += ": " + c.Detail
+		}
+		if c.Breaking {
+			breaking[name] = append(breaking[name], strings.TrimSpace(string(c.Kind)+" "+c.Location))
+			line = "[breaking] " + line
+		}
+		bodies[name] += line + "\n"
+	}
+	for name, i := range index {
+		if len(breaking[name]) == 0 {
+			suite.Cases[i].SystemOut = bodies[name]
+			continue
+		}
+		suite.Failures++
+		suite.Cases[i].Failure = &JUnitFailure{
+			Message: strings.Join(breaking[name], "; "),
+			Type:    "BreakingChange",
+			Body:    bodies[name],
+		}
+	}
+	suite.Tests = len(suite.Cases)
+	root := JUnitTestSuites{
+		Name:     "lazytest",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []JUnitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"lazytest/internal/core"
+	"lazytest/internal/secrets"
+)
+
+// Reports carry resolved auth secrets only where a request or response is echoed: error
+// messages (request URLs with query credentials), replayed requests, response values and
+// compare bodies. Only those fields are redacted (see secrets.Redact); names, paths and
+// environments of a run are written as they are.
+
+func redactStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = secrets.Redact(s)
+	}
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return secrets.Redact(s)
+	}
+	return v
+}
+
+func redactSmoke(results []core.SmokeResult) []core.SmokeResult {
+	out := make([]core.SmokeResult, len(results))
+	for i, r := range results {
+		r.Err = secrets.Redact(r.Err)
+		if r.Contract != nil {
+			c := redactDrift([]core.DriftResult{*r.Contract})[0]
+			r.Contract = &c
+		}
+		out[i] = r
+	}
+	return out
+}
+
+func redactDrift(results []core.DriftResult) []core.DriftResult {
+	out := make([]core.DriftResult, len(results))
+	for i, r := range results {
+		r.Err = secrets.Redact(r.Err)
+		findings := make([]core.DriftFinding, len(r.Findings))
+		for j, f := range r.Findings {
+			f.Actual = secrets.Redact(f.Actual)
+			findings[j] = f
+		}
+		if r.Findings != nil {
+			r.Findings = findings
+		}
+		out[i] = r
+	}
+	return out
+}
+
+func redactFuzz(results []core.FuzzResult) []core.FuzzResult {
+	out := make([]core.FuzzResult, len(results))
+	for i, r := range results {
+		r.Err = secrets.Redact(r.Err)
+		out[i] = r
+	}
+	return out
+}
+
+func redactCompare(results []core.ABCompareResult) []core.ABCompareResult {
+	out := make([]core.ABCompareResult, len(results))
+	for i, r := range results {
+		r.ErrA, r.ErrB = secrets.Redact(r.ErrA), secrets.Redact(r.ErrB)
+		r.Request = secrets.Redact(r.Request)
+		r.BodyA, r.BodyB = secrets.Redact(r.BodyA), secrets.Redact(r.BodyB)
+		r.HeadersDiff = redactStrings(r.HeadersDiff)
+		r.BodyValueDiff = redactStrings(r.BodyValueDiff)
+		if r.Patch != nil {
+			patch := make([]core.PatchOp, len(r.Patch))
+			for j, op := range r.Patch {
+				op.Value, op.Old = redactValue(op.Value), redactValue(op.Old)
+				patch[j] = op
+			}
+			r.Patch = patch
+		}
+		out[i] = r
+	}
+	return out
+}
+
+// redacted returns a copy of r with the secrets of its results redacted.
+func (r *JSONReport) redacted() *JSONReport {
+	out := *r
+	if r.Smoke != nil {
+		s := *r.Smoke
+		s.Results = redactSmoke(s.Results)
+		out.Smoke = &s
+	}
+	if r.Drift != nil {
+		d := *r.Drift
+		d.Results = redactDrift(d.Results)
+		out.Drift = &d
+	}
+	if r.Fuzz != nil {
+		f := *r.Fuzz
+		f.Results = redactFuzz(f.Results)
+		out.Fuzz = &f
+	}
+	if r.AB != nil {
+		ab := *r.AB
+		ab.Results = redactCompare(ab.Results)
+		out.AB = &ab
+	}
+	return &out
+}
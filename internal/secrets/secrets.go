@@ -0,0 +1,153 @@
+// Package secrets resolves the secret references of auth.yaml (${cmd:...}, ${vault:...}) and
+// keeps the resolved values out of everything lazytest writes: the credential-carrying fields
+// of reports and workspace.json and the desktop log viewer are redacted with Redact.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mask replaces secret values in redacted output.
+const Mask = "***"
+
+// minSecretLen is the length from which a secret is masked wherever it occurs. Shorter
+// secrets (a 6-character API key, a short password) are masked only as a whole word, so
+// they do not mask unrelated text such as environment names or parts of longer words.
+const minSecretLen = 8
+
+var registry = struct {
+	sync.RWMutex
+	values map[string]bool
+	forms  []string // values and their escaped forms, longest first
+}{values: map[string]bool{}}
+
+// Remember registers a resolved secret value for Redact. Every non-empty value is kept,
+// whatever its length (see minSecretLen).
+func Remember(v string) {
+	if v == "" {
+		return
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.values[v] {
+		return
+	}
+	registry.values[v] = true
+	forms := map[string]bool{v: true}
+	// encoding/json escapes <, > and & by default; writers may also turn that off.
+	for _, escapeHTML := range []bool{true, false} {
+		var raw bytes.Buffer
+		enc := json.NewEncoder(&raw)
+		enc.SetEscapeHTML(escapeHTML)
+		if enc.Encode(v) == nil {
+			forms[strings.TrimSuffix(strings.TrimSpace(raw.String()), `"`)[1:]] = true
+		}
+	}
+	var x bytes.Buffer
+	if xml.EscapeText(&x, []byte(v)) == nil {
+		forms[x.String()] = true
+	}
+	forms[template.HTMLEscapeString(v)] = true
+	forms[url.QueryEscape(v)] = true
+	for f := range forms {
+		registry.forms = append(registry.forms, f)
+	}
+	sort.Slice(registry.forms, func(i, j int) bool { return len(registry.forms[i]) > len(registry.forms[j]) })
+}
+
+// Redact replaces every remembered secret in s (also JSON, XML, HTML and URL escaped) with Mask.
+// Secrets shorter than minSecretLen are replaced where they stand as a whole word.
+func Redact(s string) string {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, f := range registry.forms {
+		if !strings.Contains(s, f) {
+			continue
+		}
+		if len(f) >= minSecretLen {
+			s = strings.ReplaceAll(s, f, Mask)
+		} else {
+			s = replaceWord(s, f)
+		}
+	}
+	return s
+}
+
+// replaceWord replaces the occurrences of f in s that are not part of a longer word.
+func replaceWord(s, f string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, f)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := i + len(f)
+		if (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+			b.WriteString(s[:i] + Mask)
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// CommandTimeout bounds a ${cmd:...} credential helper.
+const CommandTimeout = 30 * time.Second
+
+var commands = struct {
+	sync.Mutex
+	out map[string]string
+}{out: map[string]string{}}
+
+// Command runs a credential helper command line with the shell in dir and returns its
+// stdout without the trailing newline. The output is cached for the process and remembered
+// for Redact, so a helper runs once however many requests use it.
+func Command(cmdline, dir string) (string, error) {
+	commands.Lock()
+	defer commands.Unlock()
+	if out, ok := commands.out[cmdline]; ok {
+		return out, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", cmdline)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", cmdline)
+	}
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("${cmd:%s}: %w", cmdline, err)
+		}
+		return "", fmt.Errorf("${cmd:%s}: %w: %s", cmdline, err, msg)
+	}
+	out := strings.TrimSuffix(strings.TrimSuffix(stdout.String(), "\n"), "\r")
+	if out == "" {
+		return "", fmt.Errorf("${cmd:%s}: command printed nothing", cmdline)
+	}
+	commands.out[cmdline] = out
+	Remember(out)
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	defer func(n int) { vaultIterations = n }(vaultIterations)
+	vaultIterations = 1000
+	path := filepath.Join(t.TempDir(), "sub", "secrets.vault")
+	v, err := OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Set("partner/key", "pk-123456")
+	v.Set("admin", "hunter22")
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "pk-123456") || strings.Contains(string(raw), "partner/key") {
+		t.Fatalf("vault file is not encrypted:\n%s", raw)
+	}
+	if st, _ := os.Stat(path); st.Mode().Perm() != 0600 {
+		t.Errorf("vault mode %v, want 0600", st.Mode().Perm())
+	}
+	v, err = OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := v.Get("partner/key"); !ok || got != "pk-123456" {
+		t.Errorf("got %q, %v", got, ok)
+	}
+	if names := v.Names(); len(names) != 2 || names[0] != "admin" {
+		t.Errorf("names %v", names)
+	}
+	if _, err := OpenVault(path, "wrong"); !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("want ErrBadPassphrase, got %v", err)
+	}
+	if _, err := OpenVault(path, ""); err == nil || !strings.Contains(err.Error(), PassphraseEnv) {
+		t.Errorf("want missing passphrase error, got %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	if got, err := VaultSecret(path, "admin"); err != nil || got != "hunter22" {
+		t.Errorf("VaultSecret: %q, %v", got, err)
+	}
+	if _, err := VaultSecret(path, "missing"); err == nil || !strings.Contains(err.Error(), "${vault:missing}") {
+		t.Errorf("want missing secret error, got %v", err)
+	}
+	if got := Redact(`{"password":"hunter22"}`); got != `{"password":"***"}` {
+		t.Errorf("vault value not redacted: %s", got)
+	}
+}
+
+func TestCommandAndRedact(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "token"), []byte("tok<&>\"9\n"), 0600)
+	counter := filepath.Join(dir, "runs")
+	cmdline := "echo x >> " + counter + " && cat token"
+	for i := 0; i < 2; i++ {
+		got, err := Command(cmdline, dir)
+		if err != nil || got != `tok<&>"9` {
+			t.Fatalf("Command: %q, %v", got, err)
+		}
+	}
+	if runs, _ := os.ReadFile(counter); strings.Count(string(runs), "x") != 1 {
+		t.Errorf("credential helper ran %d times, want once", strings.Count(string(runs), "x"))
+	}
+	if _, err := Command("echo oops >&2; exit 3", dir); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("want stderr in error, got %v", err)
+	}
+
+	for in, want := range map[string]string{
+		`Bearer tok<&>"9`:                            "Bearer ***",
+		`{"auth":"Bearer tok<&>\"9"}`:                `{"auth":"Bearer ***"}`,
+		`{"auth":"Bearer tok\u003c\u0026\u003e\"9"}`: `{"auth":"Bearer ***"}`,
+		`<v>tok&lt;&amp;&gt;&#34;9</v>`:              `<v>***</v>`,
+		`GET /x?key=tok%3C%26%3E%229: EOF`:           `GET /x?key=***: EOF`,
+		"abc":                                        "abc",
+	} {
+		if got := Redact(in); got != want {
+			t.Errorf("Redact(%s) = %s, want %s", in, got, want)
+		}
+	}
+	Remember("k3y9")
+	for in, want := range map[string]string{
+		"GET /x?api_key=k3y9&n=1": "GET /x?api_key=***&n=1",
+		`{"X-Api-Key":"k3y9"}`:    `{"X-Api-Key":"***"}`,
+		"Bearer k3y9":             "Bearer ***",
+		"k3y90 ak3y9 k3y9-x":      "k3y90 ak3y9 k3y9-x",
+	} {
+		if got := Redact(in); got != want {
+			t.Errorf("short secret: Redact(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// PassphraseEnv is the environment variable holding the vault passphrase.
+const PassphraseEnv = "LAZYTEST_VAULT_PASSPHRASE"
+
+// ErrBadPassphrase is returned when a vault does not decrypt with the given passphrase.
+var ErrBadPassphrase = errors.New("wrong vault passphrase or corrupted vault")
+
+// vaultIterations is the PBKDF2-SHA256 work factor of new vault files.
+var vaultIterations = 600000
+
+// Vault is a local file of named secrets, encrypted with AES-256-GCM under a key derived
+// from a passphrase (PBKDF2-SHA256). The file is JSON:
+//
+//	{"version": 1, "kdf": "pbkdf2-sha256", "iterations": 600000, "salt": "...", "nonce": "...", "data": "..."}
+type Vault struct {
+	path       string
+	passphrase string
+	entries    map[string]string
+}
+
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// OpenVault decrypts the vault at path. A missing file is an empty vault that Save creates.
+func OpenVault(path, passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("vault %s: no passphrase (set %s)", path, PassphraseEnv)
+	}
+	v := &Vault{path: path, passphrase: passphrase, entries: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	var f vaultFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	if f.Version != 1 || f.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("vault %s: unsupported format %d/%s", path, f.Version, f.KDF)
+	}
+	aead, err := vaultCipher(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("vault %s: %w", path, ErrBadPassphrase)
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, ErrBadPassphrase)
+	}
+	if err := json.Unmarshal(plain, &v.entries); err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// Get returns the secret called name.
+func (v *Vault) Get(name string) (string, bool) {
+	s, ok := v.entries[name]
+	return s, ok
+}
+
+// Set stores value under name; Save writes it.
+func (v *Vault) Set(name, value string) {
+	v.entries[name] = value
+}
+
+// Delete removes name and reports whether it existed.
+func (v *Vault) Delete(name string) bool {
+	_, ok := v.entries[name]
+	delete(v.entries, name)
+	return ok
+}
+
+// Names lists the secret names, sorted.
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.entries))
+	for n := range v.entries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts the vault with a fresh salt and nonce and writes it readable by the owner only.
+func (v *Vault) Save() error {
+	plain, err := json.Marshal(v.entries)
+	if err != nil {
+		return err
+	}
+	f := vaultFile{Version: 1, KDF: "pbkdf2-sha256", Iterations: vaultIterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := vaultCipher(v.passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plain, nil)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(v.path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(v.path, data, 0600)
+}
+
+func vaultCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 {
+		return nil, fmt.Errorf("bad iteration count %d", iterations)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var vaults = struct {
+	sync.Mutex
+	open map[string]*Vault
+}{open: map[string]*Vault{}}
+
+// VaultSecret returns the secret called name from the vault at path, unlocked with the
+// passphrase of PassphraseEnv. The vault is decrypted once per process and the value is
+// remembered for Redact.
+func VaultSecret(path, name string) (string, error) {
+	vaults.Lock()
+	defer vaults.Unlock()
+	v, ok := vaults.open[path]
+	if !ok {
+		var err error
+		if v, err = OpenVault(path, os.Getenv(PassphraseEnv)); err != nil {
+			return "", fmt.Errorf("${vault:%s}: %w", name, err)
+		}
+		vaults.open[path] = v
+	}
+	s, ok := v.Get(name)
+	if !ok {
+		return "", fmt.Errorf("${vault:%s}: no such secret in %s", name, path)
+	}
+	Remember(s)
+	return s, nil
+}